@@ -0,0 +1,491 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	accountObjectType = "account"
+	accountOwnerType  = "accountOwner"
+
+	accountRequestObjectType = "accountRequest"
+
+	// adminAttribute is the enrollment attribute that grants admin rights
+	adminAttribute = "role"
+	adminRole      = "admin"
+
+	operatorConfigID = "operator"
+)
+
+// OperatorConfig names the organization operating the network, whose admins alone may run
+// destructive maintenance such as purging records
+type OperatorConfig struct {
+	Schema
+	MSP       string `json:"msp"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// assertMerchantMSP checks that the caller belongs to the organization registered for the merchant
+func assertMerchantMSP(ctx TransactionContext, merchant string) error {
+	mspID, err := getMerchantMSP(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return newError(ErrInternal, "failed to get client MSP ID. %s", err.Error())
+	}
+
+	if clientMSPID != mspID {
+		return newError(ErrUnauthorized, "client from %s is not authorized to issue points for merchant %s", clientMSPID, merchant)
+	}
+
+	return nil
+}
+
+// assertCanSend checks that the caller may send points from senderKey: merchants may
+// only be debited by their registered organization, customers only by their own identity
+func assertCanSend(ctx TransactionContext, senderKey string, merchant string) error {
+	sender, err := createMember(ctx, senderKey, merchant)
+	if err != nil {
+		return err
+	}
+
+	if sender.Merchant == "" {
+		return assertMerchantMSP(ctx, sender.ID)
+	}
+
+	return assertAccountOwner(ctx, sender.ID)
+}
+
+// AccountRequest is a request of an enrollment identity to be bound to a customer account,
+// which takes effect when the customer's merchant or an admin approves it
+type AccountRequest struct {
+	Schema
+	Member      string `json:"member"`
+	ClientID    string `json:"clientID"`
+	ClientMSP   string `json:"clientMSP"`
+	RequestedAt string `json:"requested_at"`
+}
+
+// RegisterAccount requests binding the caller's enrollment identity to a customer account.
+// The binding takes effect once MerchantContract:ApproveAccount approves it.
+func (s *PointsContract) RegisterAccount(ctx TransactionContext, memberID string) error {
+	clientID, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = assertCustomerAccount(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	err = assertCanBind(ctx, memberID, clientID)
+	if err != nil {
+		return err
+	}
+
+	bound, err := getAccountIdentity(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	if bound == clientID {
+		return nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	request := AccountRequest{
+		Member:      memberID,
+		ClientID:    clientID,
+		ClientMSP:   mspID,
+		RequestedAt: now.Format(time.RFC3339),
+	}
+
+	return putCompositeObject(ctx, accountRequestObjectType, []string{memberID, clientID}, &request)
+}
+
+// GetAccountRequests returns the pending requests to bind an identity to a customer account
+func (s *MerchantContract) GetAccountRequests(ctx TransactionContext, memberID string) ([]*AccountRequest, error) {
+	err := assertAccountApprover(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accountRequestObjectType, []string{memberID})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	requests := []*AccountRequest{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var request AccountRequest
+		err = unmarshalState(accountRequestObjectType, queryResponse.Key, queryResponse.Value, &request)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, &request)
+	}
+
+	return requests, nil
+}
+
+// ApproveAccount binds the identity of a pending request to the customer account. Only an admin
+// or the organization of the customer's merchant may approve it.
+func (s *MerchantContract) ApproveAccount(ctx TransactionContext, memberID string, clientID string) error {
+	err := assertAccountApprover(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	var request AccountRequest
+	found, err := getCompositeObject(ctx, accountRequestObjectType, []string{memberID, clientID}, &request)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return newError(ErrNotFound, "no request to bind %s to %s", clientID, memberID)
+	}
+
+	err = assertCanBind(ctx, memberID, clientID)
+	if err != nil {
+		return err
+	}
+
+	err = deleteAccountRequest(ctx, memberID, clientID)
+	if err != nil {
+		return err
+	}
+
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountObjectType, []string{clientID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	ownerKey, err := ctx.GetStub().CreateCompositeKey(accountOwnerType, []string{memberID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().PutState(accountKey, []byte(memberID))
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	err = ctx.GetStub().PutState(ownerKey, []byte(clientID))
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// RejectAccount discards a pending request to bind an identity to a customer account
+func (s *MerchantContract) RejectAccount(ctx TransactionContext, memberID string, clientID string) error {
+	err := assertAccountApprover(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	var request AccountRequest
+	found, err := getCompositeObject(ctx, accountRequestObjectType, []string{memberID, clientID}, &request)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return newError(ErrNotFound, "no request to bind %s to %s", clientID, memberID)
+	}
+
+	return deleteAccountRequest(ctx, memberID, clientID)
+}
+
+func deleteAccountRequest(ctx TransactionContext, memberID string, clientID string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(accountRequestObjectType, []string{memberID, clientID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// assertCustomerAccount checks that the member is not a merchant, whose points are
+// only spent by the merchant's organization and cannot be bound to an identity
+func assertCustomerAccount(ctx TransactionContext, memberID string) error {
+	_, err := getMerchant(ctx, memberID)
+	if err == nil {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot be bound to an identity", memberID)
+	}
+
+	if !hasErrorCode(err, ErrNotFound) {
+		return err
+	}
+
+	member, err := getMember(ctx, memberID)
+	if err != nil && !hasErrorCode(err, ErrNotFound) {
+		return err
+	}
+
+	if err == nil && member.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot be bound to an identity", memberID)
+	}
+
+	return nil
+}
+
+// assertCanBind checks that neither the member nor the identity is bound to another account
+func assertCanBind(ctx TransactionContext, memberID string, clientID string) error {
+	bound, err := getAccountIdentity(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	if bound != "" && bound != clientID {
+		return newError(ErrAlreadyExists, "%s is already bound to another identity", memberID)
+	}
+
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountObjectType, []string{clientID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	current, err := ctx.GetStub().GetState(accountKey)
+	if err != nil {
+		return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	if current != nil && string(current) != memberID {
+		return newError(ErrAlreadyExists, "client identity is already bound to %s", string(current))
+	}
+
+	return nil
+}
+
+// assertAccountApprover checks that the caller may approve bindings to the customer account:
+// admins always may, the organization of the customer's merchant once the customer exists
+func assertAccountApprover(ctx TransactionContext, memberID string) error {
+	if isAdmin(ctx) {
+		return nil
+	}
+
+	member, err := getMember(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	if member.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot be bound to an identity", memberID)
+	}
+
+	return assertMerchantMSP(ctx, member.Merchant)
+}
+
+// GetMyAccount returns the member account bound to the caller's identity
+func (s *PointsContract) GetMyAccount(ctx TransactionContext) (string, error) {
+	return getMyAccount(ctx)
+}
+
+// getMyAccount returns the member account bound to the caller's identity, an error if none is
+func getMyAccount(ctx TransactionContext) (string, error) {
+	account, err := getCallerAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if account == "" {
+		return "", newError(ErrNotFound, "no account registered for the client identity")
+	}
+
+	return account, nil
+}
+
+// getCallerAccount returns the member account bound to the caller's identity, or "" if none
+func getCallerAccount(ctx TransactionContext) (string, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", newError(ErrInternal, "failed to get client identity. %s", err.Error())
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(accountObjectType, []string{clientID})
+	if err != nil {
+		return "", newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	return string(bytes), nil
+}
+
+// getAccountIdentity returns the identity bound to a member, or "" if none
+func getAccountIdentity(ctx TransactionContext, memberID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(accountOwnerType, []string{memberID})
+	if err != nil {
+		return "", newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	return string(bytes), nil
+}
+
+// assertAccountOwner checks that the caller is bound to the member, admins are always allowed
+func assertAccountOwner(ctx TransactionContext, memberID string) error {
+	if isAdmin(ctx) {
+		return nil
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return newError(ErrInternal, "failed to get client identity. %s", err.Error())
+	}
+
+	bound, err := getAccountIdentity(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	if bound == "" || bound != clientID {
+		return newError(ErrUnauthorized, "client is not authorized to spend points of %s", memberID)
+	}
+
+	return nil
+}
+
+// getClient returns the enrollment ID and MSP ID of the caller
+func getClient(ctx TransactionContext) (string, string, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", "", newError(ErrInternal, "failed to get client identity. %s", err.Error())
+	}
+
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", "", newError(ErrInternal, "failed to get client MSP ID. %s", err.Error())
+	}
+
+	return clientID, mspID, nil
+}
+
+// isAdmin reports whether the caller was enrolled with the admin role
+func isAdmin(ctx TransactionContext) bool {
+	return assertAdmin(ctx) == nil
+}
+
+// assertAdmin checks that the caller was enrolled with the admin role
+func assertAdmin(ctx TransactionContext) error {
+	err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, adminRole)
+	if err != nil {
+		return newError(ErrUnauthorized, "client is not authorized to perform admin operations. %s", err.Error())
+	}
+
+	return nil
+}
+
+// SetOperatorMSP sets the operator organization. Once set, only admins of the operator may change it.
+func (s *AdminContract) SetOperatorMSP(ctx TransactionContext, mspID string) error {
+	config, err := getOperatorConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.MSP != "" {
+		err = assertOperator(ctx)
+	} else {
+		err = assertAdmin(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	config.MSP = mspID
+	config.UpdatedBy = clientID
+	config.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, configObjectType, operatorConfigID, config)
+}
+
+// GetOperatorMSP returns the operator organization, "" if none was set
+func (s *AdminContract) GetOperatorMSP(ctx TransactionContext) (string, error) {
+	config, err := getOperatorConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.MSP, nil
+}
+
+func getOperatorConfig(ctx TransactionContext) (*OperatorConfig, error) {
+	var config OperatorConfig
+	_, err := getObject(ctx, configObjectType, operatorConfigID, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// assertOperator checks that the caller is an admin of the operator organization
+func assertOperator(ctx TransactionContext) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	config, err := getOperatorConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.MSP == "" {
+		return newError(ErrInvalidState, "no operator organization is set")
+	}
+
+	_, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if mspID != config.MSP {
+		return newError(ErrUnauthorized, "client from %s is not an admin of the operator %s", mspID, config.MSP)
+	}
+
+	return nil
+}
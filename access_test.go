@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransactionRequiresMerchantMSP(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", "Order", "o1")
+	requireErrorCode(t, err, ErrNotFound)
+
+	env.registerMerchant("m1")
+
+	_, err = env.points.CreateTransaction(env.ctx(otherMSPIdentity), "t2", "m1", "alice", 10, "m1", "", "Order", "o2")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t3", "m1", "alice", 10, "m1", "", "Order", "o3")
+	require.NoError(t, err)
+}
+
+func TestRegisterAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	alice := customerIdentity("alice")
+
+	_, err := env.points.GetMyAccount(env.ctx(alice))
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.points.RegisterAccount(env.ctx(alice), "alice"))
+	_, err = env.points.GetMyAccount(env.ctx(alice))
+	requireErrorCode(t, err, ErrNotFound)
+
+	requests, err := env.merchants.GetAccountRequests(env.ctx(merchantIdentity), "alice")
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, alice.id, requests[0].ClientID)
+	require.Equal(t, "Org1MSP", requests[0].ClientMSP)
+
+	err = env.merchants.ApproveAccount(env.ctx(otherMSPIdentity), "alice", alice.id)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.merchants.ApproveAccount(env.ctx(merchantIdentity), "alice", alice.id))
+	require.NoError(t, env.points.RegisterAccount(env.ctx(alice), "alice"), "registering again is a no-op")
+
+	account, err := env.points.GetMyAccount(env.ctx(alice))
+	require.NoError(t, err)
+	require.Equal(t, "alice", account)
+
+	err = env.merchants.ApproveAccount(env.ctx(merchantIdentity), "alice", alice.id)
+	requireErrorCode(t, err, ErrNotFound)
+
+	err = env.points.RegisterAccount(env.ctx(customerIdentity("mallory")), "alice")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	err = env.points.RegisterAccount(env.ctx(alice), "bob")
+	requireErrorCode(t, err, ErrAlreadyExists)
+}
+
+func TestRegisterAccountRefusesMerchants(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	mallory := customerIdentity("mallory")
+
+	err := env.points.RegisterAccount(env.ctx(mallory), "m1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.ApproveAccount(env.ctx(merchantIdentity), "m1", mallory.id)
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestRejectAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	mallory := customerIdentity("mallory")
+
+	require.NoError(t, env.points.RegisterAccount(env.ctx(mallory), "alice"))
+	require.NoError(t, env.merchants.RejectAccount(env.ctx(merchantIdentity), "alice", mallory.id))
+
+	requests, err := env.merchants.GetAccountRequests(env.ctx(merchantIdentity), "alice")
+	require.NoError(t, err)
+	require.Empty(t, requests)
+
+	err = env.merchants.ApproveAccount(env.ctx(merchantIdentity), "alice", mallory.id)
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestCreateTransactionRequiresAccountOwner(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "r1", "alice", "m1", 10, "m1", "", "Redemption", "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	env.registerAccount("alice")
+	_, err = env.points.CreateTransaction(env.ctx(customerIdentity("bob")), "r2", "alice", "m1", 10, "m1", "", "Redemption", "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.CreateTransaction(env.ctx(adminIdentity), "r3", "alice", "m1", 10, "m1", "", "Redemption", "")
+	require.NoError(t, err, "admins may spend for customers")
+}
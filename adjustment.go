@@ -0,0 +1,244 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const adjustmentObjectType = "adjustment"
+
+// Adjustment statuses
+const (
+	AdjustmentPending  = "pending"
+	AdjustmentApproved = "approved"
+	AdjustmentRejected = "rejected"
+)
+
+// Adjustment is a manual change of a member's points which needs a second admin to approve it
+type Adjustment struct {
+	Schema
+	ID          string `json:"ID"`
+	Member      string `json:"member"`
+	Merchant    string `json:"merchant"`
+	Value       int    `json:"value"`
+	Reason      string `json:"reason"`
+	Status      string `json:"status"`
+	ProposedBy  string `json:"proposedBy"`
+	ProposerMSP string `json:"proposerMSP"`
+	ReviewedBy  string `json:"reviewedBy"`
+	ReviewerMSP string `json:"reviewerMSP"`
+	CreatedAt   string `json:"created_at"`
+	ReviewedAt  string `json:"reviewed_at"`
+	// Transaction is the adjustment transaction recorded when it was approved
+	Transaction string `json:"transaction,omitempty" metadata:"transaction,optional"`
+}
+
+// ProposeAdjustment records a pending adjustment of value points, which may be negative
+func (s *AdminContract) ProposeAdjustment(ctx TransactionContext, id string, memberKey string, merchant string, value int, reason string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if value == 0 {
+		return newError(ErrInvalidArgument, "adjustment value must not be zero")
+	}
+
+	var existing Adjustment
+	found, err := getObject(ctx, adjustmentObjectType, id, &existing)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return newError(ErrAlreadyExists, "adjustment %s already exists", id)
+	}
+
+	_, err = getMember(ctx, memberKey)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantActive(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	clientID, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	adjustment := Adjustment{
+		ID:          id,
+		Member:      memberKey,
+		Merchant:    merchant,
+		Value:       value,
+		Reason:      reason,
+		Status:      AdjustmentPending,
+		ProposedBy:  clientID,
+		ProposerMSP: mspID,
+		CreatedAt:   now.Format(time.RFC3339),
+	}
+
+	err = putObjectOwnerIndex(ctx, adjustmentObjectType, id, memberKey)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, adjustmentObjectType, id, &adjustment)
+}
+
+// ApproveAdjustment applies a pending adjustment to the member's points of the merchant and to the
+// outstanding points of the merchant, recording an adjustment transaction. A credit is sent by the
+// merchant and a debit by the member, and both count as points issued in the settlement reports.
+func (s *AdminContract) ApproveAdjustment(ctx TransactionContext, id string) error {
+	adjustment, err := s.reviewAdjustment(ctx, id, AdjustmentApproved)
+	if err != nil {
+		return err
+	}
+
+	member, err := getMember(ctx, adjustment.Member)
+	if err != nil {
+		return err
+	}
+
+	// A debit cannot take more than the untyped points the member holds of the merchant
+	if member.MerchantPoints[adjustment.Merchant]-typedPointsOf(member, adjustment.Merchant)+adjustment.Value < 0 {
+		return newError(ErrInsufficientPoints, "%s does not have enough points of %s", member.ID, adjustment.Merchant)
+	}
+
+	merchant, err := createMember(ctx, adjustment.Merchant, adjustment.Merchant)
+	if err != nil {
+		return err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     adjustment.Value,
+		Merchant:  adjustment.Merchant,
+		CreatedAt: adjustment.ReviewedAt,
+		Sender:    adjustment.Merchant,
+		Receiver:  adjustment.Member,
+		Source:    &Source{Type: TypeAdjustment, ID: adjustment.ID},
+		Status:    StatusConfirmed,
+		Reason:    adjustment.Reason,
+	}
+
+	if adjustment.Value < 0 {
+		transaction.Value = -adjustment.Value
+		transaction.Sender, transaction.Receiver = adjustment.Member, adjustment.Merchant
+	}
+
+	err = emitEvent(ctx, pointsTransferredEvent, &PointsTransferredEvent{
+		Transaction: transaction.ID,
+		Type:        transactionType(&transaction),
+		Merchant:    transaction.Merchant,
+		Sender:      transaction.Sender,
+		Receiver:    transaction.Receiver,
+		Value:       transaction.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	member.Points += adjustment.Value
+	member.MerchantPoints[adjustment.Merchant] += adjustment.Value
+	member.Transaction = &transaction
+	merchant.Points += adjustment.Value
+
+	for _, m := range []*Member{member, merchant} {
+		err = putMember(ctx, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putTransaction(ctx, &transaction)
+	if err != nil {
+		return err
+	}
+
+	err = recordSettlement(ctx, adjustment.Merchant, settlementIssued, transaction.ID, adjustment.Value)
+	if err != nil {
+		return err
+	}
+
+	err = updateProgramStats(ctx, adjustment.Merchant, programAdjusted, adjustment.Value)
+	if err != nil {
+		return err
+	}
+
+	adjustment.Transaction = transaction.ID
+	return putObject(ctx, adjustmentObjectType, id, adjustment)
+}
+
+// RejectAdjustment closes a pending adjustment without changing any balance
+func (s *AdminContract) RejectAdjustment(ctx TransactionContext, id string) error {
+	adjustment, err := s.reviewAdjustment(ctx, id, AdjustmentRejected)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, adjustmentObjectType, id, adjustment)
+}
+
+// GetAdjustment returns the adjustment stored in the world state with given id
+func (s *AdminContract) GetAdjustment(ctx TransactionContext, id string) (*Adjustment, error) {
+	var adjustment Adjustment
+	found, err := getObject(ctx, adjustmentObjectType, id, &adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "adjustment %s does not exist in world state", id)
+	}
+
+	return &adjustment, nil
+}
+
+// reviewAdjustment checks that the caller may review the pending adjustment and marks it with status
+func (s *AdminContract) reviewAdjustment(ctx TransactionContext, id string, status string) (*Adjustment, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment, err := s.GetAdjustment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if adjustment.Status != AdjustmentPending {
+		return nil, newError(ErrInvalidState, "adjustment %s is already %s", id, adjustment.Status)
+	}
+
+	clientID, mspID, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dual control: the reviewer must be another admin from another organization
+	if clientID == adjustment.ProposedBy || mspID == adjustment.ProposerMSP {
+		return nil, newError(ErrUnauthorized, "adjustment %s must be reviewed by an admin of another organization", id)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment.Status = status
+	adjustment.ReviewedBy = clientID
+	adjustment.ReviewerMSP = mspID
+	adjustment.ReviewedAt = now.Format(time.RFC3339)
+
+	return adjustment, nil
+}
@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeAdjustment(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", 5, "goodwill"))
+
+	adjustment, err := env.admin.GetAdjustment(env.ctx(adminIdentity), "a1")
+	require.NoError(t, err)
+	require.Equal(t, AdjustmentPending, adjustment.Status)
+	require.Equal(t, "admin", adjustment.ProposedBy)
+	require.Equal(t, 10, env.balance("alice", "m1"), "a pending adjustment changes no balance")
+
+	err = env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", 5, "goodwill")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	err = env.admin.ProposeAdjustment(env.ctx(merchantIdentity), "a2", "alice", "m1", 5, "goodwill")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a3", "alice", "m1", 0, "nothing")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a4", "nobody", "m1", 5, "goodwill")
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.admin.GetAdjustment(env.ctx(adminIdentity), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestApproveAdjustment(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", -4, "correction"))
+
+	err := env.admin.ApproveAdjustment(env.ctx(adminIdentity), "a1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.ApproveAdjustment(env.ctx(otherAdmin), "a1"))
+	require.Equal(t, 6, env.balance("alice", "m1"))
+	require.Equal(t, 6, env.member("m1").Points)
+
+	adjustment, err := env.admin.GetAdjustment(env.ctx(adminIdentity), "a1")
+	require.NoError(t, err)
+	require.Equal(t, AdjustmentApproved, adjustment.Status)
+	require.Equal(t, "Org2MSP", adjustment.ReviewerMSP)
+
+	// The debit is recorded as a transaction of the member, in the settlement report and in the program statistics
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), adjustment.Transaction)
+	require.NoError(t, err)
+	require.Equal(t, TypeAdjustment, transaction.Source.Type)
+	require.Equal(t, "a1", transaction.Source.ID)
+	require.Equal(t, "alice", transaction.Sender)
+	require.Equal(t, 4, transaction.Value)
+	require.Equal(t, "correction", transaction.Reason)
+
+	report, err := env.merchants.GetSettlementReport(env.ctx(adminIdentity), "m1", "2024-03", "2024-03")
+	require.NoError(t, err)
+	require.Equal(t, 6, report.Total.Issued)
+
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 6, stats.Outstanding)
+
+	err = env.admin.ApproveAdjustment(env.ctx(otherAdmin), "a1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	// A credit is sent by the merchant
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a2", "alice", "m1", 5, "goodwill"))
+	require.NoError(t, env.admin.ApproveAdjustment(env.ctx(otherAdmin), "a2"))
+	require.Equal(t, 11, env.balance("alice", "m1"))
+	require.Equal(t, 11, env.member("m1").Points)
+
+	adjustment, err = env.admin.GetAdjustment(env.ctx(adminIdentity), "a2")
+	require.NoError(t, err)
+	transaction, err = env.points.GetTransaction(env.ctx(adminIdentity), adjustment.Transaction)
+	require.NoError(t, err)
+	require.Equal(t, "m1", transaction.Sender)
+	require.Equal(t, 5, transaction.Value)
+}
+
+func TestApproveAdjustmentInsufficientPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.registerMerchant("m2")
+	env.reward("m2", "alice", 50)
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", -11, "correction"))
+
+	// Points of another merchant do not cover a debit of m1
+	err := env.admin.ApproveAdjustment(env.ctx(otherAdmin), "a1")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+	require.Equal(t, 10, env.balance("alice", "m1"))
+}
+
+func TestRejectAdjustment(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", 5, "goodwill"))
+
+	err := env.admin.RejectAdjustment(env.ctx(merchantIdentity), "a1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.RejectAdjustment(env.ctx(otherAdmin), "a1"))
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	err = env.admin.RejectAdjustment(env.ctx(otherAdmin), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
@@ -0,0 +1,117 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// allowanceObjectType stores the points a spender may still transfer from an owner
+const allowanceObjectType = "allowance"
+
+// Approve lets spender transfer up to value points from the owner's account, replacing any
+// previous allowance. An allowance of 0 revokes it.
+func (s *PointsContract) Approve(ctx TransactionContext, owner string, spender string, value int) error {
+	if value < 0 {
+		return newError(ErrInvalidArgument, "allowance must not be negative")
+	}
+
+	if owner == spender {
+		return newError(ErrInvalidArgument, "%s cannot approve itself", owner)
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertTokenHolder(member)
+	if err != nil {
+		return err
+	}
+
+	err = assertCanSend(ctx, owner, member.Merchant)
+	if err != nil {
+		return err
+	}
+
+	return putCompositeObject(ctx, allowanceObjectType, []string{owner, spender}, value)
+}
+
+// GetAllowance returns the points spender may still transfer from the owner's account
+func (s *PointsContract) GetAllowance(ctx TransactionContext, owner string, spender string) (int, error) {
+	return getAllowance(ctx, owner, spender)
+}
+
+// TransferFrom moves value points from the owner's account to another member on behalf of the
+// spender, who must be the caller's account, and decrements the spender's allowance. It returns the
+// key of the transaction, or of the transaction of an earlier submission with the same idempotency token.
+func (s *PointsContract) TransferFrom(ctx TransactionContext, spender string, owner string, to string, value int) (string, error) {
+	return idempotentCreate(ctx, func() (string, error) {
+		return ctx.GetStub().GetTxID(), transferFrom(ctx, spender, owner, to, value)
+	})
+}
+
+func transferFrom(ctx TransactionContext, spender string, owner string, to string, value int) error {
+	account, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	if account == "" || account != spender {
+		return newError(ErrUnauthorized, "client is not authorized to spend on behalf of %s", spender)
+	}
+
+	allowance, err := getAllowance(ctx, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	if allowance < value {
+		return newError(ErrInsufficientPoints, "%s may only transfer %d points of %s", spender, allowance, owner)
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertTokenHolder(member)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     value,
+		Merchant:  member.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    owner,
+		Receiver:  to,
+		Source:    &Source{Type: TypeAllowance, ID: spender},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return err
+	}
+
+	return putCompositeObject(ctx, allowanceObjectType, []string{owner, spender}, allowance-value)
+}
+
+// getAllowance returns the points spender may still transfer from owner, 0 if none were approved
+func getAllowance(ctx TransactionContext, owner string, spender string) (int, error) {
+	var allowance int
+	_, err := getCompositeObject(ctx, allowanceObjectType, []string{owner, spender}, &allowance)
+	if err != nil {
+		return 0, err
+	}
+
+	return allowance, nil
+}
@@ -0,0 +1,65 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprove(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	require.NoError(t, env.points.Approve(env.ctx(alice), "alice", "bob", 30))
+
+	allowance, err := env.points.GetAllowance(env.ctx(alice), "alice", "bob")
+	require.NoError(t, err)
+	require.Equal(t, 30, allowance)
+
+	allowance, err = env.points.GetAllowance(env.ctx(alice), "alice", "carol")
+	require.NoError(t, err)
+	require.Equal(t, 0, allowance)
+
+	err = env.points.Approve(env.ctx(customerIdentity("bob")), "alice", "bob", 100)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.Approve(env.ctx(alice), "alice", "alice", 10)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.Approve(env.ctx(alice), "alice", "bob", -1)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.Approve(env.ctx(alice), "nobody", "bob", 10)
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestTransferFrom(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "carol", 1)
+	alice := env.registerAccount("alice")
+	bob := env.registerAccount("bob")
+	require.NoError(t, env.points.Approve(env.ctx(alice), "alice", "bob", 30))
+
+	_, err := env.points.TransferFrom(env.ctx(bob), "bob", "alice", "carol", 20)
+	require.NoError(t, err)
+	require.Equal(t, 80, env.balance("alice", "m1"))
+	require.Equal(t, 21, env.balance("carol", "m1"))
+
+	allowance, err := env.points.GetAllowance(env.ctx(bob), "alice", "bob")
+	require.NoError(t, err)
+	require.Equal(t, 10, allowance)
+
+	_, err = env.points.TransferFrom(env.ctx(bob), "bob", "alice", "carol", 11)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.TransferFrom(env.ctx(alice), "bob", "alice", "carol", 5)
+	requireErrorCode(t, err, ErrUnauthorized)
+}
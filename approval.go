@@ -0,0 +1,155 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// SetApprovalPolicy makes issuance of more than threshold points by a merchant wait for
+// approvals from the given number of other organizations. A threshold of 0 disables it.
+func (s *MerchantContract) SetApprovalPolicy(ctx TransactionContext, merchantID string, threshold int, approvals int) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if threshold < 0 {
+		return newError(ErrInvalidArgument, "threshold must not be negative")
+	}
+
+	if threshold > 0 && approvals <= 0 {
+		return newError(ErrInvalidArgument, "at least one approval must be required")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.ApprovalThreshold = threshold
+	merchant.Program.RequiredApprovals = approvals
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// ApproveTransaction records the approval of a pending issuance by the caller's organization
+// and credits the points once enough organizations approved
+func (s *AdminContract) ApproveTransaction(ctx TransactionContext, id string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := getTransaction(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if transactionStatus(transaction) != StatusPending {
+		return newError(ErrInvalidState, "transaction %s is not pending", id)
+	}
+
+	merchant, err := getMerchant(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	_, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if mspID == merchant.MSP {
+		return newError(ErrUnauthorized, "transaction %s must be approved by organizations other than %s", id, mspID)
+	}
+
+	for _, approver := range transaction.Approvals {
+		if approver == mspID {
+			return newError(ErrAlreadyExists, "%s already approved transaction %s", mspID, id)
+		}
+	}
+
+	transaction.Approvals = append(transaction.Approvals, mspID)
+
+	if len(transaction.Approvals) >= merchant.Program.RequiredApprovals {
+		err = assertMerchantActive(ctx, transaction.Merchant)
+		if err != nil {
+			return err
+		}
+
+		err = transitionStatus(transaction, StatusConfirmed)
+		if err != nil {
+			return err
+		}
+
+		err = applyTransaction(ctx, transaction, transaction.Value, transaction.Merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putTransaction(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	err = accrueCommission(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	return rewardReferral(ctx, transaction)
+}
+
+// holdForApproval stores an issuance above the merchant's approval threshold as pending
+// instead of applying it. It reports whether the transaction was held.
+func holdForApproval(ctx TransactionContext, transaction *PointsTransaction) (bool, error) {
+	merchant, err := getMerchant(ctx, transaction.Sender)
+	if hasErrorCode(err, ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	threshold := merchant.Program.ApprovalThreshold
+	if threshold <= 0 || transaction.Value <= threshold {
+		return false, nil
+	}
+
+	transaction.Status = StatusPending
+
+	err = putTransaction(ctx, transaction)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// assertOwnIssuance checks that a merchant sending points sends them as points of its own program,
+// so that its approval threshold and order rewards apply to them
+func assertOwnIssuance(ctx TransactionContext, transaction *PointsTransaction) error {
+	if transaction.Sender == transaction.Merchant {
+		return nil
+	}
+
+	_, err := getMerchant(ctx, transaction.Sender)
+	if hasErrorCode(err, ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return newError(ErrInvalidArgument, "merchant %s can only issue its own points, not points of %s", transaction.Sender, transaction.Merchant)
+}
@@ -0,0 +1,78 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetApprovalPolicy(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	err := env.merchants.SetApprovalPolicy(env.ctx(merchantIdentity), "m1", 100, 1)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "m1", 100, 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "unknown", 100, 1)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "m1", 100, 1))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 100, merchant.Program.ApprovalThreshold)
+}
+
+func TestApproveTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 1)
+	require.NoError(t, env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "m1", 100, 1))
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 150, "m1", "", TypeOrder, "o1")
+	require.NoError(t, err)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), "t1")
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, transaction.Status)
+	require.Equal(t, 1, env.balance("alice", "m1"))
+
+	err = env.admin.ApproveTransaction(env.ctx(adminIdentity), "t1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.ApproveTransaction(env.ctx(otherAdmin), "t1"))
+	require.Equal(t, 151, env.balance("alice", "m1"))
+
+	err = env.admin.ApproveTransaction(env.ctx(otherAdmin), "t1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.admin.ApproveTransaction(env.ctx(otherAdmin), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 100, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err)
+	require.Equal(t, 251, env.balance("alice", "m1"), "issuance up to the threshold is not held")
+}
+
+func TestApprovalThresholdAppliesToOtherMerchants(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	env.reward("m1", "alice", 1)
+	require.NoError(t, env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "m1", 100, 1))
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 150, "m2", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+	require.Equal(t, 1, env.balance("alice", "m1"))
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 150, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err)
+	require.Equal(t, 1, env.balance("alice", "m1"), "held for approval")
+}
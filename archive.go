@@ -0,0 +1,181 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	// periodSummaryObjectType stores the archived transactions of a member, keyed by merchant, member and cutoff
+	periodSummaryObjectType = "periodSummary"
+
+	periodArchivedEvent = "PeriodArchived"
+)
+
+// PeriodSummary rolls up the archived transactions of a member with a merchant before a cutoff
+type PeriodSummary struct {
+	Schema
+	Owner        string `json:"owner"`
+	Merchant     string `json:"merchant"`
+	Before       string `json:"before"`
+	Transactions int    `json:"transactions"`
+	Credited     int    `json:"credited"`
+	Debited      int    `json:"debited"`
+}
+
+// ArchiveManifest is the payload of the PeriodArchived event
+type ArchiveManifest struct {
+	Merchant     string   `json:"merchant"`
+	Before       string   `json:"before"`
+	Transactions []string `json:"transactions"`
+	Owners       []string `json:"owners"`
+}
+
+// ArchivePeriod rolls the settled transactions of a merchant created before the cutoff into
+// per-member period summaries and deletes them. Balances are kept on the members and do not change.
+func (s *AdminContract) ArchivePeriod(ctx TransactionContext, merchant string, before string) (*ArchiveManifest, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff, err := time.Parse(time.RFC3339, before)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "invalid cutoff date %s. %s", before, err.Error())
+	}
+
+	_, err = getMerchant(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(transactionObjectType, []string{})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	manifest := ArchiveManifest{
+		Merchant:     merchant,
+		Before:       formatDate(cutoff),
+		Transactions: []string{},
+		Owners:       []string{},
+	}
+	summaries := map[string]*PeriodSummary{}
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var transaction PointsTransaction
+		err = unmarshalState(transactionObjectType, queryResponse.Key, queryResponse.Value, &transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		// Voided transactions are kept as tombstones and not counted
+		if transaction.Merchant != merchant || transactionStatus(&transaction) == StatusPending || transactionStatus(&transaction) == StatusVoided {
+			continue
+		}
+
+		createdAt, ok := parseTransactionDate(transaction.CreatedAt)
+		if !ok || !createdAt.Before(cutoff) {
+			continue
+		}
+
+		value := transaction.Value
+		if transactionStatus(&transaction) == StatusCancelled {
+			value = 0
+		} else if transaction.Source != nil && transaction.Source.Type == TypeReversal {
+			value = -value
+		}
+
+		for _, owner := range []string{transaction.Sender, transaction.Receiver} {
+			if owner == merchant {
+				continue
+			}
+
+			if _, ok := summaries[owner]; !ok {
+				manifest.Owners = append(manifest.Owners, owner)
+			}
+
+			summary, err := getPeriodSummary(ctx, summaries, merchant, owner, manifest.Before)
+			if err != nil {
+				return nil, err
+			}
+
+			summary.Transactions++
+			if owner == transaction.Receiver {
+				summary.Credited += value
+			} else {
+				summary.Debited += value
+			}
+		}
+
+		err = ctx.GetStub().DelState(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		err = delTransactionIndex(ctx, &transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Transactions = append(manifest.Transactions, transaction.ID)
+	}
+
+	for _, owner := range manifest.Owners {
+		err = putCompositeObject(ctx, periodSummaryObjectType, []string{merchant, owner, manifest.Before}, summaries[owner])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = emitEvent(ctx, periodArchivedEvent, &PeriodArchivedEvent{ArchiveManifest: manifest})
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// GetPeriodSummary returns the summary of the transactions of a member archived before a cutoff
+func (s *AdminContract) GetPeriodSummary(ctx TransactionContext, merchant string, owner string, before string) (*PeriodSummary, error) {
+	var summary PeriodSummary
+	cutoff, err := time.Parse(time.RFC3339, before)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "invalid cutoff date %s. %s", before, err.Error())
+	}
+
+	found, err := getCompositeObject(ctx, periodSummaryObjectType, []string{merchant, owner, formatDate(cutoff)}, &summary)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "no transactions of %s with %s were archived before %s", owner, merchant, before)
+	}
+
+	return &summary, nil
+}
+
+// getPeriodSummary returns the summary of an owner being built, reading it from the world state
+// first so that archiving the same cutoff twice adds to it
+func getPeriodSummary(ctx TransactionContext, summaries map[string]*PeriodSummary, merchant string, owner string, before string) (*PeriodSummary, error) {
+	if summary, ok := summaries[owner]; ok {
+		return summary, nil
+	}
+
+	summary := &PeriodSummary{Owner: owner, Merchant: merchant, Before: before}
+	_, err := getCompositeObject(ctx, periodSummaryObjectType, []string{merchant, owner, before}, summary)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries[owner] = summary
+	return summary, nil
+}
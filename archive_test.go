@@ -0,0 +1,54 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchivePeriod(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	old := env.reward("m1", "alice", 10)
+	env.advance(48 * time.Hour)
+	recent := env.reward("m1", "alice", 5)
+
+	_, err := env.admin.ArchivePeriod(env.ctx(merchantIdentity), "m1", "2024-03-16T00:00:00Z")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.ArchivePeriod(env.ctx(adminIdentity), "m1", "tomorrow")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	manifest, err := env.admin.ArchivePeriod(env.ctx(adminIdentity), "m1", "2024-03-16T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, []string{old}, manifest.Transactions)
+	require.Equal(t, []string{"alice"}, manifest.Owners)
+	env.requireEvent(periodArchivedEvent, nil)
+
+	_, err = env.points.GetTransaction(env.ctx(adminIdentity), old)
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.points.GetTransaction(env.ctx(adminIdentity), recent)
+	require.NoError(t, err)
+
+	summary, err := env.admin.GetPeriodSummary(env.ctx(adminIdentity), "m1", "alice", "2024-03-16T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.Transactions)
+	require.Equal(t, 10, summary.Credited)
+	require.Equal(t, 15, env.balance("alice", "m1"), "archiving keeps balances")
+
+	_, err = env.admin.GetPeriodSummary(env.ctx(adminIdentity), "m1", "bob", "2024-03-16T00:00:00Z")
+	requireErrorCode(t, err, ErrNotFound)
+
+	summary, err = env.admin.GetPeriodSummary(env.ctx(adminIdentity), "m1", "alice", "2024-03-16T01:00:00+01:00")
+	require.NoError(t, err, "cutoffs are looked up in UTC")
+	require.Equal(t, 1, summary.Transactions)
+
+	_, err = env.admin.GetPeriodSummary(env.ctx(adminIdentity), "m1", "alice", "20240316")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
@@ -0,0 +1,207 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// auditObjectType keys the audit log by day, timestamp and transaction ID, so that entries
+	// are read in the order they were recorded
+	auditObjectType = "audit"
+
+	// auditDate formats the day of an entry in its key
+	auditDate = "2006-01-02"
+
+	// maxAuditWindowDays is the longest period of QueryAuditLog, the log is read a day at a time
+	maxAuditWindowDays = 366
+)
+
+// AuditEntry records a privileged operation. Entries are written once by the transaction of
+// the operation and never updated, PurgeByPrefix refuses to delete them.
+type AuditEntry struct {
+	Schema
+	TxID     string `json:"txId"`
+	Function string `json:"function"`
+	// ParamsHash is the hex SHA-256 of the JSON array of the parameters, which may hold
+	// personal data and are not stored in the log
+	ParamsHash string `json:"paramsHash"`
+	Caller     string `json:"caller"`
+	MSP        string `json:"msp"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// AuditLogPage is a page of the audit log and the bookmark to fetch the next one
+type AuditLogPage struct {
+	Records []*AuditEntry `json:"records"`
+	PageInfo
+}
+
+// auditedFunctions are the privileged operations recorded in the audit log: the functions of
+// the admin and merchant contracts which write to the world state. Every one of them requires
+// the admin role or the MSP of a merchant, such as RegisterMerchant, ReverseTransaction and
+// ApproveAccount.
+var auditedFunctions = func() map[string]bool {
+	functions := map[string]bool{}
+	for _, contract := range []contractapi.ContractInterface{new(AdminContract), new(MerchantContract)} {
+		for _, function := range contractFunctions(contract) {
+			if !isQuery(function) {
+				functions[function] = true
+			}
+		}
+	}
+
+	return functions
+}()
+
+// QueryAuditLog returns a page of the audit entries recorded from from until to inclusive,
+// both RFC3339 dates, in the order they were recorded. Pass the bookmark of a page to fetch
+// the next one. Only admins may read the log.
+func (s *AdminContract) QueryAuditLog(ctx TransactionContext, from string, to string, pageSize int32, bookmark string) (*AuditLogPage, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if pageSize <= 0 {
+		return nil, newError(ErrInvalidArgument, "page size must be positive")
+	}
+
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "from must be an RFC3339 date, got %s", from)
+	}
+
+	end, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "to must be an RFC3339 date, got %s", to)
+	}
+
+	start, end = start.UTC(), end.UTC()
+	first, last := start.Truncate(24*time.Hour), end.Truncate(24*time.Hour)
+	if last.Before(first) || last.After(first.AddDate(0, 0, maxAuditWindowDays)) {
+		return nil, newError(ErrInvalidArgument, "the period must end after it starts and span at most %d days", maxAuditWindowDays)
+	}
+
+	day := first
+	if bookmark != "" {
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(bookmark)
+		if err != nil || len(attributes) != 3 {
+			return nil, newError(ErrInvalidArgument, "invalid bookmark %s", bookmark)
+		}
+
+		day, err = time.Parse(auditDate, attributes[0])
+		if err != nil {
+			return nil, newError(ErrInvalidArgument, "invalid bookmark %s", bookmark)
+		}
+	}
+
+	page := AuditLogPage{Records: []*AuditEntry{}}
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		more, err := readAuditDay(ctx, day, start, end, bookmark, pageSize, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		if more {
+			page.HasMore = true
+			break
+		}
+	}
+
+	page.FetchedRecordsCount = int32(len(page.Records))
+	if !page.HasMore {
+		page.Bookmark = ""
+	}
+
+	return &page, nil
+}
+
+// readAuditDay appends the entries of a day recorded between start and end and after the
+// bookmark to the page, until it is full. It reports whether a further entry is left, the
+// bookmark of the page is then the key of its last entry.
+func readAuditDay(ctx TransactionContext, day time.Time, start time.Time, end time.Time, bookmark string, pageSize int32, page *AuditLogPage) (bool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(auditObjectType, []string{day.Format(auditDate)})
+	if err != nil {
+		return false, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return false, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		if queryResponse.Key <= bookmark {
+			continue
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return false, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		recordedAt, err := time.Parse(time.RFC3339, attributes[1])
+		if err != nil || recordedAt.Before(start) || recordedAt.After(end) {
+			continue
+		}
+
+		// The bookmark is only returned when a further matching entry exists
+		if int32(len(page.Records)) == pageSize {
+			return true, nil
+		}
+
+		var entry AuditEntry
+		err = unmarshalState(auditObjectType, queryResponse.Key, queryResponse.Value, &entry)
+		if err != nil {
+			return false, err
+		}
+
+		page.Records = append(page.Records, &entry)
+		page.Bookmark = queryResponse.Key
+	}
+
+	return false, nil
+}
+
+// recordAudit appends an entry for the invocation of a privileged function to the audit log.
+// It is written before the function runs, a failed function is not committed with its entry.
+func recordAudit(ctx TransactionContext, function string, params []string) error {
+	clientID, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	paramsAsBytes, err := json.Marshal(params)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal the parameters of %s. %s", function, err.Error())
+	}
+
+	hash := sha256.Sum256(paramsAsBytes)
+	timestamp := now.Format(time.RFC3339)
+
+	entry := AuditEntry{
+		TxID:       ctx.GetStub().GetTxID(),
+		Function:   function,
+		ParamsHash: hex.EncodeToString(hash[:]),
+		Caller:     clientID,
+		MSP:        mspID,
+		Timestamp:  timestamp,
+	}
+
+	return putCompositeObject(ctx, auditObjectType, []string{now.Format(auditDate), timestamp, entry.TxID}, &entry)
+}
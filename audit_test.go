@@ -0,0 +1,107 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// invokeAdmin runs the hook of a function of the admin contract, as the chaincode does before the function
+func (e *testEnv) invokeAdmin(identity *testIdentity, args ...string) {
+	e.t.Helper()
+	ctx := e.ctx(identity)
+	e.stub.args = args
+	require.NoError(e.t, beforeTransaction(ctx))
+}
+
+func TestAuditLogRecordsPrivilegedOperations(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	env.invokeAdmin(adminIdentity, "AdminContract:FreezeAccount", "alice", "fraud")
+	txID := env.lastTxID()
+	env.invokeAdmin(adminIdentity, "AdminContract:GetFreeze", "alice")
+	env.invokeAdmin(adminIdentity, "PointsContract:GetMember", "alice")
+
+	page, err := env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15T00:00:00Z", "2024-03-15T23:59:59Z", 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1, "queries are not audited")
+
+	entry := page.Records[0]
+	require.Equal(t, "FreezeAccount", entry.Function)
+	require.Equal(t, txID, entry.TxID)
+	require.Equal(t, "admin", entry.Caller)
+	require.Equal(t, "Org1MSP", entry.MSP)
+	require.Equal(t, "2024-03-15T10:00:00Z", entry.Timestamp)
+	require.Len(t, entry.ParamsHash, 64)
+}
+
+func TestQueryAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	env.invokeAdmin(adminIdentity, "AdminContract:Pause", "maintenance")
+	env.advance(time.Hour)
+	env.invokeAdmin(adminIdentity, "AdminContract:Unpause")
+	env.advance(24 * time.Hour)
+	env.invokeAdmin(adminIdentity, "AdminContract:BurnPoints", "alice", "m1", "10", "fraud")
+
+	page, err := env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15T00:00:00Z", "2024-03-17T00:00:00Z", 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.True(t, page.HasMore)
+	require.Equal(t, "Pause", page.Records[0].Function)
+	require.Equal(t, "Unpause", page.Records[1].Function)
+
+	page, err = env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15T00:00:00Z", "2024-03-17T00:00:00Z", 2, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.False(t, page.HasMore)
+	require.Empty(t, page.Bookmark)
+	require.Equal(t, "BurnPoints", page.Records[0].Function)
+
+	page, err = env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15T10:30:00Z", "2024-03-16T10:30:00Z", 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "Unpause", page.Records[0].Function)
+
+	_, err = env.admin.QueryAuditLog(env.ctx(merchantIdentity), "2024-03-15T00:00:00Z", "2024-03-17T00:00:00Z", 10, "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-17T00:00:00Z", "2024-03-15T00:00:00Z", 10, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15", "2024-03-17T00:00:00Z", 10, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
+
+func TestPurgeByPrefixKeepsAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, env.admin.SetOperatorMSP(env.ctx(adminIdentity), "Org1MSP"))
+
+	_, err := env.admin.PurgeByPrefix(env.ctx(adminIdentity), "audit/2024-", 10, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
+
+func TestAuditLogRecordsMerchantOperations(t *testing.T) {
+	env := newTestEnv(t)
+	for _, function := range []string{
+		"SetMerchantMSP", "RegisterMerchant", "DeactivateMerchant", "SetApprovalPolicy", "ReverseTransaction",
+		"UpdateStatus", "ApproveAccount", "RejectAccount", "SetStockistCommission",
+	} {
+		require.True(t, auditedFunctions[function], function)
+	}
+	require.False(t, auditedFunctions["GetMerchant"])
+
+	env.invokeAdmin(adminIdentity, "MerchantContract:RegisterMerchant", "m1", "Merchant m1", "Org1MSP", "en")
+	env.invokeAdmin(merchantIdentity, "MerchantContract:GetMerchant", "m1")
+
+	page, err := env.admin.QueryAuditLog(env.ctx(adminIdentity), "2024-03-15T00:00:00Z", "2024-03-15T23:59:59Z", 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "RegisterMerchant", page.Records[0].Function)
+}
@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "encoding/json"
+
+// maxBatchSize is the maximum number of transactions imported by one invocation
+const maxBatchSize = 500
+
+// BatchItemResult reports the outcome of one transaction of a batch
+type BatchItemResult struct {
+	ID    string `json:"ID"`
+	Code  string `json:"code,omitempty" metadata:"code,optional"`
+	Error string `json:"error,omitempty" metadata:"error,optional"`
+	// Details names the invalid field and the rule it breaks when an item is rejected by validation
+	Details map[string]string `json:"details,omitempty" metadata:"details,optional"`
+}
+
+// CreateTransactionsBatch imports a JSON array of transactions, e.g. from a legacy loyalty system.
+// Invalid items are skipped and reported, the valid ones are written.
+func (s *AdminContract) CreateTransactionsBatch(ctx TransactionContext, jsonArray string) ([]BatchItemResult, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []PointsTransaction
+	err = json.Unmarshal([]byte(jsonArray), &transactions)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "failed to parse transactions. %s", err.Error())
+	}
+
+	if len(transactions) > maxBatchSize {
+		return nil, newError(ErrInvalidArgument, "batch has %d transactions, at most %d are allowed", len(transactions), maxBatchSize)
+	}
+
+	// Writes are not visible to reads of the same Fabric transaction, so each
+	// member and transaction ID may only be touched once per batch
+	touched := map[string]bool{}
+	results := []BatchItemResult{}
+
+	for i := range transactions {
+		transaction := &transactions[i]
+		result := BatchItemResult{ID: transaction.ID}
+
+		err := createBatchItem(ctx, transaction, touched)
+
+		if contractErr, ok := err.(*ContractError); ok {
+			result.Code = contractErr.Code
+			result.Error = contractErr.Message
+			result.Details = contractErr.Details
+		} else if err != nil {
+			result.Code = ErrInternal
+			result.Error = err.Error()
+		} else {
+			touched["transaction:"+transaction.ID] = true
+			touched["member:"+transaction.Sender] = true
+			touched["member:"+transaction.Receiver] = true
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// createBatchItem validates and writes one transaction of a batch
+func createBatchItem(ctx TransactionContext, transaction *PointsTransaction, touched map[string]bool) error {
+	err := validateBatchItem(transaction, touched)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Status == "" {
+		transaction.Status = StatusConfirmed
+	}
+
+	transaction.CreatedAt, err = inputDate(ctx, transaction.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = getMerchant(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	return createTransaction(ctx, transaction)
+}
+
+func validateBatchItem(transaction *PointsTransaction, touched map[string]bool) error {
+	keys := []struct {
+		name  string
+		value string
+	}{
+		{"ID", transaction.ID},
+		{"sender", transaction.Sender},
+		{"receiver", transaction.Receiver},
+		{"merchant", transaction.Merchant},
+	}
+
+	for _, key := range keys {
+		err := validateKey(namedField(key.name), key.value)
+		if err != nil {
+			return err
+		}
+
+		err = validateLength(namedField(key.name), key.value, maxFieldLength)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := validatePositive(namedField("value"), transaction.Value)
+	if err != nil {
+		return err
+	}
+
+	if transaction.CreatedAt != "" {
+		err = validateDate(namedField("created_at"), transaction.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if transaction.Status != "" {
+		err = validateEnum(namedField("status"), transaction.Status, transactionStatuses)
+		if err != nil {
+			return err
+		}
+	}
+
+	if transaction.Source != nil && transaction.Source.Type != "" {
+		err = validateEnum(namedField("source.type"), transaction.Source.Type, transactionTypes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if transaction.Sender == transaction.Receiver {
+		return newError(ErrInvalidArgument, "sender and receiver must differ")
+	}
+
+	if touched["transaction:"+transaction.ID] {
+		return newError(ErrInvalidArgument, "transaction %s is duplicated in the batch", transaction.ID)
+	}
+
+	for _, member := range []string{transaction.Sender, transaction.Receiver} {
+		if touched["member:"+member] {
+			return newError(ErrInvalidArgument, "%s is already modified by this batch, submit it in a later batch", member)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransactionsBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	batch := `[
+		{"ID": "b1", "value": 10, "merchant": "m1", "sender": "m1", "receiver": "alice"},
+		{"ID": "b2", "value": 10, "merchant": "m1", "sender": "m1", "receiver": "alice"},
+		{"ID": "b3", "value": 0, "merchant": "m1", "sender": "m1", "receiver": "bob"},
+		{"ID": "b4", "value": 10, "merchant": "m1", "sender": "dave", "receiver": "carol"}
+	]`
+	results, err := env.admin.CreateTransactionsBatch(env.ctx(adminIdentity), batch)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	require.Empty(t, results[0].Code)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, ErrInvalidArgument, results[1].Code)
+	require.Equal(t, "m1 is already modified by this batch, submit it in a later batch", results[1].Error)
+	require.Equal(t, ErrInvalidArgument, results[2].Code)
+	require.Equal(t, "value", results[2].Details["field"])
+	require.Equal(t, rulePositive, results[2].Details["rule"])
+	require.Equal(t, ErrInsufficientPoints, results[3].Code)
+	require.Equal(t, "dave does not have enough points", results[3].Error)
+
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	_, err = env.admin.CreateTransactionsBatch(env.ctx(merchantIdentity), batch)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.CreateTransactionsBatch(env.ctx(adminIdentity), "not json")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
@@ -0,0 +1,140 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+const birthdayObjectType = "birthday"
+
+// BirthdayGrant marks that a customer received the birthday points of a merchant in a year
+type BirthdayGrant struct {
+	Schema
+	Owner       string `json:"owner"`
+	Merchant    string `json:"merchant"`
+	Year        int    `json:"year"`
+	Value       int    `json:"value"`
+	Transaction string `json:"transaction"`
+	GrantedAt   string `json:"granted_at"`
+}
+
+// SetBirthdayPoints sets the number of points a merchant grants to customers on their birthday
+func (s *MerchantContract) SetBirthdayPoints(ctx TransactionContext, merchantID string, points int) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if points < 0 {
+		return newError(ErrInvalidArgument, "birthday points must not be negative")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.BirthdayPoints = points
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// GrantBirthdayPoints credits the birthday points of a merchant to a customer, at most once per calendar year
+func (s *PointsContract) GrantBirthdayPoints(ctx TransactionContext, owner string, merchantID string, year int) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantMSP(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if merchant.Program.BirthdayPoints <= 0 {
+		return newError(ErrInvalidState, "merchant %s does not grant birthday points", merchantID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if year != now.Year() {
+		return newError(ErrInvalidArgument, "birthday points can only be granted for the current year %d", now.Year())
+	}
+
+	existing, err := getBirthdayGrant(ctx, merchantID, owner, year)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return newError(ErrAlreadyExists, "%s already received birthday points of %s in %d", owner, merchantID, year).
+			WithDetail("transaction", existing.Transaction)
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     merchant.Program.BirthdayPoints,
+		Merchant:  merchantID,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    merchantID,
+		Receiver:  owner,
+		Source:    &Source{Type: TypeBirthday, ID: strconv.Itoa(year)},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return err
+	}
+
+	grant := BirthdayGrant{
+		Owner:       owner,
+		Merchant:    merchantID,
+		Year:        year,
+		Value:       transaction.Value,
+		Transaction: transaction.ID,
+		GrantedAt:   transaction.CreatedAt,
+	}
+
+	return putCompositeObject(ctx, birthdayObjectType, []string{merchantID, owner, strconv.Itoa(year)}, &grant)
+}
+
+// GetBirthdayGrant returns the birthday points a customer received from a merchant in a year
+func (s *PointsContract) GetBirthdayGrant(ctx TransactionContext, owner string, merchantID string, year int) (*BirthdayGrant, error) {
+	grant, err := getBirthdayGrant(ctx, merchantID, owner, year)
+	if err != nil {
+		return nil, err
+	}
+
+	if grant == nil {
+		return nil, newError(ErrNotFound, "%s received no birthday points of %s in %d", owner, merchantID, year)
+	}
+
+	return grant, nil
+}
+
+// getBirthdayGrant returns the birthday grant of a customer in a year, or nil if there is none
+func getBirthdayGrant(ctx TransactionContext, merchantID string, owner string, year int) (*BirthdayGrant, error) {
+	var grant BirthdayGrant
+	found, err := getCompositeObject(ctx, birthdayObjectType, []string{merchantID, owner, strconv.Itoa(year)}, &grant)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &grant, nil
+}
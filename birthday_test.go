@@ -0,0 +1,47 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantBirthdayPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 1)
+
+	err := env.points.GrantBirthdayPoints(env.ctx(merchantIdentity), "alice", "m1", 2024)
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.merchants.SetBirthdayPoints(env.ctx(otherMSPIdentity), "m1", 50)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetBirthdayPoints(env.ctx(merchantIdentity), "m1", -1)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.merchants.SetBirthdayPoints(env.ctx(merchantIdentity), "m1", 50))
+
+	err = env.points.GrantBirthdayPoints(env.ctx(otherMSPIdentity), "alice", "m1", 2024)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.GrantBirthdayPoints(env.ctx(merchantIdentity), "alice", "m1", 2023)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.points.GrantBirthdayPoints(env.ctx(merchantIdentity), "alice", "m1", 2024))
+	require.Equal(t, 51, env.balance("alice", "m1"))
+
+	grant, err := env.points.GetBirthdayGrant(env.ctx(adminIdentity), "alice", "m1", 2024)
+	require.NoError(t, err)
+	require.Equal(t, 50, grant.Value)
+
+	err = env.points.GrantBirthdayPoints(env.ctx(merchantIdentity), "alice", "m1", 2024)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	_, err = env.points.GetBirthdayGrant(env.ctx(adminIdentity), "bob", "m1", 2024)
+	requireErrorCode(t, err, ErrNotFound)
+}
@@ -0,0 +1,446 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+)
+
+const (
+	bridgeReceiptObjectType = "bridgeReceipt"
+	bridgeMintObjectType    = "bridgeMint"
+	bridgeConfigID          = "bridge"
+
+	// bridgeRole is the value of the role attribute of the identity which carries receipts
+	// between channels
+	bridgeRole = "bridge"
+)
+
+// BridgeReceipt records points locked on this channel to be minted on another channel. The
+// bridge identity carries it to the destination channel as the proof of MintFromBridge.
+type BridgeReceipt struct {
+	Schema
+	ID                 string `json:"ID"`
+	SourceChannel      string `json:"sourceChannel"`
+	DestinationChannel string `json:"destinationChannel"`
+	Merchant           string `json:"merchant"`
+	Owner              string `json:"owner"`
+	// Receiver is the member credited on the destination channel
+	Receiver string `json:"receiver"`
+	Value    int    `json:"value"`
+	LockedAt string `json:"lockedAt"`
+}
+
+// BridgeConfig pins the identity allowed to mint bridged points and the key which signs the
+// receipts it carries. It is stored in the world state, so that every endorsing peer checks
+// the same bridge.
+type BridgeConfig struct {
+	Schema
+	// MSP is the MSP of the bridge identity
+	MSP string `json:"msp"`
+	// PublicKey is the PEM encoded ECDSA public key verifying the signatures of receipts
+	PublicKey string `json:"publicKey"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// BridgeMint records a receipt of another channel credited on this channel, so that it is
+// not credited twice
+type BridgeMint struct {
+	Schema
+	Receipt     BridgeReceipt `json:"receipt"`
+	Transaction string        `json:"transaction"`
+	MintedBy    string        `json:"mintedBy"`
+	MintedAt    string        `json:"mintedAt"`
+}
+
+// LockForBridge takes value points of the owner's merchant off the owner's account to be
+// minted for the receiver on the destination channel. It returns the ID of the receipt, or of
+// the receipt of an earlier submission with the same idempotency token.
+func (s *PointsContract) LockForBridge(ctx TransactionContext, owner string, value int, destinationChannel string, receiver string) (string, error) {
+	return idempotentCreate(ctx, func() (string, error) {
+		return ctx.GetStub().GetTxID(), lockForBridge(ctx, owner, value, destinationChannel, receiver)
+	})
+}
+
+func lockForBridge(ctx TransactionContext, owner string, value int, destinationChannel string, receiver string) error {
+	sourceChannel := ctx.GetStub().GetChannelID()
+	if destinationChannel == sourceChannel {
+		return newError(ErrInvalidArgument, "destination channel must differ from channel %s", sourceChannel)
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if member.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant, only customer points can be bridged", owner)
+	}
+
+	err = assertAccountOwner(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	receipt := BridgeReceipt{
+		ID:                 ctx.GetStub().GetTxID(),
+		SourceChannel:      sourceChannel,
+		DestinationChannel: destinationChannel,
+		Merchant:           member.Merchant,
+		Owner:              owner,
+		Receiver:           receiver,
+		Value:              value,
+		LockedAt:           now.Format(time.RFC3339),
+	}
+
+	// The points return to the merchant as a lock rather than a redemption, they remain
+	// outstanding on the destination channel
+	transaction := PointsTransaction{
+		ID:        receipt.ID,
+		Value:     value,
+		Merchant:  member.Merchant,
+		CreatedAt: receipt.LockedAt,
+		Sender:    owner,
+		Receiver:  member.Merchant,
+		Source:    &Source{Type: TypeBridgeLock, ID: destinationChannel},
+		Status:    StatusConfirmed,
+	}
+
+	err = lockPoints(ctx, member, &transaction)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, bridgeReceiptObjectType, receipt.ID, &receipt)
+}
+
+// lockPoints debits the points of a lock from the customer and the outstanding points of the
+// merchant, without recording a redemption
+func lockPoints(ctx TransactionContext, member *Member, transaction *PointsTransaction) error {
+	err := assertMerchantActive(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(ctx, member.ID)
+	if err != nil {
+		return err
+	}
+
+	var existing PointsTransaction
+	exists, err := getObject(ctx, transactionObjectType, transaction.ID, &existing)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return newError(ErrAlreadyExists, "transaction %s already exists", transaction.ID)
+	}
+
+	if spendablePoints(member) < transaction.Value {
+		return newError(ErrInsufficientPoints, "%s does not have enough points", member.ID)
+	}
+
+	err = assertLifeCardEligible(ctx, member)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := getMember(ctx, member.Merchant)
+	if err != nil {
+		return err
+	}
+
+	err = emitEvent(ctx, pointsTransferredEvent, &PointsTransferredEvent{
+		Transaction: transaction.ID,
+		Type:        transactionType(transaction),
+		Merchant:    transaction.Merchant,
+		Sender:      transaction.Sender,
+		Receiver:    transaction.Receiver,
+		Value:       transaction.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	member.Points -= transaction.Value
+	member.MerchantPoints[member.Merchant] -= transaction.Value
+	member.Transaction = transaction
+	merchant.Points -= transaction.Value
+
+	err = putMember(ctx, member)
+	if err != nil {
+		return err
+	}
+
+	err = putMember(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	err = putTransaction(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	return updateProgramStats(ctx, transaction.Merchant, programAdjusted, -transaction.Value)
+}
+
+// GetBridgeReceipt returns a receipt of points locked on this channel
+func (s *PointsContract) GetBridgeReceipt(ctx TransactionContext, id string) (*BridgeReceipt, error) {
+	var receipt BridgeReceipt
+	found, err := getObject(ctx, bridgeReceiptObjectType, id, &receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "bridge receipt %s does not exist", id)
+	}
+
+	return &receipt, nil
+}
+
+// SetBridgeConfig pins the bridge to an MSP and to the public key, PEM encoded, which signs
+// the receipts passed to MintFromBridge
+func (s *AdminContract) SetBridgeConfig(ctx TransactionContext, mspID string, publicKey string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseBridgeKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	config := BridgeConfig{
+		MSP:       mspID,
+		PublicKey: publicKey,
+		UpdatedBy: clientID,
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, configObjectType, bridgeConfigID, &config)
+}
+
+// GetBridgeConfig returns the MSP and public key of the bridge
+func (s *AdminContract) GetBridgeConfig(ctx TransactionContext) (*BridgeConfig, error) {
+	return getBridgeConfig(ctx)
+}
+
+func getBridgeConfig(ctx TransactionContext) (*BridgeConfig, error) {
+	var config BridgeConfig
+	found, err := getObject(ctx, configObjectType, bridgeConfigID, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrInvalidState, "bridge is not configured")
+	}
+
+	return &config, nil
+}
+
+// MintFromBridge credits the receiver of a receipt locked on another channel, given as JSON
+// with its signature, base64 encoded. Chaincode cannot read the ledger of another channel, so
+// only the bridge identity of the configured MSP may mint, the receipt must be signed by the
+// configured key, and each receipt is credited once.
+func (s *PointsContract) MintFromBridge(ctx TransactionContext, proof string, signature string) (string, error) {
+	config, err := assertBridge(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = verifyBridgeProof(config, proof, signature)
+	if err != nil {
+		return "", err
+	}
+
+	var receipt BridgeReceipt
+	err = json.Unmarshal([]byte(proof), &receipt)
+	if err != nil {
+		return "", newError(ErrInvalidArgument, "failed to parse bridge receipt. %s", err.Error())
+	}
+
+	err = validateBridgeReceipt(ctx, &receipt)
+	if err != nil {
+		return "", err
+	}
+
+	mintKey := []string{receipt.SourceChannel, receipt.ID}
+	var existing BridgeMint
+	found, err := getCompositeObject(ctx, bridgeMintObjectType, mintKey, &existing)
+	if err != nil {
+		return "", err
+	}
+
+	if found {
+		return "", newError(ErrAlreadyExists, "bridge receipt %s of channel %s was already minted in transaction %s", receipt.ID, receipt.SourceChannel, existing.Transaction)
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     receipt.Value,
+		Merchant:  receipt.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    receipt.Merchant,
+		Receiver:  receipt.Receiver,
+		Source:    &Source{Type: TypeBridge, ID: receipt.SourceChannel},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return "", err
+	}
+
+	mint := BridgeMint{
+		Receipt:     receipt,
+		Transaction: transaction.ID,
+		MintedBy:    clientID,
+		MintedAt:    now.Format(time.RFC3339),
+	}
+
+	err = putCompositeObject(ctx, bridgeMintObjectType, mintKey, &mint)
+	if err != nil {
+		return "", err
+	}
+
+	return transaction.ID, nil
+}
+
+// assertBridge checks that the client is the bridge identity of the configured MSP and
+// returns the configuration of the bridge
+func assertBridge(ctx TransactionContext) (*BridgeConfig, error) {
+	config, err := getBridgeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, bridgeRole)
+	if err != nil {
+		return nil, newError(ErrUnauthorized, "client is not authorized to mint bridged points. %s", err.Error())
+	}
+
+	_, mspID, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if mspID != config.MSP {
+		return nil, newError(ErrUnauthorized, "client of MSP %s is not the bridge, expected MSP %s", mspID, config.MSP)
+	}
+
+	return config, nil
+}
+
+// verifyBridgeProof checks the ECDSA signature of the SHA-256 digest of a receipt with the
+// public key of the bridge
+func verifyBridgeProof(config *BridgeConfig, proof string, signature string) error {
+	key, err := parseBridgeKey(config.PublicKey)
+	if err != nil {
+		return newError(ErrInternal, "invalid bridge configuration. %s", err.Error())
+	}
+
+	signatureAsBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return newError(ErrInvalidArgument, "failed to decode signature of bridge receipt. %s", err.Error())
+	}
+
+	digest := sha256.Sum256([]byte(proof))
+	if !ecdsa.VerifyASN1(key, digest[:], signatureAsBytes) {
+		return newError(ErrUnauthorized, "signature of bridge receipt is not valid")
+	}
+
+	return nil
+}
+
+// parseBridgeKey parses the PEM encoded ECDSA public key of the bridge
+func parseBridgeKey(publicKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, newError(ErrInvalidArgument, "bridge public key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "failed to parse bridge public key. %s", err.Error())
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, newError(ErrInvalidArgument, "bridge public key is not an ECDSA key")
+	}
+
+	return ecdsaKey, nil
+}
+
+// validateBridgeReceipt checks that a receipt is complete and addressed to this channel
+func validateBridgeReceipt(ctx TransactionContext, receipt *BridgeReceipt) error {
+	for _, f := range []struct {
+		name  string
+		value string
+	}{
+		{"ID", receipt.ID},
+		{"sourceChannel", receipt.SourceChannel},
+		{"merchant", receipt.Merchant},
+		{"receiver", receipt.Receiver},
+	} {
+		err := validateKey(namedField(f.name), f.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := validatePositive(namedField("value"), receipt.Value)
+	if err != nil {
+		return err
+	}
+
+	channel := ctx.GetStub().GetChannelID()
+	if receipt.DestinationChannel != channel {
+		return newError(ErrInvalidArgument, "bridge receipt %s is addressed to channel %s, not %s", receipt.ID, receipt.DestinationChannel, channel)
+	}
+
+	if receipt.SourceChannel == channel {
+		return newError(ErrInvalidArgument, "bridge receipt %s was locked on this channel", receipt.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockForBridge(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	id, err := env.points.LockForBridge(env.ctx(alice), "alice", 40, "rewards", "alice-abroad")
+	require.NoError(t, err)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+
+	receipt, err := env.points.GetBridgeReceipt(env.ctx(alice), id)
+	require.NoError(t, err)
+	require.Equal(t, testChannel, receipt.SourceChannel)
+	require.Equal(t, "rewards", receipt.DestinationChannel)
+	require.Equal(t, 40, receipt.Value)
+
+	transaction, err := env.points.GetTransaction(env.ctx(alice), id)
+	require.NoError(t, err)
+	require.Equal(t, TypeBridgeLock, transactionType(transaction))
+
+	// The locked points are no longer outstanding here, but were not redeemed
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 0, stats.Redeemed)
+	require.Equal(t, 60, stats.Outstanding)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(adminIdentity), id, "mistake")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	_, err = env.points.LockForBridge(env.ctx(alice), "alice", 61, "rewards", "alice-abroad")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.LockForBridge(env.ctx(alice), "alice", 10, testChannel, "alice")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.LockForBridge(env.ctx(customerIdentity("bob")), "alice", 10, "rewards", "bob")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.GetBridgeReceipt(env.ctx(alice), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestSetBridgeConfig(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.admin.GetBridgeConfig(env.ctx(adminIdentity))
+	requireErrorCode(t, err, ErrInvalidState)
+
+	key := env.configureBridge("Org2MSP")
+	config, err := env.admin.GetBridgeConfig(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Equal(t, "Org2MSP", config.MSP)
+	require.Equal(t, bridgePublicKey(t, key), config.PublicKey)
+
+	err = env.admin.SetBridgeConfig(env.ctx(adminIdentity), "Org2MSP", "not a key")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.SetBridgeConfig(env.ctx(merchantIdentity), "Org2MSP", config.PublicKey)
+	requireErrorCode(t, err, ErrUnauthorized)
+}
+
+func TestMintFromBridge(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 1)
+
+	proof, err := json.Marshal(BridgeReceipt{
+		ID:                 "lock1",
+		SourceChannel:      "rewards",
+		DestinationChannel: testChannel,
+		Merchant:           "m1",
+		Owner:              "alice-abroad",
+		Receiver:           "alice",
+		Value:              40,
+		LockedAt:           "2024-03-14T10:00:00Z",
+	})
+	require.NoError(t, err)
+
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(proof), "")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	key := env.configureBridge("Org1MSP")
+	signature := signBridgeProof(t, key, proof)
+
+	_, err = env.points.MintFromBridge(env.ctx(adminIdentity), string(proof), signature)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	// The bridge role is only trusted from the configured MSP
+	foreignBridge := &testIdentity{id: "bridge", mspID: "Org2MSP", attributes: map[string]string{adminAttribute: bridgeRole}}
+	_, err = env.points.MintFromBridge(env.ctx(foreignBridge), string(proof), signature)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(proof), signBridgeProof(t, other, proof))
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	tampered := []byte(string(proof[:len(proof)-1]) + ",\"value\":4000}")
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(tampered), signature)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(proof), "not base64")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(proof), signature)
+	require.NoError(t, err)
+	require.Equal(t, 41, env.balance("alice", "m1"))
+
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), string(proof), signature)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	_, err = env.points.MintFromBridge(env.ctx(bridgeIdentity), "not json", signBridgeProof(t, key, []byte("not json")))
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
+
+// configureBridge pins the bridge to an MSP and a new key, and returns the key
+func (e *testEnv) configureBridge(mspID string) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(e.t, err)
+	require.NoError(e.t, e.admin.SetBridgeConfig(e.ctx(adminIdentity), mspID, bridgePublicKey(e.t, key)))
+	return key
+}
+
+// bridgePublicKey returns the PEM encoded public key of a bridge key
+func bridgePublicKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+}
+
+// signBridgeProof returns the base64 encoded signature of a receipt
+func signBridgeProof(t *testing.T, key *ecdsa.PrivateKey, proof []byte) string {
+	digest := sha256.Sum256(proof)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
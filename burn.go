@@ -0,0 +1,76 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// BurnPoints removes value points of a merchant from a customer's account and from the merchant's
+// issued total, recording a burn transaction with the reason. It returns the ID of the transaction.
+func (s *AdminContract) BurnPoints(ctx TransactionContext, owner string, merchantID string, value int, reason string) (string, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	if member.Merchant == "" {
+		return "", newError(ErrInvalidArgument, "%s is a merchant, only customer points can be burnt", owner)
+	}
+
+	if member.MerchantPoints[merchantID]-typedPointsOf(member, merchantID) < value {
+		return "", newError(ErrInsufficientPoints, "%s does not have enough points of %s", owner, merchantID)
+	}
+
+	merchant, err := getMember(ctx, merchantID)
+	if err != nil {
+		return "", err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     value,
+		Merchant:  merchantID,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    owner,
+		Receiver:  merchantID,
+		Source:    &Source{Type: TypeBurn},
+		Status:    StatusConfirmed,
+		Reason:    reason,
+	}
+
+	member.Points -= value
+	member.MerchantPoints[merchantID] -= value
+	member.Transaction = &transaction
+
+	merchant.Points -= value
+
+	err = updateProgramStats(ctx, merchantID, programBurned, value)
+	if err != nil {
+		return "", err
+	}
+
+	for _, m := range []*Member{member, merchant} {
+		err = putMember(ctx, m)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = putTransaction(ctx, &transaction)
+	if err != nil {
+		return "", err
+	}
+
+	return transaction.ID, nil
+}
@@ -0,0 +1,39 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBurnPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	_, err := env.admin.BurnPoints(env.ctx(merchantIdentity), "alice", "m1", 10, "fraud")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.BurnPoints(env.ctx(adminIdentity), "alice", "m1", 101, "fraud")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.admin.BurnPoints(env.ctx(adminIdentity), "m1", "m1", 10, "fraud")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.BurnPoints(env.ctx(adminIdentity), "nobody", "m1", 10, "fraud")
+	requireErrorCode(t, err, ErrNotFound)
+
+	id, err := env.admin.BurnPoints(env.ctx(adminIdentity), "alice", "m1", 30, "fraud")
+	require.NoError(t, err)
+	require.Equal(t, 70, env.balance("alice", "m1"))
+	require.Equal(t, 70, env.member("m1").Points)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), id)
+	require.NoError(t, err)
+	require.Equal(t, TypeBurn, transaction.Source.Type)
+	require.Equal(t, "fraud", transaction.Reason)
+}
@@ -0,0 +1,265 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	campaignObjectType = "campaign"
+
+	campaignBudgetExhaustedEvent = "CampaignBudgetExhausted"
+)
+
+// Campaign awards bonus points on purchases at eligible merchants during a period, up to a budget
+type Campaign struct {
+	Schema
+	ID       string `json:"ID"`
+	Name     string `json:"name"`
+	Merchant string `json:"merchant"`
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+	// MultiplierPercent is applied to the base amount, 150 awards 1.5 points per unit
+	MultiplierPercent int      `json:"multiplierPercent"`
+	EligibleMerchants []string `json:"eligibleMerchants"`
+	Budget            int      `json:"budget"`
+	// Awarded is the sum of the points awarded, which may never exceed the budget
+	Awarded   int    `json:"awarded"`
+	CreatedAt string `json:"created_at"`
+	// ExhaustedAt is set by the award which uses up the budget
+	ExhaustedAt string `json:"exhaustedAt,omitempty" metadata:"exhaustedAt,optional"`
+}
+
+// CampaignSpend holds the points awarded by a campaign against its budget
+type CampaignSpend struct {
+	Campaign    string `json:"campaign"`
+	Budget      int    `json:"budget"`
+	Awarded     int    `json:"awarded"`
+	Remaining   int    `json:"remaining"`
+	ExhaustedAt string `json:"exhaustedAt,omitempty" metadata:"exhaustedAt,optional"`
+}
+
+// CreateCampaign sets up a campaign of a merchant, running from startsAt until endsAt
+func (s *MerchantContract) CreateCampaign(ctx TransactionContext, id string, name string, merchant string, startsAt string, endsAt string, multiplierPercent int, eligibleMerchants []string, budget int) error {
+	if !isAdmin(ctx) {
+		err := assertMerchantMSP(ctx, merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := assertMerchantActive(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	var existing Campaign
+	found, err := getObject(ctx, campaignObjectType, id, &existing)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return newError(ErrAlreadyExists, "campaign %s already exists", id)
+	}
+
+	start, err := time.Parse(time.RFC3339, startsAt)
+	if err != nil {
+		return newError(ErrInvalidArgument, "invalid start date %s. %s", startsAt, err.Error())
+	}
+
+	end, err := time.Parse(time.RFC3339, endsAt)
+	if err != nil {
+		return newError(ErrInvalidArgument, "invalid end date %s. %s", endsAt, err.Error())
+	}
+
+	if !end.After(start) {
+		return newError(ErrInvalidArgument, "campaign must end after it starts")
+	}
+
+	if multiplierPercent <= 0 || budget <= 0 {
+		return newError(ErrInvalidArgument, "multiplier and budget must be positive")
+	}
+
+	if len(eligibleMerchants) == 0 {
+		eligibleMerchants = []string{merchant}
+	}
+
+	for _, eligible := range eligibleMerchants {
+		_, err = getMerchant(ctx, eligible)
+		if err != nil {
+			return err
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	campaign := Campaign{
+		ID:                id,
+		Name:              name,
+		Merchant:          merchant,
+		StartsAt:          start.UTC().Format(time.RFC3339),
+		EndsAt:            end.UTC().Format(time.RFC3339),
+		MultiplierPercent: multiplierPercent,
+		EligibleMerchants: eligibleMerchants,
+		Budget:            budget,
+		CreatedAt:         now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, campaignObjectType, id, &campaign)
+}
+
+// GetCampaign returns the campaign stored in the world state with given id
+func (s *MerchantContract) GetCampaign(ctx TransactionContext, id string) (*Campaign, error) {
+	return getCampaign(ctx, id)
+}
+
+// GetCampaignSpend returns the points awarded by a campaign and what is left of its budget
+func (s *MerchantContract) GetCampaignSpend(ctx TransactionContext, campaignID string) (*CampaignSpend, error) {
+	campaign, err := getCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	return campaign.spend(), nil
+}
+
+// AwardCampaignPoints credits the owner with the campaign points earned on a purchase of
+// baseAmount at merchant, recorded as transaction id. It returns the awarded points, retries
+// with the idempotency token of an earlier submission return the points it awarded.
+func (s *PointsContract) AwardCampaignPoints(ctx TransactionContext, id string, campaignID string, merchant string, owner string, baseAmount int) (int, error) {
+	campaign, err := getCampaign(ctx, campaignID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = assertMerchantMSP(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed, err := replayedTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if replayed != "" {
+		transaction, err := getTransaction(ctx, replayed)
+		if err != nil {
+			return 0, err
+		}
+
+		return transaction.Value, nil
+	}
+
+	eligible := false
+	for _, eligibleMerchant := range campaign.EligibleMerchants {
+		if eligibleMerchant == merchant {
+			eligible = true
+		}
+	}
+
+	if !eligible {
+		return 0, newError(ErrInvalidArgument, "merchant %s is not eligible for campaign %s", merchant, campaignID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	start, err := time.Parse(time.RFC3339, campaign.StartsAt)
+	if err != nil {
+		return 0, newError(ErrInternal, "invalid start date of campaign %s. %s", campaignID, err.Error())
+	}
+
+	end, err := time.Parse(time.RFC3339, campaign.EndsAt)
+	if err != nil {
+		return 0, newError(ErrInternal, "invalid end date of campaign %s. %s", campaignID, err.Error())
+	}
+
+	if now.Before(start) || !now.Before(end) {
+		return 0, newError(ErrInvalidState, "campaign %s is not running", campaignID)
+	}
+
+	awarded := baseAmount * campaign.MultiplierPercent / 100
+	if awarded <= 0 {
+		return 0, newError(ErrInvalidArgument, "base amount %d earns no points", baseAmount)
+	}
+
+	if campaign.Awarded+awarded > campaign.Budget {
+		return 0, newError(ErrBudgetExceeded, "campaign %s has %d points left in its budget", campaignID, campaign.Budget-campaign.Awarded).
+			WithDetail("campaign", campaignID).
+			WithDetail("remaining", strconv.Itoa(campaign.Budget-campaign.Awarded))
+	}
+
+	transaction := PointsTransaction{
+		ID:        id,
+		Value:     awarded,
+		Merchant:  merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    merchant,
+		Receiver:  owner,
+		Source:    &Source{Type: TypeCampaign, ID: campaignID},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return 0, err
+	}
+
+	campaign.Awarded += awarded
+	exhausted := campaign.Awarded == campaign.Budget
+	if exhausted {
+		campaign.ExhaustedAt = transaction.CreatedAt
+	}
+
+	// Every award writes the campaign, so concurrent awards conflict rather than overspend
+	err = putObject(ctx, campaignObjectType, campaignID, campaign)
+	if err != nil {
+		return 0, err
+	}
+
+	// Replaces the event of the transaction, as the events of its other effects do
+	if exhausted {
+		err = emitEvent(ctx, campaignBudgetExhaustedEvent, &CampaignBudgetExhaustedEvent{CampaignSpend: *campaign.spend()})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return awarded, putIdempotencyToken(ctx, id)
+}
+
+// spend returns the points awarded by the campaign against its budget
+func (c *Campaign) spend() *CampaignSpend {
+	return &CampaignSpend{
+		Campaign:    c.ID,
+		Budget:      c.Budget,
+		Awarded:     c.Awarded,
+		Remaining:   c.Budget - c.Awarded,
+		ExhaustedAt: c.ExhaustedAt,
+	}
+}
+
+func getCampaign(ctx TransactionContext, id string) (*Campaign, error) {
+	var campaign Campaign
+	found, err := getObject(ctx, campaignObjectType, id, &campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "campaign %s does not exist in world state", id)
+	}
+
+	return &campaign, nil
+}
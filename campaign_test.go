@@ -0,0 +1,87 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCampaign(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	require.NoError(t, env.merchants.CreateCampaign(env.ctx(merchantIdentity), "c1", "Spring", "m1", "2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z", 150, []string{"m1"}, 100))
+
+	campaign, err := env.merchants.GetCampaign(env.ctx(merchantIdentity), "c1")
+	require.NoError(t, err)
+	require.Equal(t, 150, campaign.MultiplierPercent)
+
+	err = env.merchants.CreateCampaign(env.ctx(merchantIdentity), "c1", "Spring", "m1", "2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z", 150, []string{"m1"}, 100)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	err = env.merchants.CreateCampaign(env.ctx(otherMSPIdentity), "c2", "Spring", "m1", "2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z", 150, []string{"m1"}, 100)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.CreateCampaign(env.ctx(merchantIdentity), "c3", "Spring", "m1", "2024-04-01T00:00:00Z", "2024-03-01T00:00:00Z", 150, []string{"m1"}, 100)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.merchants.GetCampaign(env.ctx(merchantIdentity), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestAwardCampaignPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	env.reward("m1", "alice", 1)
+	require.NoError(t, env.merchants.CreateCampaign(env.ctx(merchantIdentity), "c1", "Spring", "m1", "2024-03-01T00:00:00Z", "2024-04-01T00:00:00Z", 150, []string{"m1"}, 100))
+
+	awarded, err := env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a1", "c1", "m1", "alice", 40)
+	require.NoError(t, err)
+	require.Equal(t, 60, awarded)
+	require.Equal(t, 61, env.balance("alice", "m1"))
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a2", "c1", "m1", "alice", 40)
+	requireErrorCode(t, err, ErrBudgetExceeded)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a3", "c1", "m2", "alice", 10)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(otherMSPIdentity), "a4", "c1", "m1", "alice", 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a5", "missing", "m1", "alice", 10)
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a6", "c1", "m1", "alice", 26)
+	require.NoError(t, err)
+
+	spend, err := env.merchants.GetCampaignSpend(env.ctx(merchantIdentity), "c1")
+	require.NoError(t, err)
+	require.Equal(t, 99, spend.Awarded)
+	require.Equal(t, 1, spend.Remaining)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a7", "c1", "m1", "alice", 1)
+	require.NoError(t, err)
+
+	var event CampaignBudgetExhaustedEvent
+	env.requireEvent(campaignBudgetExhaustedEvent, &event)
+	require.Equal(t, 0, event.Remaining)
+
+	_, err = env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a8", "c1", "m1", "alice", 1)
+	requireErrorCode(t, err, ErrBudgetExceeded)
+}
+
+func TestAwardCampaignPointsOutsidePeriod(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 1)
+	require.NoError(t, env.merchants.CreateCampaign(env.ctx(merchantIdentity), "c1", "Summer", "m1", "2024-06-01T00:00:00Z", "2024-07-01T00:00:00Z", 100, []string{"m1"}, 100))
+
+	_, err := env.points.AwardCampaignPoints(env.ctx(merchantIdentity), "a1", "c1", "m1", "alice", 10)
+	requireErrorCode(t, err, ErrInvalidState)
+}
@@ -0,0 +1,61 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"net"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// newChaincodeServer creates the gRPC server serving cc to the peer on address like
+// shim.ChaincodeServer.Start, but takes the TLS configuration from reloader so certificates
+// can be rotated while the server runs. TLS is disabled when reloader is nil.
+func newChaincodeServer(ccid string, address string, cc shim.Chaincode, reloader *tlsReloader, config grpcConfig) (*grpc.Server, net.Listener, error) {
+	if ccid == "" {
+		return nil, nil, errors.New("ccid must be specified")
+	}
+
+	if address == "" {
+		return nil, nil, errors.New("address must be specified")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:              config.KeepaliveTime,
+			Timeout:           config.KeepaliveTimeout,
+			MaxConnectionIdle: config.MaxConnectionIdle,
+		}),
+		// The peer may ping idle connections, but not more often than KeepaliveMinTime
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             config.KeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.MaxSendMsgSize(config.MaxSendMessageSize),
+		grpc.MaxRecvMsgSize(config.MaxRecvMessageSize),
+		grpc.ConnectionTimeout(config.ConnectionTimeout),
+	}
+
+	if reloader != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+	}
+
+	server := grpc.NewServer(options...)
+
+	// shim.ChaincodeServer handles the Connect stream of the peer
+	peer.RegisterChaincodeServer(server, &shim.ChaincodeServer{CCID: ccid, Address: address, CC: cc})
+
+	return server, listener, nil
+}
@@ -0,0 +1,567 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Names of the state codecs, set on the ledger with AdminContract:SetStateCodec
+const (
+	codecJSON     = "json"
+	codecProtobuf = "protobuf"
+)
+
+// codecConfigID stores the codec of the values written to the world state
+const codecConfigID = "stateCodec"
+
+// protobufPrefix starts the values written by the protobuf codec, followed by a byte naming
+// the message. JSON cannot start with a NUL byte, so values without it are read as JSON.
+var protobufPrefix = []byte("\x00pb")
+
+// Messages of the protobuf codec, stored after protobufPrefix
+const (
+	transactionMessage byte = 1
+	memberMessage      byte = 2
+)
+
+// StateCodec records the codec of the values written to the world state. It is stored on the
+// ledger, so that every peer endorsing a transaction writes the same bytes.
+type StateCodec struct {
+	Schema
+	Codec     string `json:"codec"`
+	UpdatedBy string `json:"updatedBy,omitempty" metadata:"updatedBy,optional"`
+	UpdatedAt string `json:"updated_at,omitempty" metadata:"updated_at,optional"`
+}
+
+// stateCodec encodes the JSON documents of the chaincode into the values stored in the world
+// state. Values are decoded by their format whatever the codec, so records written with
+// another codec stay readable.
+type stateCodec interface {
+	encode(objectType string, document []byte) ([]byte, error)
+}
+
+// stateCodecs are the codecs which may be set
+var stateCodecs = map[string]stateCodec{
+	codecJSON:     jsonCodec{},
+	codecProtobuf: protobufCodec{},
+}
+
+// jsonCodec stores the JSON documents as they are
+type jsonCodec struct{}
+
+func (jsonCodec) encode(objectType string, document []byte) ([]byte, error) {
+	return document, nil
+}
+
+// protobufCodec stores transactions and members, the records read by range scans, as protobuf
+// messages with numbered fields. The other records are stored as JSON.
+type protobufCodec struct{}
+
+func (protobufCodec) encode(objectType string, document []byte) ([]byte, error) {
+	switch objectType {
+	case transactionObjectType:
+		var transaction PointsTransaction
+		err := json.Unmarshal(document, &transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(protobufHeader(transactionMessage), marshalTransaction(&transaction)...), nil
+	case memberObjectType:
+		var member Member
+		err := json.Unmarshal(document, &member)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(protobufHeader(memberMessage), marshalMember(&member)...), nil
+	}
+
+	return document, nil
+}
+
+func protobufHeader(message byte) []byte {
+	return append(append([]byte{}, protobufPrefix...), message)
+}
+
+// SetStateCodec sets the codec of the values written to the world state, json or protobuf.
+// Records stored with the other codec stay readable, MigrateRange rewrites them.
+func (s *AdminContract) SetStateCodec(ctx TransactionContext, name string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := stateCodecs[name]; !ok {
+		return newError(ErrInvalidArgument, "unknown state codec %s, expected %s or %s", name, codecJSON, codecProtobuf)
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	config := StateCodec{
+		Codec:     name,
+		UpdatedBy: clientID,
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, configObjectType, codecConfigID, &config)
+}
+
+// GetStateCodec returns the codec of the values written to the world state, json until one is set
+func (s *AdminContract) GetStateCodec(ctx TransactionContext) (*StateCodec, error) {
+	return getStateCodec(ctx)
+}
+
+func getStateCodec(ctx TransactionContext) (*StateCodec, error) {
+	config := StateCodec{Codec: codecJSON}
+	_, err := getObject(ctx, configObjectType, codecConfigID, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// encodeState encodes the JSON document of a record of objectType with the codec set on the ledger
+func encodeState(ctx TransactionContext, objectType string, document []byte) ([]byte, error) {
+	// The configuration is JSON with every codec
+	if objectType == configObjectType {
+		return document, nil
+	}
+
+	config, err := getStateCodec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, ok := stateCodecs[config.Codec]
+	if !ok {
+		return nil, newError(ErrInternal, "unknown state codec %s", config.Codec)
+	}
+
+	value, err := codec.encode(objectType, document)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to encode %s. %s", objectType, err.Error())
+	}
+
+	return value, nil
+}
+
+// unmarshalState decodes a value of a record of objectType read from key into v. Protobuf
+// messages in the current schema are decoded straight into v, other values are brought to the
+// current schema as JSON documents first.
+func unmarshalState(objectType string, key string, value []byte, v interface{}) error {
+	decoded, err := decodeMessage(value, v)
+	if err != nil {
+		return newError(ErrInternal, "failed to decode %s. %s", key, err.Error())
+	}
+
+	if decoded {
+		return nil
+	}
+
+	value, err = upgradeRecord(objectType, value)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(value, v)
+	if err != nil {
+		return newError(ErrInternal, "failed to unmarshal %s. %s", key, err.Error())
+	}
+
+	return nil
+}
+
+// decodeMessage decodes a protobuf message into v, the transaction or member it holds. It
+// returns false for JSON values, messages of another type than v and messages stored with an
+// older schema, which are decoded through their JSON document.
+func decodeMessage(value []byte, v interface{}) (bool, error) {
+	if !bytes.HasPrefix(value, protobufPrefix) || len(value) == len(protobufPrefix) {
+		return false, nil
+	}
+
+	message := value[len(protobufPrefix)+1:]
+
+	switch target := v.(type) {
+	case *PointsTransaction:
+		if value[len(protobufPrefix)] != transactionMessage {
+			return false, nil
+		}
+
+		transaction, err := unmarshalTransaction(message)
+		if err != nil || transaction.SchemaVersion < currentSchemaVersion {
+			return false, err
+		}
+
+		*target = *transaction
+		return true, nil
+	case *Member:
+		if value[len(protobufPrefix)] != memberMessage {
+			return false, nil
+		}
+
+		member, err := unmarshalMember(message)
+		if err != nil || member.SchemaVersion < currentSchemaVersion {
+			return false, err
+		}
+
+		*target = *member
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// decodeDocument returns the JSON document of a value read from the world state, for records
+// which are upgraded or migrated
+func decodeDocument(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, protobufPrefix) || len(value) == len(protobufPrefix) {
+		return value, nil
+	}
+
+	message := value[len(protobufPrefix)+1:]
+
+	var record interface{}
+	var err error
+	switch value[len(protobufPrefix)] {
+	case transactionMessage:
+		record, err = unmarshalTransaction(message)
+	case memberMessage:
+		record, err = unmarshalMember(message)
+	default:
+		err = fmt.Errorf("unknown message %d", value[len(protobufPrefix)])
+	}
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to decode state. %s", err.Error())
+	}
+
+	document, err := json.Marshal(record)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to decode state. %s", err.Error())
+	}
+
+	return document, nil
+}
+
+// marshalTransaction encodes a transaction as the message
+//
+//	message Transaction {
+//	  string doc_type = 1; int64 schema_version = 2; string id = 3; int64 value = 4;
+//	  string merchant = 5; string created_at = 6; string sender = 7; string receiver = 8;
+//	  Source source = 9; string status = 10; string point_type = 11; string reason = 12;
+//	  string reversed_by = 13; repeated string approvals = 14; string document_hash = 15;
+//	  string document_uri = 16; int64 converted = 17; string voided_by = 18;
+//	  string voided_at = 19; string void_reason = 20;
+//	}
+//	message Source { string type = 1; string id = 2; string stockist = 3; }
+func marshalTransaction(t *PointsTransaction) []byte {
+	var b []byte
+	b = appendString(b, 1, t.DocType)
+	b = appendInt(b, 2, int64(t.SchemaVersion))
+	b = appendString(b, 3, t.ID)
+	b = appendInt(b, 4, int64(t.Value))
+	b = appendString(b, 5, t.Merchant)
+	b = appendString(b, 6, t.CreatedAt)
+	b = appendString(b, 7, t.Sender)
+	b = appendString(b, 8, t.Receiver)
+	if t.Source != nil {
+		var source []byte
+		source = appendString(source, 1, t.Source.Type)
+		source = appendString(source, 2, t.Source.ID)
+		source = appendString(source, 3, t.Source.Stockist)
+		b = appendMessage(b, 9, source)
+	}
+	b = appendString(b, 10, t.Status)
+	b = appendString(b, 11, t.PointType)
+	b = appendString(b, 12, t.Reason)
+	b = appendString(b, 13, t.ReversedBy)
+	for _, approval := range t.Approvals {
+		b = protowire.AppendTag(b, 14, protowire.BytesType)
+		b = protowire.AppendString(b, approval)
+	}
+	b = appendString(b, 15, t.DocumentHash)
+	b = appendString(b, 16, t.DocumentURI)
+	b = appendInt(b, 17, int64(t.Converted))
+	b = appendString(b, 18, t.VoidedBy)
+	b = appendString(b, 19, t.VoidedAt)
+	b = appendString(b, 20, t.VoidReason)
+	return b
+}
+
+func unmarshalTransaction(b []byte) (*PointsTransaction, error) {
+	t := PointsTransaction{}
+	err := consumeFields(b, func(num protowire.Number, v uint64, s []byte) error {
+		switch num {
+		case 1:
+			t.DocType = string(s)
+		case 2:
+			t.SchemaVersion = int(v)
+		case 3:
+			t.ID = string(s)
+		case 4:
+			t.Value = int(int64(v))
+		case 5:
+			t.Merchant = string(s)
+		case 6:
+			t.CreatedAt = string(s)
+		case 7:
+			t.Sender = string(s)
+		case 8:
+			t.Receiver = string(s)
+		case 9:
+			t.Source = &Source{}
+			return consumeFields(s, func(num protowire.Number, v uint64, s []byte) error {
+				switch num {
+				case 1:
+					t.Source.Type = string(s)
+				case 2:
+					t.Source.ID = string(s)
+				case 3:
+					t.Source.Stockist = string(s)
+				}
+				return nil
+			})
+		case 10:
+			t.Status = string(s)
+		case 11:
+			t.PointType = string(s)
+		case 12:
+			t.Reason = string(s)
+		case 13:
+			t.ReversedBy = string(s)
+		case 14:
+			t.Approvals = append(t.Approvals, string(s))
+		case 15:
+			t.DocumentHash = string(s)
+		case 16:
+			t.DocumentURI = string(s)
+		case 17:
+			t.Converted = int(int64(v))
+		case 18:
+			t.VoidedBy = string(s)
+		case 19:
+			t.VoidedAt = string(s)
+		case 20:
+			t.VoidReason = string(s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// marshalMember encodes a member as the message
+//
+//	message Member {
+//	  string doc_type = 1; int64 schema_version = 2; string id = 3; string merchant = 4;
+//	  map<string, int64> merchant_points = 5; int64 points = 6; Transaction transaction = 7;
+//	  map<string, TypedPoints> typed_points = 8; string locale = 9;
+//	}
+//	message TypedPoints { map<string, int64> points = 1; }
+func marshalMember(m *Member) []byte {
+	var b []byte
+	b = appendString(b, 1, m.DocType)
+	b = appendInt(b, 2, int64(m.SchemaVersion))
+	b = appendString(b, 3, m.ID)
+	b = appendString(b, 4, m.Merchant)
+	b = appendPoints(b, 5, m.MerchantPoints)
+	b = appendInt(b, 6, int64(m.Points))
+	if m.Transaction != nil {
+		b = appendMessage(b, 7, marshalTransaction(m.Transaction))
+	}
+	for _, merchant := range sortedKeys(m.TypedPoints) {
+		var entry []byte
+		entry = appendString(entry, 1, merchant)
+		entry = appendMessage(entry, 2, appendPoints(nil, 1, m.TypedPoints[merchant]))
+		b = appendMessage(b, 8, entry)
+	}
+	b = appendString(b, 9, m.Locale)
+	return b
+}
+
+func unmarshalMember(b []byte) (*Member, error) {
+	m := Member{MerchantPoints: map[string]int{}}
+	err := consumeFields(b, func(num protowire.Number, v uint64, s []byte) error {
+		switch num {
+		case 1:
+			m.DocType = string(s)
+		case 2:
+			m.SchemaVersion = int(v)
+		case 3:
+			m.ID = string(s)
+		case 4:
+			m.Merchant = string(s)
+		case 5:
+			return consumePoint(s, m.MerchantPoints)
+		case 6:
+			m.Points = int(int64(v))
+		case 7:
+			transaction, err := unmarshalTransaction(s)
+			m.Transaction = transaction
+			return err
+		case 8:
+			if m.TypedPoints == nil {
+				m.TypedPoints = map[string]map[string]int{}
+			}
+
+			var merchant string
+			points := map[string]int{}
+			err := consumeFields(s, func(num protowire.Number, v uint64, s []byte) error {
+				switch num {
+				case 1:
+					merchant = string(s)
+				case 2:
+					return consumeFields(s, func(num protowire.Number, v uint64, s []byte) error {
+						if num == 1 {
+							return consumePoint(s, points)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			m.TypedPoints[merchant] = points
+			return err
+		case 9:
+			m.Locale = string(s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// appendString appends a string field, empty strings are left out as in proto3
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendInt appends an int64 field, zeros are left out as in proto3
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+// appendPoints appends a map<string, int64> field in key order, so that every peer writes
+// the same bytes
+func appendPoints(b []byte, num protowire.Number, points map[string]int) []byte {
+	keys := make([]string, 0, len(points))
+	for key := range points {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var entry []byte
+		entry = appendString(entry, 1, key)
+		entry = appendInt(entry, 2, int64(points[key]))
+		b = appendMessage(b, num, entry)
+	}
+
+	return b
+}
+
+// consumePoint decodes an entry of a map<string, int64> field into points
+func consumePoint(b []byte, points map[string]int) error {
+	var key string
+	var value int
+	err := consumeFields(b, func(num protowire.Number, v uint64, s []byte) error {
+		switch num {
+		case 1:
+			key = string(s)
+		case 2:
+			value = int(int64(v))
+		}
+		return nil
+	})
+
+	points[key] = value
+	return err
+}
+
+func sortedKeys(m map[string]map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// consumeFields calls field with the value of each varint or length-delimited field of a
+// message, fields of other wire types are skipped
+func consumeFields(b []byte, field func(num protowire.Number, v uint64, s []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var err error
+		switch typ {
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			if n >= 0 {
+				err = field(num, v, nil)
+			}
+		case protowire.BytesType:
+			var s []byte
+			s, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				err = field(num, 0, s)
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtobufStateCodec(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, env.admin.SetStateCodec(env.ctx(adminIdentity), codecProtobuf))
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 100)
+
+	key, err := env.stub.CreateCompositeKey(transactionObjectType, []string{order})
+	require.NoError(t, err)
+	value, err := env.stub.GetState(key)
+	require.NoError(t, err)
+	require.Equal(t, protobufHeader(transactionMessage), value[:len(protobufPrefix)+1])
+
+	var decoded PointsTransaction
+	ok, err := decodeMessage(value, &decoded)
+	require.NoError(t, err)
+	require.True(t, ok, "current messages are decoded without a JSON document")
+	require.Equal(t, order, decoded.ID)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, 100, transaction.Value)
+	require.Equal(t, order, transaction.Source.ID)
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	codec, err := env.admin.GetStateCodec(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Equal(t, codecProtobuf, codec.Codec)
+	require.Equal(t, "admin", codec.UpdatedBy)
+}
+
+func TestSetStateCodec(t *testing.T) {
+	env := newTestEnv(t)
+
+	codec, err := env.admin.GetStateCodec(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Equal(t, codecJSON, codec.Codec)
+
+	err = env.admin.SetStateCodec(env.ctx(merchantIdentity), codecProtobuf)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.SetStateCodec(env.ctx(adminIdentity), "xml")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	// Records written as JSON are rewritten once the codec is switched
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 100)
+	require.NoError(t, env.admin.SetStateCodec(env.ctx(adminIdentity), codecProtobuf))
+
+	result, err := env.admin.MigrateRange(env.ctx(adminIdentity), transactionObjectType, transactionObjectType+"~", 10, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Migrated)
+
+	key, err := env.stub.CreateCompositeKey(transactionObjectType, []string{order})
+	require.NoError(t, err)
+	value, err := env.stub.GetState(key)
+	require.NoError(t, err)
+	require.Equal(t, protobufHeader(transactionMessage), value[:len(protobufPrefix)+1])
+}
@@ -0,0 +1,157 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// serverConfig is the configuration of the chaincode process. It is read from the YAML or
+// JSON file given with --config or CHAINCODE_CONFIG_FILE, environment variables override
+// the file and the defaults apply to settings set in neither.
+type serverConfig struct {
+	CCID              string        `yaml:"ccid"`
+	Address           string        `yaml:"address"`
+	OperationsAddress string        `yaml:"operationsAddress"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+	TLS               tlsConfig     `yaml:"tls"`
+	GRPC              grpcConfig    `yaml:"grpc"`
+	Log               logConfig     `yaml:"log"`
+	Features          featureFlags  `yaml:"features"`
+}
+
+// tlsConfig holds the TLS settings of the chaincode server
+type tlsConfig struct {
+	Disabled       bool          `yaml:"disabled"`
+	Key            string        `yaml:"key"`
+	Cert           string        `yaml:"cert"`
+	ClientCACert   string        `yaml:"clientCACert"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+// grpcConfig holds the keepalive and tuning settings of the gRPC server the peer connects to
+type grpcConfig struct {
+	// KeepaliveTime is the idle time after which the server pings the peer, lower it
+	// below the idle timeout of load balancers between the peer and the server
+	KeepaliveTime time.Duration `yaml:"keepaliveTime"`
+	// KeepaliveTimeout is how long the server waits for the answer to a ping
+	KeepaliveTimeout time.Duration `yaml:"keepaliveTimeout"`
+	// KeepaliveMinTime is the minimum interval between the pings of the peer
+	KeepaliveMinTime time.Duration `yaml:"keepaliveMinTime"`
+	// MaxConnectionIdle closes connections idle for longer, 0 keeps them open
+	MaxConnectionIdle  time.Duration `yaml:"maxConnectionIdle"`
+	MaxRecvMessageSize int           `yaml:"maxRecvMessageSize"`
+	MaxSendMessageSize int           `yaml:"maxSendMessageSize"`
+	// ConnectionTimeout bounds the TLS handshake of new connections
+	ConnectionTimeout time.Duration `yaml:"connectionTimeout"`
+}
+
+// logConfig holds the settings of the chaincode logger
+type logConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// featureFlags switch optional parts of the chaincode process on or off
+type featureFlags struct {
+	// Metrics serves the Prometheus metrics on the operations server
+	Metrics bool `yaml:"metrics"`
+}
+
+// defaultServerConfig returns the configuration used for settings which are not configured
+func defaultServerConfig() serverConfig {
+	return serverConfig{
+		OperationsAddress: "0.0.0.0:9443",
+		ShutdownTimeout:   20 * time.Second,
+		TLS: tlsConfig{
+			Disabled:       true,
+			ReloadInterval: time.Minute,
+		},
+		// The defaults of shim.ChaincodeServer
+		GRPC: grpcConfig{
+			KeepaliveTime:      time.Minute,
+			KeepaliveTimeout:   20 * time.Second,
+			KeepaliveMinTime:   time.Minute,
+			MaxRecvMessageSize: 100 * 1024 * 1024,
+			MaxSendMessageSize: 100 * 1024 * 1024,
+			ConnectionTimeout:  5 * time.Second,
+		},
+		Log: logConfig{
+			Level:  "info",
+			Format: formatJSON,
+		},
+		Features: featureFlags{
+			Metrics: true,
+		},
+	}
+}
+
+// loadServerConfig reads the configuration file, if file is not empty, and applies the
+// environment variables on top of it
+func loadServerConfig(file string) (serverConfig, error) {
+	config := defaultServerConfig()
+
+	if file != "" {
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return config, fmt.Errorf("failed to read config file %s. %s", file, err)
+		}
+
+		// YAML is a superset of JSON, so this reads both. Unknown settings are rejected
+		// to catch typos which would otherwise silently keep the default.
+		err = yaml.UnmarshalStrict(bytes, &config)
+		if err != nil {
+			return config, fmt.Errorf("failed to parse config file %s. %s", file, err)
+		}
+	}
+
+	// See chaincode.env
+	config.CCID = getEnvOrDefault("CHAINCODE_ID", config.CCID)
+	config.Address = getEnvOrDefault("CHAINCODE_SERVER_ADDRESS", config.Address)
+	config.OperationsAddress = getEnvOrDefault("CHAINCODE_OPERATIONS_ADDRESS", config.OperationsAddress)
+	config.ShutdownTimeout = getDurationOrDefault("CHAINCODE_SHUTDOWN_TIMEOUT", config.ShutdownTimeout)
+
+	config.TLS.Disabled = getBoolOrDefault(getEnvOrDefault("CHAINCODE_TLS_DISABLED", ""), config.TLS.Disabled)
+	config.TLS.Key = getEnvOrDefault("CHAINCODE_TLS_KEY", config.TLS.Key)
+	config.TLS.Cert = getEnvOrDefault("CHAINCODE_TLS_CERT", config.TLS.Cert)
+	config.TLS.ClientCACert = getEnvOrDefault("CHAINCODE_CLIENT_CA_CERT", config.TLS.ClientCACert)
+	config.TLS.ReloadInterval = getDurationOrDefault("CHAINCODE_TLS_RELOAD_INTERVAL", config.TLS.ReloadInterval)
+
+	config.GRPC.KeepaliveTime = getDurationOrDefault("CHAINCODE_KEEPALIVE_TIME", config.GRPC.KeepaliveTime)
+	config.GRPC.KeepaliveTimeout = getDurationOrDefault("CHAINCODE_KEEPALIVE_TIMEOUT", config.GRPC.KeepaliveTimeout)
+	config.GRPC.KeepaliveMinTime = getDurationOrDefault("CHAINCODE_KEEPALIVE_MIN_TIME", config.GRPC.KeepaliveMinTime)
+	config.GRPC.MaxConnectionIdle = getDurationOrDefault("CHAINCODE_MAX_CONNECTION_IDLE", config.GRPC.MaxConnectionIdle)
+	config.GRPC.MaxRecvMessageSize = getIntOrDefault("CHAINCODE_MAX_RECV_MSG_SIZE", config.GRPC.MaxRecvMessageSize)
+	config.GRPC.MaxSendMessageSize = getIntOrDefault("CHAINCODE_MAX_SEND_MSG_SIZE", config.GRPC.MaxSendMessageSize)
+	config.GRPC.ConnectionTimeout = getDurationOrDefault("CHAINCODE_CONNECTION_TIMEOUT", config.GRPC.ConnectionTimeout)
+
+	config.Log.Level = getEnvOrDefault("CHAINCODE_LOG_LEVEL", config.Log.Level)
+	config.Log.Format = getEnvOrDefault("CHAINCODE_LOG_FORMAT", config.Log.Format)
+
+	config.Features.Metrics = getBoolOrDefault(getEnvOrDefault("CHAINCODE_METRICS_ENABLED", ""), config.Features.Metrics)
+
+	return config, config.validate()
+}
+
+// validate rejects settings the servers cannot run with
+func (c serverConfig) validate() error {
+	if c.GRPC.KeepaliveTime <= 0 || c.GRPC.KeepaliveTimeout <= 0 {
+		return fmt.Errorf("gRPC keepalive time and timeout must be positive")
+	}
+
+	if c.GRPC.MaxRecvMessageSize <= 0 || c.GRPC.MaxSendMessageSize <= 0 {
+		return fmt.Errorf("gRPC maximum message sizes must be positive")
+	}
+
+	if c.GRPC.ConnectionTimeout <= 0 {
+		return fmt.Errorf("gRPC connection timeout must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func TestLoadServerConfigDefaults(t *testing.T) {
+	config, err := loadServerConfig("")
+	require.NoError(t, err)
+	require.Equal(t, defaultServerConfig(), config)
+}
+
+func TestLoadServerConfigYAML(t *testing.T) {
+	file := writeConfigFile(t, "chaincode.yaml", `
+ccid: points:1
+address: localhost:9999
+shutdownTimeout: 5s
+tls:
+  disabled: false
+  key: server.key
+  cert: server.crt
+log:
+  level: debug
+features:
+  metrics: false
+`)
+
+	config, err := loadServerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "points:1", config.CCID)
+	require.Equal(t, "localhost:9999", config.Address)
+	require.Equal(t, 5*time.Second, config.ShutdownTimeout)
+	require.False(t, config.TLS.Disabled)
+	require.Equal(t, "server.key", config.TLS.Key)
+	require.Equal(t, time.Minute, config.TLS.ReloadInterval, "settings missing from the file keep the default")
+	require.Equal(t, "debug", config.Log.Level)
+	require.Equal(t, formatJSON, config.Log.Format)
+	require.False(t, config.Features.Metrics)
+}
+
+func TestLoadServerConfigJSON(t *testing.T) {
+	file := writeConfigFile(t, "chaincode.json", `{"ccid": "points:2", "operationsAddress": "", "log": {"format": "text"}}`)
+
+	config, err := loadServerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "points:2", config.CCID)
+	require.Empty(t, config.OperationsAddress)
+	require.Equal(t, formatText, config.Log.Format)
+}
+
+func TestLoadServerConfigEnvironmentOverrides(t *testing.T) {
+	file := writeConfigFile(t, "chaincode.yaml", "ccid: points:1\nshutdownTimeout: 5s\n")
+
+	t.Setenv("CHAINCODE_ID", "points:3")
+	t.Setenv("CHAINCODE_SHUTDOWN_TIMEOUT", "1m")
+	t.Setenv("CHAINCODE_TLS_DISABLED", "false")
+	t.Setenv("CHAINCODE_METRICS_ENABLED", "false")
+
+	config, err := loadServerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "points:3", config.CCID)
+	require.Equal(t, time.Minute, config.ShutdownTimeout)
+	require.False(t, config.TLS.Disabled)
+	require.False(t, config.Features.Metrics)
+}
+
+func TestLoadServerConfigErrors(t *testing.T) {
+	_, err := loadServerConfig(filepath.Join(os.TempDir(), "missing-chaincode.yaml"))
+	require.Error(t, err)
+
+	file := writeConfigFile(t, "chaincode.yaml", "adress: localhost:9999\n")
+	_, err = loadServerConfig(file)
+	require.Error(t, err, "unknown settings are rejected")
+
+	file = writeConfigFile(t, "chaincode.yaml", "grpc:\n  keepaliveTime: 0s\n")
+	_, err = loadServerConfig(file)
+	require.EqualError(t, err, "gRPC keepalive time and timeout must be positive")
+
+	t.Setenv("CHAINCODE_MAX_RECV_MSG_SIZE", "0")
+	_, err = loadServerConfig("")
+	require.EqualError(t, err, "gRPC maximum message sizes must be positive")
+}
+
+func TestLoadServerConfigGRPC(t *testing.T) {
+	file := writeConfigFile(t, "chaincode.yaml", "grpc:\n  keepaliveTime: 30s\n  maxConnectionIdle: 5m\n")
+
+	t.Setenv("CHAINCODE_MAX_SEND_MSG_SIZE", "1048576")
+
+	config, err := loadServerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, config.GRPC.KeepaliveTime)
+	require.Equal(t, 20*time.Second, config.GRPC.KeepaliveTimeout)
+	require.Equal(t, 5*time.Minute, config.GRPC.MaxConnectionIdle)
+	require.Equal(t, 1048576, config.GRPC.MaxSendMessageSize)
+	require.Equal(t, 100*1024*1024, config.GRPC.MaxRecvMessageSize)
+}
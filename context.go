@@ -0,0 +1,82 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Stub is the part of the chaincode stub the contracts use: the world state and its composite
+// keys, private data, the proposal and events
+type Stub interface {
+	GetFunctionAndParameters() (string, []string)
+	GetTxID() string
+	GetChannelID() string
+	GetTxTimestamp() (*timestamp.Timestamp, error)
+	GetTransient() (map[string][]byte, error)
+	InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response
+
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+	DelState(key string) error
+	GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error)
+	GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error)
+	GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error)
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+	SplitCompositeKey(compositeKey string) (string, []string, error)
+	GetStateValidationParameter(key string) ([]byte, error)
+	SetStateValidationParameter(key string, ep []byte) error
+
+	GetPrivateData(collection, key string) ([]byte, error)
+	PutPrivateData(collection string, key string, value []byte) error
+
+	SetEvent(name string, payload []byte) error
+}
+
+// ClientIdentity is the part of the client identity the contracts check
+type ClientIdentity interface {
+	GetID() (string, error)
+	GetMSPID() (string, error)
+	AssertAttributeValue(attrName, attrValue string) error
+}
+
+// TransactionContext is the context every contract function and helper takes. It only exposes
+// the Stub and ClientIdentity above, so tests can implement it over fakes of either.
+type TransactionContext interface {
+	GetStub() Stub
+	GetClientIdentity() ClientIdentity
+}
+
+// transactionContext is the transaction context contractapi builds for the contracts, it
+// narrows the stub and client identity of a contractapi.TransactionContext and collects the
+// events of the transaction
+type transactionContext struct {
+	contractapi.TransactionContext
+	events []Event
+}
+
+// GetStub returns the stub of the transaction
+func (ctx *transactionContext) GetStub() Stub {
+	return ctx.TransactionContext.GetStub()
+}
+
+// GetClientIdentity returns the identity of the client submitting the transaction
+func (ctx *transactionContext) GetClientIdentity() ClientIdentity {
+	clientIdentity := ctx.TransactionContext.GetClientIdentity()
+	if clientIdentity == nil {
+		return nil
+	}
+
+	return clientIdentity
+}
+
+// collectEvent adds an event to those emitted during the transaction and returns them all
+func (ctx *transactionContext) collectEvent(event Event) []Event {
+	ctx.events = append(ctx.events, event)
+	return ctx.events
+}
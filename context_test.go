@@ -0,0 +1,43 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// unreadableIdentity is a client identity whose certificate cannot be read
+type unreadableIdentity struct {
+	*testIdentity
+}
+
+func (i unreadableIdentity) GetMSPID() (string, error) {
+	return "", errors.New("no certificate")
+}
+
+func TestTransactionContextNarrowsContractapi(t *testing.T) {
+	stub := shimtest.NewMockStub("points-transfer", nil)
+	ctx := new(transactionContext)
+	ctx.SetStub(stub)
+	require.Equal(t, stub, ctx.GetStub())
+	require.Nil(t, ctx.GetClientIdentity(), "no identity is not a nil interface holding a nil identity")
+
+	ctx.SetClientIdentity(merchantIdentity)
+	require.Equal(t, merchantIdentity, ctx.GetClientIdentity())
+}
+
+func TestGetClientFailsOnUnreadableIdentity(t *testing.T) {
+	env := newTestEnv(t)
+	_, _, err := getClient(env.ctx(merchantIdentity))
+	require.NoError(t, err)
+
+	ctx := &mockContext{stub: env.stub, identity: unreadableIdentity{merchantIdentity}}
+	_, _, err = getClient(ctx)
+	requireErrorCode(t, err, ErrInternal)
+}
@@ -0,0 +1,303 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+// ledgerMethods are the methods of the Stub which read or write the ledger
+var ledgerMethods = []string{
+	"GetState",
+	"PutState",
+	"DelState",
+	"GetStateByRange",
+	"GetStateByPartialCompositeKey",
+	"GetStateByPartialCompositeKeyWithPagination",
+	"CreateCompositeKey",
+	"GetStateValidationParameter",
+	"SetStateValidationParameter",
+	"GetPrivateData",
+	"PutPrivateData",
+	"GetTxTimestamp",
+	"GetTransient",
+	"InvokeChaincode",
+	"SetEvent",
+}
+
+// functionPaths holds the error code a contract function returns on each path of the mock
+// sweeps, "" if it succeeds
+type functionPaths struct {
+	// ledgerDown is the code when every ledger method of the stub fails
+	ledgerDown string
+	// notFound is the code when none of the IDs passed to it is stored
+	notFound string
+	// otherMSP is the code when a client of another organization calls it on a ledger holding
+	// the merchant m1 and its customer alice
+	otherMSP string
+}
+
+// contractPaths lists the paths of every function of the contracts. The sweeps pass the same
+// ID to every string parameter and 10 to every number, so a code tells which check of the
+// function rejects such a call first.
+var contractPaths = map[string]functionPaths{
+	"AcceptGift":                       {ErrInternal, ErrNotFound, ErrNotFound},
+	"AccrueLifeCardBonus":              {ErrInternal, ErrNotFound, ErrNotFound},
+	"AnchorDocument":                   {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"Approve":                          {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"ApproveAccount":                   {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"ApproveAdjustment":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"ApproveTransaction":               {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"ArchivePeriod":                    {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"AwardCampaignPoints":              {ErrInternal, ErrNotFound, ErrNotFound},
+	"BalanceOf":                        {ErrInternal, ErrNotFound, ""},
+	"BurnPoints":                       {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"CapturePoints":                    {ErrInternal, ErrNotFound, ErrNotFound},
+	"ConvertPoints":                    {ErrInternal, ErrNotFound, ErrNotFound},
+	"CreateCampaign":                   {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"CreateGiftTransactionPrivate":     {ErrInternal, ErrInvalidArgument, ErrInvalidArgument},
+	"CreateMember":                     {ErrInternal, ErrNotFound, ""},
+	"CreateOrderTransaction":           {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"CreateTransaction":                {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"CreateTransactionsBatch":          {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"DeactivateMerchant":               {ErrInternal, ErrInvalidState, ErrUnauthorized},
+	"Decimals":                         {ErrInternal, ErrNotFound, ""},
+	"ExpireGift":                       {ErrInternal, ErrNotFound, ErrNotFound},
+	"ExpirePoints":                     {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"FreezeAccount":                    {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"GetAccountEndorsement":            {ErrInternal, ErrNotFound, ""},
+	"GetAccountMerge":                  {ErrInternal, "", ""},
+	"GetAccountRequests":               {ErrInternal, "", ErrInvalidArgument},
+	"GetAdjustment":                    {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetAllMembers":                    {ErrInternal, "", ""},
+	"GetAllMerchants":                  {"", "", ""},
+	"GetAllowance":                     {ErrInternal, "", ""},
+	"GetBalanceProvenance":             {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"GetBirthdayGrant":                 {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetBridgeConfig":                  {ErrInternal, ErrInvalidState, ErrInvalidState},
+	"GetBridgeReceipt":                 {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetCampaign":                      {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetCampaignSpend":                 {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetCustomersByMerchant":           {"", "", ""},
+	"GetExchangeRate":                  {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetFreeze":                        {ErrInternal, "", ""},
+	"GetGift":                          {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetHold":                          {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetLifeCard":                      {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetLifeCardStatus":                {ErrInternal, "", ""},
+	"GetMember":                        {ErrInternal, ErrNotFound, ""},
+	"GetMemberPrivateDetails":          {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"GetMemberPrivateHash":             {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetMerchant":                      {ErrInternal, ErrNotFound, ""},
+	"GetMerchantMSP":                   {ErrInternal, ErrNotFound, ""},
+	"GetMergedTransactions":            {ErrInternal, "", ""},
+	"GetMyAccount":                     {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetMyBalance":                     {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetOperatorMSP":                   {ErrInternal, "", ""},
+	"GetOrderReward":                   {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetPauseState":                    {ErrInternal, "", ""},
+	"GetPaymentsIntegration":           {ErrInternal, "", ""},
+	"GetPeriodSummary":                 {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"GetPrivateGiftBalance":            {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"GetProgramStats":                  {ErrInternal, ErrNotFound, ""},
+	"GetProgramStatsAsCSV":             {ErrInternal, ErrNotFound, ""},
+	"GetReferral":                      {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetSettlementReport":              {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"GetSettlementReportAsCSV":         {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"GetStateCodec":                    {ErrInternal, "", ""},
+	"GetStockistBalance":               {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"GetTier":                          {ErrInternal, "", ""},
+	"GetTransaction":                   {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetVelocityLimits":                {ErrInternal, "", ""},
+	"GetVelocityUsage":                 {ErrInternal, "", ""},
+	"GetVoucher":                       {ErrInternal, ErrNotFound, ErrNotFound},
+	"GetVouchersByOwner":               {ErrInternal, "", ""},
+	"GetVouchersByStatus":              {ErrInternal, "", ""},
+	"GrantBirthdayPoints":              {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"HoldPoints":                       {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"InitLedger":                       {ErrInternal, "", ""},
+	"IssueLifeCard":                    {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"IssuePoints":                      {ErrInternal, ErrNotFound, ErrNotFound},
+	"IssueVoucher":                     {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"LockForBridge":                    {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"MergeAccounts":                    {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"MigrateFlatKeys":                  {ErrInternal, "", ErrUnauthorized},
+	"MigrateRange":                     {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"MintFromBridge":                   {ErrInternal, ErrInvalidState, ErrInvalidState},
+	"Name":                             {ErrInternal, ErrNotFound, ""},
+	"OfferGift":                        {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"Pause":                            {ErrInternal, "", ErrUnauthorized},
+	"ProposeAdjustment":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"PruneDeltas":                      {ErrInternal, ErrNotFound, ""},
+	"PurgeByPrefix":                    {ErrInternal, ErrInvalidState, ErrUnauthorized},
+	"PutMemberPrivateDetails":          {ErrInternal, ErrInvalidArgument, ErrInvalidArgument},
+	"QueryAuditLog":                    {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"QueryMyTransactions":              {ErrInternal, ErrNotFound, ErrNotFound},
+	"QueryPointsExpiringWithin":        {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"QueryStockistCommissions":         {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"QueryTransactionsByStatus":        {ErrInternal, "", ""},
+	"QueryTransactionsByStatusAndType": {ErrInternal, "", ""},
+	"RecordReferral":                   {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"RedeemPoints":                     {ErrInternal, ErrNotFound, ErrNotFound},
+	"RedeemVoucher":                    {ErrInternal, ErrNotFound, ErrNotFound},
+	"RegisterAccount":                  {ErrInternal, "", ErrInvalidArgument},
+	"RegisterMerchant":                 {ErrInternal, ErrInvalidState, ErrUnauthorized},
+	"ReindexTransactions":              {ErrInternal, "", ErrUnauthorized},
+	"RejectAccount":                    {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"RejectAdjustment":                 {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"RejectGift":                       {ErrInternal, ErrNotFound, ErrNotFound},
+	"ReleaseExpiredHolds":              {ErrInternal, "", ErrUnauthorized},
+	"ReleaseHold":                      {ErrInternal, ErrNotFound, ErrNotFound},
+	"RenewLifeCard":                    {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"ReverseTransaction":               {ErrInternal, ErrNotFound, ErrNotFound},
+	"RevokeLifeCard":                   {ErrInternal, ErrNotFound, ErrInvalidArgument},
+	"SetAccountEndorsement":            {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetApprovalPolicy":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetBirthdayPoints":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetBridgeConfig":                  {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"SetDefaultVelocityLimits":         {ErrInternal, "", ErrUnauthorized},
+	"SetExchangeRate":                  {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"SetLifeCardProgram":               {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetMemberLocale":                  {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetMerchantMSP":                   {ErrInternal, ErrInvalidState, ErrUnauthorized},
+	"SetOperatorMSP":                   {ErrInternal, "", ErrUnauthorized},
+	"SetPaymentsIntegration":           {ErrInternal, "", ErrUnauthorized},
+	"SetPointTypeRule":                 {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetReferralPoints":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetStateCodec":                    {ErrInvalidArgument, ErrInvalidArgument, ErrUnauthorized},
+	"SetStockistCommission":            {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetTokenSymbol":                   {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"SetVelocityLimits":                {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"Symbol":                           {ErrInternal, ErrNotFound, ""},
+	"TotalSupply":                      {ErrInternal, ErrNotFound, ""},
+	"Transfer":                         {ErrInternal, ErrUnauthorized, ErrUnauthorized},
+	"TransferFrom":                     {ErrInternal, ErrUnauthorized, ErrUnauthorized},
+	"UnfreezeAccount":                  {ErrInternal, "", ErrUnauthorized},
+	"Unpause":                          {ErrInternal, "", ErrUnauthorized},
+	"UpdateMerchant":                   {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"UpdateStatus":                     {ErrInternal, ErrNotFound, ErrNotFound},
+	"VerifyBalance":                    {ErrInternal, ErrNotFound, ErrUnauthorized},
+	"VerifyDocument":                   {ErrInvalidArgument, ErrInvalidArgument, ErrInvalidArgument},
+	"VoidTransaction":                  {ErrInternal, ErrNotFound, ErrUnauthorized},
+}
+
+func TestContractPathsListEveryFunction(t *testing.T) {
+	functions := []string{}
+	for _, contract := range sweptContracts() {
+		functions = append(functions, contractFunctions(contract)...)
+	}
+
+	listed := []string{}
+	for function := range contractPaths {
+		listed = append(listed, function)
+	}
+
+	require.ElementsMatch(t, functions, listed)
+}
+
+func TestContractFunctionsSurfaceLedgerErrors(t *testing.T) {
+	codes := sweepContracts(t, adminIdentity, "missing", func(env *testEnv) {
+		for _, method := range ledgerMethods {
+			env.stub.failWith(method, errors.New("ledger unavailable"))
+		}
+	})
+
+	for _, function := range sortedFunctions(codes) {
+		require.Equalf(t, contractPaths[function].ledgerDown, codes[function], "%s with the ledger down", function)
+	}
+}
+
+func TestContractFunctionsReportMissingRecords(t *testing.T) {
+	codes := sweepContracts(t, adminIdentity, "missing", func(env *testEnv) {})
+
+	for _, function := range sortedFunctions(codes) {
+		require.Equalf(t, contractPaths[function].notFound, codes[function], "%s on an empty ledger", function)
+	}
+}
+
+func TestContractFunctionsCheckTheCaller(t *testing.T) {
+	codes := sweepContracts(t, otherMSPIdentity, "m1", func(env *testEnv) {
+		env.registerMerchant("m1")
+		env.reward("m1", "alice", 100)
+	})
+
+	for _, function := range sortedFunctions(codes) {
+		require.Equalf(t, contractPaths[function].otherMSP, codes[function], "%s called by another organization", function)
+	}
+}
+
+func sweptContracts() []contractapi.ContractInterface {
+	return []contractapi.ContractInterface{new(PointsContract), new(MerchantContract), new(AdminContract)}
+}
+
+// sweepContracts calls every function of the contracts in a transaction of the identity, each
+// on a new ledger set up by prepare, passing id to every string parameter. It returns the error
+// code of each call, failing the test on errors which are not a ContractError.
+func sweepContracts(t *testing.T, identity *testIdentity, id string, prepare func(env *testEnv)) map[string]string {
+	codes := map[string]string{}
+	for _, contract := range sweptContracts() {
+		for _, function := range contractFunctions(contract) {
+			env := newTestEnv(t)
+			prepare(env)
+
+			method := reflect.ValueOf(contract).MethodByName(function)
+			results := method.Call(sweepArgs(env.ctx(identity), method.Type(), id))
+
+			codes[function] = ""
+			if len(results) == 0 {
+				continue
+			}
+
+			err, _ := results[len(results)-1].Interface().(error)
+			if err == nil {
+				continue
+			}
+
+			contractErr, ok := err.(*ContractError)
+			require.Truef(t, ok, "%s returned %v, which is not a ContractError", function, err)
+			codes[function] = contractErr.Code
+		}
+	}
+
+	return codes
+}
+
+// sweepArgs returns the arguments of a call to a contract function of the given type
+func sweepArgs(ctx TransactionContext, functionType reflect.Type, id string) []reflect.Value {
+	args := []reflect.Value{}
+	for i := 0; i < functionType.NumIn(); i++ {
+		paramType := functionType.In(i)
+		switch {
+		case i == 0 && reflect.TypeOf(ctx).Implements(paramType):
+			args = append(args, reflect.ValueOf(ctx))
+		case paramType.Kind() == reflect.String:
+			args = append(args, reflect.ValueOf(id).Convert(paramType))
+		case paramType.Kind() == reflect.Int || paramType.Kind() == reflect.Int32 || paramType.Kind() == reflect.Int64:
+			args = append(args, reflect.ValueOf(10).Convert(paramType))
+		case paramType == reflect.TypeOf([]string{}):
+			args = append(args, reflect.ValueOf([]string{id}))
+		default:
+			args = append(args, reflect.Zero(paramType))
+		}
+	}
+
+	return args
+}
+
+func sortedFunctions(codes map[string]string) []string {
+	functions := []string{}
+	for function := range codes {
+		functions = append(functions, function)
+	}
+	sort.Strings(functions)
+
+	return functions
+}
@@ -0,0 +1,187 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const exchangeRateObjectType = "exchangeRate"
+
+// ExchangeRate converts points of one merchant into points of another. The rate is kept
+// as a fraction so conversions give the same result on every peer.
+type ExchangeRate struct {
+	Schema
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Numerator   int    `json:"numerator"`
+	Denominator int    `json:"denominator"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// SetExchangeRate sets how many points of toMerchant one point of fromMerchant is worth,
+// as numerator/denominator
+func (s *AdminContract) SetExchangeRate(ctx TransactionContext, fromMerchant string, toMerchant string, numerator int, denominator int) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if fromMerchant == toMerchant {
+		return newError(ErrInvalidArgument, "cannot set an exchange rate from %s to itself", fromMerchant)
+	}
+
+	if numerator <= 0 || denominator <= 0 {
+		return newError(ErrInvalidArgument, "exchange rate must be positive")
+	}
+
+	for _, merchant := range []string{fromMerchant, toMerchant} {
+		_, err = getMerchant(ctx, merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	rate := ExchangeRate{
+		From:        fromMerchant,
+		To:          toMerchant,
+		Numerator:   numerator,
+		Denominator: denominator,
+		UpdatedAt:   now.Format(time.RFC3339),
+	}
+
+	return putCompositeObject(ctx, exchangeRateObjectType, []string{fromMerchant, toMerchant}, &rate)
+}
+
+// GetExchangeRate returns the exchange rate from one merchant's points to another's
+func (s *PointsContract) GetExchangeRate(ctx TransactionContext, fromMerchant string, toMerchant string) (*ExchangeRate, error) {
+	return getExchangeRate(ctx, fromMerchant, toMerchant)
+}
+
+// ConvertPoints debits value points of fromMerchant from the owner and credits them the
+// equivalent points of toMerchant at the stored exchange rate. It returns the credited points.
+func (s *PointsContract) ConvertPoints(ctx TransactionContext, owner string, fromMerchant string, toMerchant string, value int) (int, error) {
+	rate, err := getExchangeRate(ctx, fromMerchant, toMerchant)
+	if err != nil {
+		return 0, err
+	}
+
+	converted := value * rate.Numerator / rate.Denominator
+	if converted <= 0 {
+		return 0, newError(ErrInvalidArgument, "%d points of %s are worth no points of %s", value, fromMerchant, toMerchant)
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	if member.Merchant == "" {
+		return 0, newError(ErrInvalidArgument, "%s is a merchant and cannot convert points", owner)
+	}
+
+	err = assertAccountOwner(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	err = assertNotFrozen(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	if member.MerchantPoints[fromMerchant]-typedPointsOf(member, fromMerchant) < value {
+		return 0, newError(ErrInsufficientPoints, "%s does not have enough points of %s", owner, fromMerchant)
+	}
+
+	// The merchants' outstanding points move with the customer's
+	from, err := getMember(ctx, fromMerchant)
+	if err != nil {
+		return 0, err
+	}
+
+	to, err := getMember(ctx, toMerchant)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, merchant := range []string{fromMerchant, toMerchant} {
+		err = assertMerchantActive(ctx, merchant)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     value,
+		Merchant:  fromMerchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    owner,
+		Receiver:  owner,
+		Source:    &Source{Type: TypeConversion, ID: toMerchant},
+		Status:    StatusConfirmed,
+		Converted: converted,
+	}
+
+	member.MerchantPoints[fromMerchant] -= value
+	member.MerchantPoints[toMerchant] += converted
+	member.Points += converted - value
+	member.Transaction = &transaction
+
+	from.Points -= value
+	to.Points += converted
+
+	err = recordSettlement(ctx, fromMerchant, settlementTransferred, transaction.ID, value)
+	if err != nil {
+		return 0, err
+	}
+
+	err = updateProgramStats(ctx, fromMerchant, programAdjusted, -value)
+	if err != nil {
+		return 0, err
+	}
+
+	err = updateProgramStats(ctx, toMerchant, programAdjusted, converted)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range []*Member{member, from, to} {
+		err = putMember(ctx, m)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = putTransaction(ctx, &transaction)
+	if err != nil {
+		return 0, err
+	}
+
+	return converted, nil
+}
+
+func getExchangeRate(ctx TransactionContext, fromMerchant string, toMerchant string) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	found, err := getCompositeObject(ctx, exchangeRateObjectType, []string{fromMerchant, toMerchant}, &rate)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "no exchange rate from %s to %s", fromMerchant, toMerchant)
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,69 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetExchangeRate(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+
+	err := env.admin.SetExchangeRate(env.ctx(merchantIdentity), "m1", "m2", 1, 2)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "m1", 1, 2)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "m2", 1, 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "unknown", 1, 2)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "m2", 1, 2))
+
+	rate, err := env.points.GetExchangeRate(env.ctx(adminIdentity), "m1", "m2")
+	require.NoError(t, err)
+	require.Equal(t, 1, rate.Numerator)
+	require.Equal(t, 2, rate.Denominator)
+
+	_, err = env.points.GetExchangeRate(env.ctx(adminIdentity), "m2", "m1")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestConvertPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	env.reward("m1", "alice", 100)
+	env.reward("m2", "bob", 10)
+	alice := env.registerAccount("alice")
+	require.NoError(t, env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "m2", 1, 2))
+
+	converted, err := env.points.ConvertPoints(env.ctx(alice), "alice", "m1", "m2", 40)
+	require.NoError(t, err)
+	require.Equal(t, 20, converted)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 20, env.balance("alice", "m2"))
+	require.Equal(t, 60, env.member("m1").Points)
+	require.Equal(t, 30, env.member("m2").Points)
+
+	_, err = env.points.ConvertPoints(env.ctx(alice), "alice", "m1", "m2", 61)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.ConvertPoints(env.ctx(alice), "alice", "m1", "m2", 1)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.ConvertPoints(env.ctx(customerIdentity("bob")), "alice", "m1", "m2", 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.ConvertPoints(env.ctx(alice), "alice", "m2", "m1", 10)
+	requireErrorCode(t, err, ErrNotFound)
+}
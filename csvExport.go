@@ -0,0 +1,73 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+)
+
+// GetSettlementReportAsCSV returns the settlement report of a merchant as CSV, one row per
+// month followed by a total row
+func (s *MerchantContract) GetSettlementReportAsCSV(ctx TransactionContext, merchant string, periodStart string, periodEnd string) (string, error) {
+	report, err := s.GetSettlementReport(ctx, merchant, periodStart, periodEnd)
+	if err != nil {
+		return "", err
+	}
+
+	records := [][]string{{"merchant", "month", "issued", "redeemed", "expired", "transferred"}}
+	for _, totals := range report.Months {
+		records = append(records, settlementRecord(merchant, totals.Month, &totals))
+	}
+
+	records = append(records, settlementRecord(merchant, "total", &report.Total))
+
+	return writeCSV(records)
+}
+
+func settlementRecord(merchant string, month string, totals *SettlementTotals) []string {
+	return []string{
+		merchant,
+		month,
+		strconv.Itoa(totals.Issued),
+		strconv.Itoa(totals.Redeemed),
+		strconv.Itoa(totals.Expired),
+		strconv.Itoa(totals.Transferred),
+	}
+}
+
+// GetProgramStatsAsCSV returns the program statistics of a merchant as CSV
+func (s *MerchantContract) GetProgramStatsAsCSV(ctx TransactionContext, merchant string) (string, error) {
+	stats, err := s.GetProgramStats(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	return writeCSV([][]string{
+		{"merchant", "issued", "redeemed", "burned", "expired", "outstanding", "updated_at"},
+		{
+			merchant,
+			strconv.Itoa(stats.Issued),
+			strconv.Itoa(stats.Redeemed),
+			strconv.Itoa(stats.Burned),
+			strconv.Itoa(stats.Expired),
+			strconv.Itoa(stats.Outstanding),
+			stats.UpdatedAt,
+		},
+	})
+}
+
+func writeCSV(records [][]string) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	err := writer.WriteAll(records)
+	if err != nil {
+		return "", newError(ErrInternal, "failed to write CSV. %s", err.Error())
+	}
+
+	return buffer.String(), nil
+}
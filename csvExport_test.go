@@ -0,0 +1,37 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettlementReportAsCSV(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	csv, err := env.merchants.GetSettlementReportAsCSV(env.ctx(merchantIdentity), "m1", "2024-03", "2024-03")
+	require.NoError(t, err)
+	require.Equal(t, "merchant,month,issued,redeemed,expired,transferred\nm1,2024-03,100,0,0,0\nm1,total,100,0,0,0\n", csv)
+
+	_, err = env.merchants.GetSettlementReportAsCSV(env.ctx(otherMSPIdentity), "m1", "2024-03", "2024-03")
+	requireErrorCode(t, err, ErrUnauthorized)
+}
+
+func TestGetProgramStatsAsCSV(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	csv, err := env.merchants.GetProgramStatsAsCSV(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "merchant,issued,redeemed,burned,expired,outstanding,updated_at\nm1,100,0,0,0,100,2024-03-15T10:00:00Z\n", csv)
+
+	_, err = env.merchants.GetProgramStatsAsCSV(env.ctx(merchantIdentity), "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
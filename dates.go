@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// legacyDateLayout is the date format of the transactions written by the first InitLedger
+const legacyDateLayout = "20060102"
+
+// formatDate formats a date as stored in the world state: RFC3339 in UTC, so that stored
+// dates sort chronologically as strings
+func formatDate(date time.Time) string {
+	return date.UTC().Format(time.RFC3339)
+}
+
+// parseTransactionDate parses the creation date of a transaction, accepting the legacy YYYYMMDD format
+func parseTransactionDate(date string) (time.Time, bool) {
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err == nil {
+		return parsed, true
+	}
+
+	parsed, err = time.Parse(legacyDateLayout, date)
+	return parsed, err == nil
+}
+
+// normalizeDate rewrites a stored date in the format of formatDate, dates which cannot be
+// parsed are returned unchanged
+func normalizeDate(date string) string {
+	parsed, ok := parseTransactionDate(date)
+	if !ok {
+		return date
+	}
+
+	return formatDate(parsed)
+}
+
+// inputDate returns the date passed by a caller in the format of formatDate, or the
+// transaction timestamp when it is empty
+func inputDate(ctx TransactionContext, date string) (string, error) {
+	if date == "" {
+		now, err := txTime(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		return formatDate(now), nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return "", newError(ErrInvalidArgument, "invalid date %s, dates must be RFC3339. %s", date, err.Error())
+	}
+
+	return formatDate(parsed), nil
+}
@@ -0,0 +1,50 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	require.Equal(t, "2021-10-09T00:00:00Z", normalizeDate("20211009"))
+	require.Equal(t, "2024-03-15T08:00:00Z", normalizeDate("2024-03-15T10:00:00+02:00"))
+	require.Equal(t, "yesterday", normalizeDate("yesterday"), "dates which cannot be parsed are kept")
+}
+
+func TestCreateTransactionDates(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "20240315", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 10, "m1", "2024-03-15T12:30:00+08:00", TypeOrder, "o2")
+	require.NoError(t, err)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), "t2")
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-15T04:30:00Z", transaction.CreatedAt, "dates are stored in UTC")
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t3", "m1", "alice", 10, "m1", "", TypeOrder, "o3")
+	require.NoError(t, err)
+
+	transaction, err = env.points.GetTransaction(env.ctx(adminIdentity), "t3")
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-15T10:00:00Z", transaction.CreatedAt, "the transaction timestamp is the default")
+}
+
+func TestUpgradeRecordNormalizesDates(t *testing.T) {
+	upgraded, err := upgradeRecord(transactionObjectType, []byte(`{"schemaVersion":2,"ID":"t1","value":5,"created_at":"20211009"}`))
+	require.NoError(t, err)
+
+	var transaction PointsTransaction
+	require.NoError(t, json.Unmarshal(upgraded, &transaction))
+	require.Equal(t, "2021-10-09T00:00:00Z", transaction.CreatedAt)
+	require.Equal(t, currentSchemaVersion, transaction.SchemaVersion)
+}
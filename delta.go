@@ -0,0 +1,300 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+const (
+	// balanceDeltaObjectType stores points credited to a member without writing the member, so
+	// that transactions crediting the same member in a block do not conflict
+	balanceDeltaObjectType = "balanceDelta"
+
+	// counterDeltaObjectType stores the increments of the tier, velocity and program statistics
+	// counters by credits written as balance deltas, keyed by the object type and attributes of
+	// the counter and the transaction, so that the credits do not read the counters either
+	counterDeltaObjectType = "counterDelta"
+)
+
+// BalanceDelta holds points credited to a member by one transaction, added to the member's
+// balances when it is read and removed when the member is written
+type BalanceDelta struct {
+	Schema
+	Owner          string                    `json:"owner"`
+	Points         int                       `json:"points"`
+	MerchantPoints map[string]int            `json:"merchantPoints"`
+	TypedPoints    map[string]map[string]int `json:"typedPoints,omitempty" metadata:"typedPoints,optional"`
+	// Transaction is set on the deltas of customers, whose last transaction it becomes
+	Transaction *PointsTransaction `json:"transaction,omitempty" metadata:"transaction,optional"`
+}
+
+// CounterDelta is the increment of a counter by one transaction, added to the counter when it
+// is read and removed when the counter is written
+type CounterDelta struct {
+	Schema
+	Value int `json:"value"`
+	// Period is the month of a tier delta and the day of a velocity delta
+	Period string `json:"period,omitempty" metadata:"period,optional"`
+}
+
+// PruneDeltas consolidates the balance deltas of a member into the member, and the counter
+// deltas into its tiers, velocity usage or program statistics, and returns the number of
+// deltas removed. The balances do not change, reads just get cheaper. Tier changes from
+// credits written as deltas are announced with TierChanged events here.
+func (s *PointsContract) PruneDeltas(ctx TransactionContext, owner string) (int, error) {
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := len(member.deltaKeys)
+	if pruned > 0 {
+		err = putMember(ctx, member)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if member.Merchant == "" {
+		stats, err := getProgramStats(ctx, member.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(stats.deltaKeys) > 0 {
+			pruned += len(stats.deltaKeys)
+			err = putProgramStats(ctx, stats)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return pruned, nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	usage, err := getVelocityUsage(ctx, member.ID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(usage.deltaKeys) > 0 {
+		pruned += len(usage.deltaKeys)
+		err = putVelocityUsage(ctx, usage)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	merchants := make([]string, 0, len(member.MerchantPoints))
+	for merchantID := range member.MerchantPoints {
+		merchants = append(merchants, merchantID)
+	}
+	sort.Strings(merchants)
+
+	for _, merchantID := range merchants {
+		status, err := getTierStatus(ctx, member.ID, merchantID)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(status.deltaKeys) == 0 {
+			continue
+		}
+
+		pruned += len(status.deltaKeys)
+		previous := status.Tier
+		status.refresh(now)
+		status.UpdatedAt = now.Format(time.RFC3339)
+
+		err = putTierStatus(ctx, status, previous)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return pruned, nil
+}
+
+// creditFromMerchant applies a transaction of a merchant to an existing customer as balance
+// deltas, so that neither member is written. Neither member is read with its deltas either,
+// as the range read of the deltas would conflict with other credits. It returns false if the
+// transaction is not such a credit.
+func creditFromMerchant(ctx TransactionContext, transaction *PointsTransaction, value int) (bool, error) {
+	sender, err := getStoredMember(ctx, transaction.Sender)
+	if hasErrorCode(err, ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	receiver, err := getStoredMember(ctx, transaction.Receiver)
+	if hasErrorCode(err, ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if sender.Merchant != "" || receiver.Merchant == "" {
+		return false, nil
+	}
+
+	// Deltas are not covered by the endorsement policy of an account, so such members are written
+	for _, member := range []*Member{sender, receiver} {
+		endorsed, err := hasAccountEndorsement(ctx, member.ID)
+		if err != nil || endorsed {
+			return false, err
+		}
+	}
+
+	err = recordIssuance(ctx, transaction, sender, receiver, value, true)
+	if err != nil {
+		return false, err
+	}
+
+	transaction.setSchema(transactionObjectType)
+
+	receiverDelta := BalanceDelta{
+		Owner:          receiver.ID,
+		Points:         value,
+		MerchantPoints: map[string]int{sender.ID: value},
+		Transaction:    transaction,
+	}
+	if transaction.PointType != "" {
+		receiverDelta.TypedPoints = map[string]map[string]int{sender.ID: {transaction.PointType: value}}
+	}
+
+	senderDelta := BalanceDelta{
+		Owner:          sender.ID,
+		Points:         value,
+		MerchantPoints: map[string]int{},
+	}
+	if sender.ID != receiver.Merchant {
+		senderDelta.MerchantPoints[receiver.Merchant] = value
+	}
+
+	for _, delta := range []*BalanceDelta{&receiverDelta, &senderDelta} {
+		err = putCompositeObject(ctx, balanceDeltaObjectType, []string{delta.Owner, transaction.ID}, delta)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// applyBalanceDeltas adds the balance deltas of a member to its balances
+func applyBalanceDeltas(ctx TransactionContext, member *Member) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(balanceDeltaObjectType, []string{member.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var delta BalanceDelta
+		err = json.Unmarshal(queryResponse.Value, &delta)
+		if err != nil {
+			return newError(ErrInternal, "failed to unmarshal %s. %s", queryResponse.Key, err.Error())
+		}
+
+		if member.MerchantPoints == nil {
+			member.MerchantPoints = map[string]int{}
+		}
+
+		member.Points += delta.Points
+		for merchant, points := range delta.MerchantPoints {
+			member.MerchantPoints[merchant] += points
+		}
+
+		for merchant, typed := range delta.TypedPoints {
+			for pointType, points := range typed {
+				addTypedPoints(member, merchant, pointType, points)
+			}
+		}
+
+		if delta.Transaction != nil && (member.Transaction == nil || delta.Transaction.CreatedAt >= member.Transaction.CreatedAt) {
+			member.Transaction = delta.Transaction
+		}
+
+		member.deltaKeys = append(member.deltaKeys, queryResponse.Key)
+	}
+
+	return nil
+}
+
+// deleteBalanceDeltas deletes the balance deltas a member was read with, before the member is
+// written with them included
+func deleteBalanceDeltas(ctx TransactionContext, member *Member) error {
+	err := deleteDeltas(ctx, member.deltaKeys)
+	if err != nil {
+		return err
+	}
+
+	member.deltaKeys = nil
+	return nil
+}
+
+// putCounterDelta adds value to a counter as a delta of the transaction, without reading the counter
+func putCounterDelta(ctx TransactionContext, objectType string, attributes []string, transactionID string, delta *CounterDelta) error {
+	keys := append([]string{objectType}, attributes...)
+	return putCompositeObject(ctx, counterDeltaObjectType, append(keys, transactionID), delta)
+}
+
+// getCounterDeltas returns the deltas of a counter and their keys
+func getCounterDeltas(ctx TransactionContext, objectType string, attributes []string) ([]*CounterDelta, []string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(counterDeltaObjectType, append([]string{objectType}, attributes...))
+	if err != nil {
+		return nil, nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	deltas, keys := []*CounterDelta{}, []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var delta CounterDelta
+		err = unmarshalState(counterDeltaObjectType, queryResponse.Key, queryResponse.Value, &delta)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		deltas = append(deltas, &delta)
+		keys = append(keys, queryResponse.Key)
+	}
+
+	return deltas, keys, nil
+}
+
+// deleteDeltas deletes the deltas a record was read with, before it is written with them included
+func deleteDeltas(ctx TransactionContext, keys []string) error {
+	for _, key := range keys {
+		err := ctx.GetStub().DelState(key)
+		if err != nil {
+			return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneDeltas(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.reward("m1", "alice", 20)
+	env.reward("m1", "alice", 30)
+
+	pruned, err := env.points.PruneDeltas(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 6, pruned, "the first credit creates the member, the others are balance, tier and velocity deltas")
+	require.Equal(t, 60, env.balance("alice", "m1"))
+
+	pruned, err = env.points.PruneDeltas(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 0, pruned)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+
+	_, err = env.points.PruneDeltas(env.ctx(adminIdentity), "nobody")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestCreditCountersAsDeltas(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "alice", 950)
+
+	tierKey, err := env.stub.CreateCompositeKey(tierObjectType, []string{"m1", "alice"})
+	require.NoError(t, err)
+	stored, err := env.stub.GetState(tierKey)
+	require.NoError(t, err)
+
+	tier, err := env.points.GetTier(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, 1050, tier.Rolling)
+	require.Equal(t, TierSilver, tier.Tier)
+
+	usage, err := env.admin.GetVelocityUsage(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 1050, usage.Earned)
+
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 1050, stats.Issued)
+	require.Equal(t, 1050, stats.Outstanding)
+
+	// The second credit added deltas without writing the counters
+	current, err := env.stub.GetState(tierKey)
+	require.NoError(t, err)
+	require.Equal(t, stored, current)
+
+	_, err = env.points.PruneDeltas(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	var event TierChangedEvent
+	env.requireEvent(tierChangedEvent, &event)
+	require.Equal(t, TierChange{Owner: "alice", Merchant: "m1", From: TierNone, To: TierSilver}, event.TierChange)
+
+	pruned, err := env.points.PruneDeltas(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 2, pruned, "the balance and program statistics deltas of the merchant")
+
+	stats, err = env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 1050, stats.Issued)
+}
+
+func TestVelocityLimitsReadDeltas(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.reward("m1", "alice", 20)
+	require.NoError(t, env.admin.SetVelocityLimits(env.ctx(adminIdentity), "alice", 50, 0))
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 21, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrVelocityLimitExceeded)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 20, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err)
+	require.Equal(t, 50, env.balance("alice", "m1"))
+}
@@ -0,0 +1,72 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// AnchorDocument records the SHA-256 hash, hex encoded, and optionally the location of the
+// off-chain receipt or invoice of an order transaction. A document may only be anchored once.
+func (s *PointsContract) AnchorDocument(ctx TransactionContext, id string, documentHash string, documentURI string) error {
+	documentHash, err := normalizeDocumentHash(documentHash)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := getTransaction(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantMSP(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Source == nil || transaction.Source.Type != TypeOrder {
+		return newError(ErrInvalidArgument, "transaction %s is not an order transaction", id)
+	}
+
+	if transaction.DocumentHash != "" {
+		return newError(ErrAlreadyExists, "a document is already anchored to transaction %s", id).
+			WithDetail("documentHash", transaction.DocumentHash)
+	}
+
+	transaction.DocumentHash = documentHash
+	transaction.DocumentURI = documentURI
+
+	return putTransaction(ctx, transaction)
+}
+
+// VerifyDocument reports whether documentHash is the hash of the document anchored to a transaction,
+// it is false when no document was anchored
+func (s *PointsContract) VerifyDocument(ctx TransactionContext, id string, documentHash string) (bool, error) {
+	documentHash, err := normalizeDocumentHash(documentHash)
+	if err != nil {
+		return false, err
+	}
+
+	transaction, err := getTransaction(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	return transaction.DocumentHash == documentHash, nil
+}
+
+// normalizeDocumentHash checks that a hash is a hex encoded SHA-256 digest and returns it in lower case
+func normalizeDocumentHash(documentHash string) (string, error) {
+	documentHash = strings.ToLower(strings.TrimSpace(documentHash))
+
+	digest, err := hex.DecodeString(documentHash)
+	if err != nil || len(digest) != sha256.Size {
+		return "", newError(ErrInvalidArgument, "document hash must be a hex encoded SHA-256 digest, got %q", documentHash)
+	}
+
+	return documentHash, nil
+}
@@ -0,0 +1,41 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnchorDocument(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 10)
+	hash := strings.Repeat("ab", 32)
+
+	err := env.points.AnchorDocument(env.ctx(otherMSPIdentity), order, hash, "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.AnchorDocument(env.ctx(merchantIdentity), order, "not a hash", "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.AnchorDocument(env.ctx(merchantIdentity), "missing", hash, "")
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.points.AnchorDocument(env.ctx(merchantIdentity), order, strings.ToUpper(hash), "https://example.com/receipt.pdf"))
+
+	err = env.points.AnchorDocument(env.ctx(merchantIdentity), order, hash, "")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	verified, err := env.points.VerifyDocument(env.ctx(adminIdentity), order, hash)
+	require.NoError(t, err)
+	require.True(t, verified)
+
+	verified, err = env.points.VerifyDocument(env.ctx(adminIdentity), order, strings.Repeat("cd", 32))
+	require.NoError(t, err)
+	require.False(t, verified)
+}
@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+
+// SetAccountEndorsement requires peers of all orgs to endorse any change to the balance and
+// account binding of a member, typically the customer's org and the operator org
+func (s *AdminContract) SetAccountEndorsement(ctx TransactionContext, memberID string, orgs []string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(orgs) == 0 {
+		return newError(ErrInvalidArgument, "at least one org must endorse the account")
+	}
+
+	keys, err := accountEndorsementKeys(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return newError(ErrInternal, "failed to create the endorsement policy. %s", err.Error())
+	}
+
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return newError(ErrInvalidArgument, "failed to add orgs to the endorsement policy. %s", err.Error())
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return newError(ErrInternal, "failed to create the endorsement policy. %s", err.Error())
+	}
+
+	for _, key := range keys {
+		err = ctx.GetStub().SetStateValidationParameter(key, policy)
+		if err != nil {
+			return newError(ErrInternal, "failed to set the endorsement policy of %s. %s", memberID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// GetAccountEndorsement returns the orgs which must endorse changes to a member's balance,
+// none if the chaincode endorsement policy applies
+func (s *AdminContract) GetAccountEndorsement(ctx TransactionContext, memberID string) ([]string, error) {
+	keys, err := accountEndorsementKeys(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ctx.GetStub().GetStateValidationParameter(keys[0])
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to get the endorsement policy of %s. %s", memberID, err.Error())
+	}
+
+	if len(policy) == 0 {
+		return []string{}, nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to parse the endorsement policy of %s. %s", memberID, err.Error())
+	}
+
+	return endorsementPolicy.ListOrgs(), nil
+}
+
+// accountEndorsementKeys returns the member key and, if the member is bound to an identity,
+// the account binding key of a member
+func accountEndorsementKeys(ctx TransactionContext, memberID string) ([]string, error) {
+	_, err := getMember(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberKey, err := ctx.GetStub().CreateCompositeKey(memberObjectType, []string{memberID})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	keys := []string{memberKey}
+
+	bound, err := getAccountIdentity(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bound != "" {
+		ownerKey, err := ctx.GetStub().CreateCompositeKey(accountOwnerType, []string{memberID})
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		keys = append(keys, ownerKey)
+	}
+
+	return keys, nil
+}
+
+// hasAccountEndorsement reports whether changes to the balance of a member need the endorsement
+// of the orgs set with SetAccountEndorsement
+func hasAccountEndorsement(ctx TransactionContext, memberID string) (bool, error) {
+	memberKey, err := ctx.GetStub().CreateCompositeKey(memberObjectType, []string{memberID})
+	if err != nil {
+		return false, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	policy, err := ctx.GetStub().GetStateValidationParameter(memberKey)
+	if err != nil {
+		return false, newError(ErrInternal, "failed to get the endorsement policy of %s. %s", memberID, err.Error())
+	}
+
+	return len(policy) > 0, nil
+}
@@ -0,0 +1,36 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAccountEndorsement(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	orgs, err := env.admin.GetAccountEndorsement(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Empty(t, orgs)
+
+	err = env.admin.SetAccountEndorsement(env.ctx(merchantIdentity), "alice", []string{"Org1MSP"})
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.SetAccountEndorsement(env.ctx(adminIdentity), "alice", []string{})
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.SetAccountEndorsement(env.ctx(adminIdentity), "nobody", []string{"Org1MSP"})
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.admin.SetAccountEndorsement(env.ctx(adminIdentity), "alice", []string{"Org1MSP", "Org2MSP"}))
+
+	orgs, err = env.admin.GetAccountEndorsement(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"Org1MSP", "Org2MSP"}, orgs)
+}
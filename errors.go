@@ -0,0 +1,69 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error codes returned to clients
+const (
+	ErrInvalidArgument       = "INVALID_ARGUMENT"
+	ErrNotFound              = "NOT_FOUND"
+	ErrFunctionNotFound      = "FUNCTION_NOT_FOUND"
+	ErrAlreadyExists         = "ALREADY_EXISTS"
+	ErrUnauthorized          = "UNAUTHORIZED"
+	ErrInsufficientPoints    = "INSUFFICIENT_POINTS"
+	ErrAccountFrozen         = "ACCOUNT_FROZEN"
+	ErrAccountClosed         = "ACCOUNT_CLOSED"
+	ErrInvalidState          = "INVALID_STATE"
+	ErrVelocityLimitExceeded = "VELOCITY_LIMIT_EXCEEDED"
+	ErrLifeCardInactive      = "LIFECARD_INACTIVE"
+	ErrPaymentFailed         = "PAYMENT_FAILED"
+	ErrBudgetExceeded        = "BUDGET_EXCEEDED"
+	ErrInternal              = "INTERNAL"
+)
+
+// ContractError is the error returned by every function of the chaincode. It is serialized
+// as JSON in the error message so clients can tell failures apart by code.
+type ContractError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	errorAsBytes, err := json.Marshal(e)
+	if err != nil {
+		return e.Message
+	}
+
+	return string(errorAsBytes)
+}
+
+// WithDetail adds a detail to the error
+func (e *ContractError) WithDetail(key string, value string) *ContractError {
+	if e.Details == nil {
+		e.Details = map[string]string{}
+	}
+
+	e.Details[key] = value
+	return e
+}
+
+// newError returns a ContractError with given code and formatted message
+func newError(code string, format string, args ...interface{}) *ContractError {
+	return &ContractError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// hasErrorCode reports whether err is a ContractError with the given code
+func hasErrorCode(err error, code string) bool {
+	contractErr, ok := err.(*ContractError)
+	return ok && contractErr.Code == code
+}
@@ -0,0 +1,27 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractError(t *testing.T) {
+	err := newError(ErrNotFound, "%s does not exist in world state", "alice").WithDetail("member", "alice")
+	require.Equal(t, `{"code":"NOT_FOUND","message":"alice does not exist in world state","details":{"member":"alice"}}`, err.Error())
+
+	err = newError(ErrInternal, "failed")
+	require.Equal(t, `{"code":"INTERNAL","message":"failed"}`, err.Error())
+}
+
+func TestHasErrorCode(t *testing.T) {
+	require.True(t, hasErrorCode(newError(ErrNotFound, "missing"), ErrNotFound))
+	require.False(t, hasErrorCode(newError(ErrInternal, "failed"), ErrNotFound))
+	require.False(t, hasErrorCode(errors.New("missing"), ErrNotFound))
+	require.False(t, hasErrorCode(nil, ErrNotFound))
+}
@@ -0,0 +1,158 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "encoding/json"
+
+// eventVersion is the version of the event payloads, raised when a payload changes incompatibly
+const eventVersion = 1
+
+// transactionEvent is the name of the Fabric event of a transaction, its EventEnvelope
+// payload lists the events the transaction emitted
+const transactionEvent = "PointsEvents"
+
+const (
+	pointIssuedEvent       = "PointIssued"
+	pointsTransferredEvent = "PointsTransferred"
+	pointsRedeemedEvent    = "PointsRedeemed"
+	giftOfferedEvent       = "GiftOffered"
+	giftAcceptedEvent      = "GiftAccepted"
+	giftRejectedEvent      = "GiftRejected"
+	giftExpiredEvent       = "GiftExpired"
+)
+
+// EventHeader is embedded in the payload of every event
+type EventHeader struct {
+	EventVersion int `json:"eventVersion"`
+}
+
+// setEventVersion stamps the payload with the current event version
+func (h *EventHeader) setEventVersion() {
+	h.EventVersion = eventVersion
+}
+
+type versionedEvent interface {
+	setEventVersion()
+}
+
+// Event is an event emitted by a transaction, Payload is the typed payload of the event Name
+type Event struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventEnvelope is the payload of the Fabric event of a transaction. Fabric delivers a single
+// event per transaction, so it lists every event of the transaction in the order emitted.
+type EventEnvelope struct {
+	EventHeader
+	Transaction string  `json:"transaction"`
+	Events      []Event `json:"events"`
+}
+
+// eventCollector is implemented by the transaction contexts which collect the events emitted
+// during a transaction
+type eventCollector interface {
+	collectEvent(event Event) []Event
+}
+
+// PointIssuedEvent is the payload of the PointIssued event
+type PointIssuedEvent struct {
+	EventHeader
+	Transaction string `json:"transaction"`
+	Merchant    string `json:"merchant"`
+	Owner       string `json:"owner"`
+	PointType   string `json:"pointType"`
+	Value       int    `json:"value"`
+}
+
+// PointsTransferredEvent is the payload of the PointsTransferred event, emitted for the
+// transactions which have no more specific event
+type PointsTransferredEvent struct {
+	EventHeader
+	Transaction string `json:"transaction"`
+	Type        string `json:"type"`
+	Merchant    string `json:"merchant"`
+	Sender      string `json:"sender"`
+	Receiver    string `json:"receiver"`
+	Value       int    `json:"value"`
+}
+
+// PointsRedeemedEvent is the payload of the PointsRedeemed event
+type PointsRedeemedEvent struct {
+	EventHeader
+	Transaction string `json:"transaction"`
+	Merchant    string `json:"merchant"`
+	Owner       string `json:"owner"`
+	PointType   string `json:"pointType"`
+	Value       int    `json:"value"`
+}
+
+// GiftEvent is the payload of the GiftOffered, GiftAccepted, GiftRejected and GiftExpired events
+type GiftEvent struct {
+	EventHeader
+	Gift
+}
+
+// TierChangedEvent is the payload of the TierChanged event
+type TierChangedEvent struct {
+	EventHeader
+	TierChange
+}
+
+// PeriodArchivedEvent is the payload of the PeriodArchived event
+type PeriodArchivedEvent struct {
+	EventHeader
+	ArchiveManifest
+}
+
+// AccountsMergedEvent is the payload of the AccountsMerged event
+type AccountsMergedEvent struct {
+	EventHeader
+	AccountMerge
+}
+
+// VoucherEvent is the payload of the VoucherIssued and VoucherRedeemed events
+type VoucherEvent struct {
+	EventHeader
+	Voucher
+}
+
+// CampaignBudgetExhaustedEvent is the payload of the CampaignBudgetExhausted event
+type CampaignBudgetExhaustedEvent struct {
+	EventHeader
+	CampaignSpend
+}
+
+// emitEvent adds an event to the envelope event of the transaction
+func emitEvent(ctx TransactionContext, name string, payload versionedEvent) error {
+	payload.setEventVersion()
+
+	payloadAsBytes, err := json.Marshal(payload)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s event. %s", name, err.Error())
+	}
+
+	event := Event{Name: name, Payload: payloadAsBytes}
+	events := []Event{event}
+	if collector, ok := ctx.(eventCollector); ok {
+		events = collector.collectEvent(event)
+	}
+
+	envelope := EventEnvelope{Transaction: ctx.GetStub().GetTxID(), Events: events}
+	envelope.setEventVersion()
+
+	envelopeAsBytes, err := json.Marshal(&envelope)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s event. %s", transactionEvent, err.Error())
+	}
+
+	// A later call replaces the envelope with one that also lists the new event
+	err = ctx.GetStub().SetEvent(transactionEvent, envelopeAsBytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to set event. %s", err.Error())
+	}
+
+	return nil
+}
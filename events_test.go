@@ -0,0 +1,38 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmittedEventsAreVersioned(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	var event PointsTransferredEvent
+	env.requireEvent(pointsTransferredEvent, &event)
+	require.Equal(t, eventVersion, event.EventVersion)
+}
+
+func TestTransactionEventListsEveryEvent(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 1000)
+
+	events := env.events()
+	require.Len(t, events, 2)
+	require.Equal(t, pointsTransferredEvent, events[0].Name)
+	require.Equal(t, tierChangedEvent, events[1].Name)
+
+	var envelope EventEnvelope
+	require.NoError(t, json.Unmarshal(env.stub.event.Payload, &envelope))
+	require.Equal(t, eventVersion, envelope.EventVersion)
+	require.Equal(t, env.lastTxID(), envelope.Transaction)
+}
@@ -0,0 +1,446 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// pointExpiryIndex lists the points credited to customers which expire, keyed by merchant,
+	// expiry date, owner and transaction ID, with the credited points as value
+	pointExpiryIndex = "pointExpiry"
+
+	expiryDate = "2006-01-02"
+
+	// maxExpiryWindowDays is the longest window of QueryPointsExpiringWithin, the index is read
+	// a day at a time
+	maxExpiryWindowDays = 366
+)
+
+// ExpiringPoints is a lot of points of a customer which expires. Points is capped at the
+// customer's current balance at the merchant, as the lot may have been spent in part.
+type ExpiringPoints struct {
+	Owner       string `json:"owner"`
+	Points      int    `json:"points"`
+	ExpiresAt   string `json:"expiresAt"`
+	Transaction string `json:"transaction"`
+}
+
+// ExpiringPointsPage is a page of expiring points and the bookmark to fetch the next one
+type ExpiringPointsPage struct {
+	Records []*ExpiringPoints `json:"records"`
+	PageInfo
+}
+
+// QueryPointsExpiringWithin returns a page of the points of a merchant expiring from today
+// until days from today, ordered by expiry date, pass the bookmark of a page to fetch the next one
+func (s *PointsContract) QueryPointsExpiringWithin(ctx TransactionContext, merchant string, days int, pageSize int32, bookmark string) (*ExpiringPointsPage, error) {
+	if days <= 0 || days > maxExpiryWindowDays {
+		return nil, newError(ErrInvalidArgument, "days must be between 1 and %d", maxExpiryWindowDays)
+	}
+
+	if pageSize <= 0 {
+		return nil, newError(ErrInvalidArgument, "page size must be positive")
+	}
+
+	_, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := now.Truncate(24 * time.Hour)
+	last := today.AddDate(0, 0, days)
+
+	day, dayBookmark := today, ""
+	if bookmark != "" {
+		day, dayBookmark, err = parseExpiryBookmark(bookmark)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page := ExpiringPointsPage{Records: []*ExpiringPoints{}}
+	balances := map[string]int{}
+	for ; !day.After(last) && int32(len(page.Records)) < pageSize; day = day.AddDate(0, 0, 1) {
+		attributes := []string{merchant, day.Format(expiryDate)}
+		resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(pointExpiryIndex, attributes, pageSize-int32(len(page.Records)), dayBookmark)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+			}
+
+			record, err := expiringPoints(ctx, merchant, queryResponse.Key, queryResponse.Value, balances)
+			if err != nil {
+				resultsIterator.Close()
+				return nil, err
+			}
+
+			page.Records = append(page.Records, record)
+		}
+		resultsIterator.Close()
+
+		// A full page ends within the day, the next page continues from the bookmark of the day
+		dayBookmark = ""
+		if int32(len(page.Records)) == pageSize {
+			dayBookmark = metadata.Bookmark
+			if dayBookmark == "" {
+				day = day.AddDate(0, 0, 1)
+			}
+			break
+		}
+	}
+
+	page.FetchedRecordsCount = int32(len(page.Records))
+	page.Bookmark, err = nextExpiryBookmark(ctx, merchant, day, last, dayBookmark)
+	if err != nil {
+		return nil, err
+	}
+	page.HasMore = page.Bookmark != ""
+
+	return &page, nil
+}
+
+// expiringPoints returns the expiring points of an entry of the expiry index
+func expiringPoints(ctx TransactionContext, merchant string, key string, value []byte, balances map[string]int) (*ExpiringPoints, error) {
+	_, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+	}
+
+	var points int
+	err = json.Unmarshal(value, &points)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to unmarshal %s. %s", key, err.Error())
+	}
+
+	owner := attributes[2]
+	balance, ok := balances[owner]
+	if !ok {
+		member, err := getMember(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+
+		balance = member.MerchantPoints[merchant]
+		balances[owner] = balance
+	}
+
+	if points > balance {
+		points = balance
+	}
+
+	return &ExpiringPoints{
+		Owner:       owner,
+		Points:      points,
+		ExpiresAt:   attributes[1],
+		Transaction: attributes[3],
+	}, nil
+}
+
+// nextExpiryBookmark returns the bookmark of the first entry of the expiry index from the
+// bookmark of day until last, or "" if there is none
+func nextExpiryBookmark(ctx TransactionContext, merchant string, day time.Time, last time.Time, dayBookmark string) (string, error) {
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		resultsIterator, _, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(pointExpiryIndex, []string{merchant, day.Format(expiryDate)}, 1, dayBookmark)
+		if err != nil {
+			return "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		found := resultsIterator.HasNext()
+		resultsIterator.Close()
+
+		if found {
+			return day.Format(expiryDate) + ":" + dayBookmark, nil
+		}
+
+		dayBookmark = ""
+	}
+
+	return "", nil
+}
+
+// parseExpiryBookmark returns the day and the bookmark within the day of a bookmark of
+// QueryPointsExpiringWithin
+func parseExpiryBookmark(bookmark string) (time.Time, string, error) {
+	parts := strings.SplitN(bookmark, ":", 2)
+	day, err := time.Parse(expiryDate, parts[0])
+	if err != nil || len(parts) != 2 {
+		return time.Time{}, "", newError(ErrInvalidArgument, "invalid bookmark %s", bookmark)
+	}
+
+	return day, parts[1], nil
+}
+
+// recordPointLot adds points a customer earned from a merchant to the expiry index, when the
+// merchant's points expire. A negative value, when a transaction is voided or reversed, removes
+// the lot of the original transaction.
+func recordPointLot(ctx TransactionContext, transaction *PointsTransaction, owner string, merchantID string, value int) error {
+	if value < 0 && transaction.Source != nil && transaction.Source.Type == TypeReversal {
+		original, err := getTransaction(ctx, transaction.Source.ID)
+		if err != nil {
+			return err
+		}
+
+		transaction = original
+	}
+
+	key, err := pointLotKey(ctx, transaction, owner, merchantID)
+	if err != nil || key == "" {
+		return err
+	}
+
+	if value < 0 {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		return nil
+	}
+
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s. %s", key, err.Error())
+	}
+
+	err = ctx.GetStub().PutState(key, valueAsBytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to write to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// reindexPointLot adds the points credited to a customer by a confirmed transaction of a
+// merchant to the expiry index
+func reindexPointLot(ctx TransactionContext, transaction *PointsTransaction) error {
+	if transactionStatus(transaction) != StatusConfirmed || transaction.Sender != transaction.Merchant ||
+		transactionType(transaction) == TypeReversal {
+		return nil
+	}
+
+	receiver, err := getStoredMember(ctx, transaction.Receiver)
+	if err != nil || receiver.Merchant == "" {
+		return nil
+	}
+
+	return recordPointLot(ctx, transaction, transaction.Receiver, transaction.Merchant, transaction.Value)
+}
+
+// pointLotKey returns the key of the points credited by a transaction in the expiry index, or
+// "" if the points do not expire. The expiry of the type of the points overrides the expiry
+// of the merchant's program.
+func pointLotKey(ctx TransactionContext, transaction *PointsTransaction, owner string, merchantID string) (string, error) {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return "", err
+	}
+
+	expiryDays := merchant.Program.ExpiryDays
+	if rule, ok := merchant.PointTypes[transaction.PointType]; ok && rule.ExpiryDays > 0 {
+		expiryDays = rule.ExpiryDays
+	}
+
+	if expiryDays <= 0 {
+		return "", nil
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, transaction.CreatedAt)
+	if err != nil {
+		return "", newError(ErrInternal, "invalid creation date of transaction %s. %s", transaction.ID, err.Error())
+	}
+
+	expiresAt := createdAt.UTC().AddDate(0, 0, expiryDays).Format(expiryDate)
+
+	key, err := ctx.GetStub().CreateCompositeKey(pointExpiryIndex, []string{merchantID, expiresAt, owner, transaction.ID})
+	if err != nil {
+		return "", newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	return key, nil
+}
+
+// ExpirePoints debits up to limit lots of a merchant's points which expired before today,
+// recording an expiry transaction for each. A lot is capped at the owner's balance of its point
+// type at the merchant, as it may have been spent in part, and spent lots are dropped. The
+// expired points are counted in the settlement report and program statistics of the merchant.
+// It returns the number of lots expired or dropped.
+func (s *AdminContract) ExpirePoints(ctx TransactionContext, merchantID string, limit int) (int, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= 0 {
+		return 0, newError(ErrInvalidArgument, "limit must be positive")
+	}
+
+	merchant, err := getMember(ctx, merchantID)
+	if err != nil {
+		return 0, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	today := now.UTC().Format(expiryDate)
+
+	// The lots are ordered by expiry date, those expiring today or later are left
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(pointExpiryIndex, []string{merchantID})
+	if err != nil {
+		return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	// Each owner is debited once per call, as writes are not visible to later reads
+	owners := map[string]bool{}
+	expired := 0
+	total := 0
+	for expired < limit && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		if attributes[1] >= today {
+			break
+		}
+
+		owner := attributes[2]
+		if owners[owner] {
+			continue
+		}
+
+		owners[owner] = true
+
+		var points int
+		err = json.Unmarshal(queryResponse.Value, &points)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to unmarshal %s. %s", queryResponse.Key, err.Error())
+		}
+
+		value, err := expirePointLot(ctx, merchantID, owner, attributes[3], points)
+		if err != nil {
+			return 0, err
+		}
+
+		err = ctx.GetStub().DelState(queryResponse.Key)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		total += value
+		expired++
+	}
+
+	if total == 0 {
+		return expired, nil
+	}
+
+	err = updateProgramStats(ctx, merchantID, programExpired, total)
+	if err != nil {
+		return 0, err
+	}
+
+	merchant.Points -= total
+
+	err = putMember(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// expirePointLot debits the points left of a lot credited to owner by a transaction of a
+// merchant and records the expiry transaction. It returns the points debited.
+func expirePointLot(ctx TransactionContext, merchantID string, owner string, transactionID string, points int) (int, error) {
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	// The points of archived transactions are untyped
+	var credited PointsTransaction
+	_, err = getObject(ctx, transactionObjectType, transactionID, &credited)
+	if err != nil {
+		return 0, err
+	}
+
+	available := member.MerchantPoints[merchantID] - typedPointsOf(member, merchantID)
+	if credited.PointType != "" {
+		available = member.TypedPoints[merchantID][credited.PointType]
+	}
+
+	if points > available {
+		points = available
+	}
+
+	if points <= 0 {
+		return 0, nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID() + "-" + owner,
+		Value:     points,
+		Merchant:  merchantID,
+		PointType: credited.PointType,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    owner,
+		Receiver:  merchantID,
+		Source:    &Source{Type: TypeExpiry, ID: transactionID},
+		Status:    StatusConfirmed,
+	}
+
+	member.Points -= points
+	member.MerchantPoints[merchantID] -= points
+	if credited.PointType != "" {
+		addTypedPoints(member, merchantID, credited.PointType, -points)
+	}
+	member.Transaction = &transaction
+
+	err = putMember(ctx, member)
+	if err != nil {
+		return 0, err
+	}
+
+	err = putTransaction(ctx, &transaction)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordSettlement(ctx, merchantID, settlementExpired, transaction.ID, points)
+	if err != nil {
+		return 0, err
+	}
+
+	return points, nil
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPointsExpiringWithin(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "m1", "Merchant m1", "en", 1, 30, 0))
+	first := env.reward("m1", "alice", 10)
+	env.advance(24 * time.Hour)
+	env.reward("m1", "bob", 20)
+	env.reward("m1", "carol", 30)
+
+	page, err := env.points.QueryPointsExpiringWithin(env.ctx(merchantIdentity), "m1", 31, 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.Equal(t, "alice", page.Records[0].Owner)
+	require.Equal(t, first, page.Records[0].Transaction)
+	require.Equal(t, "2024-04-14", page.Records[0].ExpiresAt)
+	require.Equal(t, "bob", page.Records[1].Owner)
+	require.True(t, page.HasMore)
+
+	page, err = env.points.QueryPointsExpiringWithin(env.ctx(merchantIdentity), "m1", 31, 2, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "carol", page.Records[0].Owner)
+	require.False(t, page.HasMore)
+
+	page, err = env.points.QueryPointsExpiringWithin(env.ctx(merchantIdentity), "m1", 7, 10, "")
+	require.NoError(t, err)
+	require.Empty(t, page.Records)
+
+	_, err = env.points.QueryPointsExpiringWithin(env.ctx(merchantIdentity), "m1", 0, 10, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.QueryPointsExpiringWithin(env.ctx(merchantIdentity), "unknown", 30, 10, "")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestExpirePoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "m1", "Merchant m1", "en", 1, 30, 0))
+	first := env.reward("m1", "alice", 100)
+	env.reward("m1", "alice", 50)
+	env.reward("m1", "bob", 20)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 40, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	_, err = env.admin.ExpirePoints(env.ctx(merchantIdentity), "m1", 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	expired, err := env.admin.ExpirePoints(env.ctx(adminIdentity), "m1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, expired, "no points expired yet")
+
+	env.advance(31 * 24 * time.Hour)
+
+	// Each owner is debited once per call
+	expired, err = env.admin.ExpirePoints(env.ctx(adminIdentity), "m1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, expired)
+	id := env.lastTxID() + "-alice"
+	require.Equal(t, 10, env.balance("alice", "m1"))
+	require.Equal(t, 0, env.balance("bob", "m1"))
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), id)
+	require.NoError(t, err)
+	require.Equal(t, TypeExpiry, transaction.Source.Type)
+	require.Equal(t, first, transaction.Source.ID)
+	require.Equal(t, 100, transaction.Value)
+
+	expired, err = env.admin.ExpirePoints(env.ctx(adminIdentity), "m1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+	require.Equal(t, 0, env.balance("alice", "m1"), "the second lot is capped at the balance left")
+
+	expired, err = env.admin.ExpirePoints(env.ctx(adminIdentity), "m1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, expired)
+
+	report, err := env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", "2024-04", "2024-04")
+	require.NoError(t, err)
+	require.Equal(t, 130, report.Total.Expired)
+
+	verification, err := env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.True(t, verification.Consistent)
+}
@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const freezeObjectType = "freeze"
+
+// Freeze records why and by whom an account was blocked
+type Freeze struct {
+	Schema
+	Member   string `json:"member"`
+	Reason   string `json:"reason"`
+	FrozenBy string `json:"frozenBy"`
+	FrozenAt string `json:"frozenAt"`
+}
+
+// FreezeAccount blocks a member from issuing, receiving, transferring or redeeming points
+func (s *AdminContract) FreezeAccount(ctx TransactionContext, owner string, reason string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	freeze := Freeze{
+		Member:   owner,
+		Reason:   reason,
+		FrozenBy: clientID,
+		FrozenAt: now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, freezeObjectType, owner, &freeze)
+}
+
+// UnfreezeAccount lifts the block on a member
+func (s *AdminContract) UnfreezeAccount(ctx TransactionContext, owner string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(freezeObjectType, []string{owner})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetFreeze returns the freeze record of a member, or nil if the account is not frozen
+func (s *AdminContract) GetFreeze(ctx TransactionContext, owner string) (*Freeze, error) {
+	return getFreeze(ctx, owner)
+}
+
+func getFreeze(ctx TransactionContext, owner string) (*Freeze, error) {
+	var freeze Freeze
+	found, err := getObject(ctx, freezeObjectType, owner, &freeze)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &freeze, nil
+}
+
+// assertNotFrozen returns an ErrAccountFrozen error if any of the members is frozen, or an
+// ErrAccountClosed error if it was closed by a merge
+func assertNotFrozen(ctx TransactionContext, members ...string) error {
+	for _, member := range members {
+		freeze, err := getFreeze(ctx, member)
+		if err != nil {
+			return err
+		}
+
+		if freeze != nil {
+			return newError(ErrAccountFrozen, "account %s is frozen: %s", member, freeze.Reason).
+				WithDetail("member", member).
+				WithDetail("reason", freeze.Reason)
+		}
+
+		merge, err := getAccountMerge(ctx, member)
+		if err != nil {
+			return err
+		}
+
+		if merge != nil {
+			return newError(ErrAccountClosed, "account %s was merged into %s", member, merge.Target).
+				WithDetail("member", member).
+				WithDetail("mergedInto", merge.Target)
+		}
+	}
+
+	return nil
+}
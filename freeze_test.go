@@ -0,0 +1,49 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeAccount(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	err := env.admin.FreezeAccount(env.ctx(merchantIdentity), "alice", "fraud")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.FreezeAccount(env.ctx(adminIdentity), "nobody", "fraud")
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.admin.FreezeAccount(env.ctx(adminIdentity), "alice", "fraud"))
+
+	freeze, err := env.admin.GetFreeze(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, "fraud", freeze.Reason)
+
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 10, "m1", "", "Redemption", "")
+	requireErrorCode(t, err, ErrAccountFrozen)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "o2", "m1", "alice", 10, "m1", "", TypeOrder, "o2")
+	requireErrorCode(t, err, ErrAccountFrozen)
+
+	err = env.admin.UnfreezeAccount(env.ctx(merchantIdentity), "alice")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.UnfreezeAccount(env.ctx(adminIdentity), "alice"))
+
+	freeze, err = env.admin.GetFreeze(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Nil(t, freeze)
+
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r2", "alice", "m1", 10, "m1", "", "Redemption", "")
+	require.NoError(t, err)
+	require.Equal(t, 90, env.balance("alice", "m1"))
+}
@@ -0,0 +1,327 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const giftObjectType = "gift"
+
+// Gift statuses
+const (
+	GiftOffered  = "offered"
+	GiftAccepted = "accepted"
+	GiftRejected = "rejected"
+	GiftExpired  = "expired"
+)
+
+// Gift describes points offered by one customer to another, held until the giftee answers
+type Gift struct {
+	Schema
+	ID        string `json:"ID"`
+	Gifter    string `json:"gifter"`
+	Giftee    string `json:"giftee"`
+	Merchant  string `json:"merchant"`
+	Value     int    `json:"value"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// OfferGift holds value points from the gifter until the giftee accepts or rejects the gift
+func (s *PointsContract) OfferGift(ctx TransactionContext, id string, gifterKey string, gifteeKey string, value int, expiresAt string) error {
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return newError(ErrInvalidArgument, "invalid expiry date %s. %s", expiresAt, err.Error())
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !expiry.After(now) {
+		return newError(ErrInvalidArgument, "gift expiry must be in the future")
+	}
+
+	existing, err := getGift(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return newError(ErrAlreadyExists, "gift %s already exists", id)
+	}
+
+	gifter, err := getMember(ctx, gifterKey)
+	if err != nil {
+		return err
+	}
+
+	if gifter.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot offer gifts", gifter.ID)
+	}
+
+	err = assertMerchantActive(ctx, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if gifterKey == gifteeKey {
+		return newError(ErrInvalidArgument, "%s cannot offer a gift to itself", gifter.ID)
+	}
+
+	err = assertAccountOwner(ctx, gifter.ID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(ctx, gifter.ID, gifteeKey)
+	if err != nil {
+		return err
+	}
+
+	err = recordVelocity(ctx, gifter.ID, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	// The gift is held from the points of the gifter's merchant, so points of other merchants
+	// and typed points cannot back it
+	if spendablePoints(gifter) < value {
+		return newError(ErrInsufficientPoints, "%s does not have enough points", gifter.ID)
+	}
+
+	err = assertLifeCardEligible(ctx, gifter)
+	if err != nil {
+		return err
+	}
+
+	gifter.Points -= value
+	gifter.MerchantPoints[gifter.Merchant] -= value
+
+	err = putMember(ctx, gifter)
+	if err != nil {
+		return err
+	}
+
+	gift := Gift{
+		ID:        id,
+		Gifter:    gifter.ID,
+		Giftee:    gifteeKey,
+		Merchant:  gifter.Merchant,
+		Value:     value,
+		Status:    GiftOffered,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: expiry.UTC().Format(time.RFC3339),
+	}
+
+	err = putGift(ctx, &gift)
+	if err != nil {
+		return err
+	}
+
+	err = putObjectOwnerIndex(ctx, giftObjectType, gift.ID, gift.Gifter, gift.Giftee)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, giftOfferedEvent, &GiftEvent{Gift: gift})
+}
+
+// AcceptGift credits the held points to the giftee
+func (s *PointsContract) AcceptGift(ctx TransactionContext, id string) error {
+	gift, err := s.GetGift(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = assertAccountOwner(ctx, gift.Giftee)
+	if err != nil {
+		return err
+	}
+
+	err = assertGiftOpen(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(ctx, gift.Gifter, gift.Giftee)
+	if err != nil {
+		return err
+	}
+
+	giftee, err := createMember(ctx, gift.Giftee, gift.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if giftee.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot accept gifts", giftee.ID)
+	}
+
+	giftee.Points += gift.Value
+	giftee.MerchantPoints[gift.Merchant] += gift.Value
+	giftee.Transaction = &PointsTransaction{
+		ID:        gift.ID,
+		Value:     gift.Value,
+		CreatedAt: gift.CreatedAt,
+		Sender:    gift.Gifter,
+		Receiver:  gift.Giftee,
+		Source:    &Source{Type: TypeGift, ID: gift.ID},
+		Status:    StatusConfirmed,
+	}
+
+	err = putMember(ctx, giftee)
+	if err != nil {
+		return err
+	}
+
+	err = recordSettlement(ctx, gift.Merchant, settlementTransferred, gift.ID, gift.Value)
+	if err != nil {
+		return err
+	}
+
+	gift.Status = GiftAccepted
+	err = putGift(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, giftAcceptedEvent, &GiftEvent{Gift: *gift})
+}
+
+// RejectGift returns the held points to the gifter
+func (s *PointsContract) RejectGift(ctx TransactionContext, id string) error {
+	gift, err := s.GetGift(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = assertAccountOwner(ctx, gift.Giftee)
+	if err != nil {
+		return err
+	}
+
+	err = assertGiftOpen(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	return returnGift(ctx, gift, GiftRejected)
+}
+
+// ExpireGift returns the held points of a lapsed offer to the gifter, anyone may call it
+func (s *PointsContract) ExpireGift(ctx TransactionContext, id string) error {
+	gift, err := s.GetGift(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if gift.Status != GiftOffered {
+		return newError(ErrInvalidState, "gift %s is already %s", gift.ID, gift.Status)
+	}
+
+	expired, err := giftExpired(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	if !expired {
+		return newError(ErrInvalidState, "gift %s has not expired yet", gift.ID)
+	}
+
+	return returnGift(ctx, gift, GiftExpired)
+}
+
+// GetGift returns the gift stored in the world state with given id
+func (s *PointsContract) GetGift(ctx TransactionContext, id string) (*Gift, error) {
+	gift, err := getGift(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if gift == nil {
+		return nil, newError(ErrNotFound, "gift %s does not exist in world state", id)
+	}
+
+	return gift, nil
+}
+
+// getGift returns the gift with given id, or nil if it does not exist
+func getGift(ctx TransactionContext, id string) (*Gift, error) {
+	var gift Gift
+	found, err := getObject(ctx, giftObjectType, id, &gift)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &gift, nil
+}
+
+func putGift(ctx TransactionContext, gift *Gift) error {
+	return putObject(ctx, giftObjectType, gift.ID, gift)
+}
+
+// returnGift credits the held points back to the gifter, closes the gift with status, rejected
+// or expired, and emits the event of the status
+func returnGift(ctx TransactionContext, gift *Gift, status string) error {
+	gifter, err := getMember(ctx, gift.Gifter)
+	if err != nil {
+		return err
+	}
+
+	gifter.Points += gift.Value
+	gifter.MerchantPoints[gift.Merchant] += gift.Value
+
+	err = putMember(ctx, gifter)
+	if err != nil {
+		return err
+	}
+
+	gift.Status = status
+	err = putGift(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	name := giftRejectedEvent
+	if status == GiftExpired {
+		name = giftExpiredEvent
+	}
+
+	return emitEvent(ctx, name, &GiftEvent{Gift: *gift})
+}
+
+// assertGiftOpen checks that the gift is still waiting for an answer
+func assertGiftOpen(ctx TransactionContext, gift *Gift) error {
+	if gift.Status != GiftOffered {
+		return newError(ErrInvalidState, "gift %s is already %s", gift.ID, gift.Status)
+	}
+
+	expired, err := giftExpired(ctx, gift)
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		return newError(ErrInvalidState, "gift %s has expired", gift.ID)
+	}
+
+	return nil
+}
+
+func giftExpired(ctx TransactionContext, gift *Gift) (bool, error) {
+	expiry, err := time.Parse(time.RFC3339, gift.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return !now.Before(expiry), nil
+}
@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfferGift(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	require.NoError(t, env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 30, "2024-03-22T00:00:00Z"))
+	env.requireEvent(giftOfferedEvent, nil)
+	require.Equal(t, 70, env.balance("alice", "m1"), "the gift is held from the gifter")
+
+	gift, err := env.points.GetGift(env.ctx(alice), "g1")
+	require.NoError(t, err)
+	require.Equal(t, GiftOffered, gift.Status)
+
+	err = env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 30, "2024-03-22T00:00:00Z")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	err = env.points.OfferGift(env.ctx(alice), "g2", "alice", "bob", 71, "2024-03-22T00:00:00Z")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	// Points of another merchant do not back a gift of the gifter's merchant
+	env.registerMerchant("m2")
+	env.reward("m2", "alice", 50)
+	err = env.points.OfferGift(env.ctx(alice), "g2", "alice", "bob", 71, "2024-03-22T00:00:00Z")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+	require.Equal(t, 70, env.balance("alice", "m1"))
+
+	err = env.points.OfferGift(env.ctx(alice), "g3", "alice", "bob", 10, "2024-03-01T00:00:00Z")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.OfferGift(env.ctx(customerIdentity("bob")), "g4", "alice", "bob", 10, "2024-03-22T00:00:00Z")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.GetGift(env.ctx(alice), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestAcceptGift(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	bob := env.registerAccount("bob")
+	require.NoError(t, env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 30, "2024-03-22T00:00:00Z"))
+
+	err := env.points.AcceptGift(env.ctx(alice), "g1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.points.AcceptGift(env.ctx(bob), "g1"))
+	var event GiftEvent
+	env.requireEvent(giftAcceptedEvent, &event)
+	require.Equal(t, GiftAccepted, event.Status)
+	require.Equal(t, 30, env.balance("bob", "m1"))
+	require.Equal(t, 70, env.balance("alice", "m1"))
+
+	err = env.points.AcceptGift(env.ctx(bob), "g1")
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestRejectGift(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	bob := env.registerAccount("bob")
+	require.NoError(t, env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 30, "2024-03-22T00:00:00Z"))
+
+	err := env.points.RejectGift(env.ctx(alice), "g1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.points.RejectGift(env.ctx(bob), "g1"))
+	env.requireEvent(giftRejectedEvent, nil)
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	gift, err := env.points.GetGift(env.ctx(alice), "g1")
+	require.NoError(t, err)
+	require.Equal(t, GiftRejected, gift.Status)
+}
+
+func TestExpireGift(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	bob := env.registerAccount("bob")
+	require.NoError(t, env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 30, "2024-03-22T00:00:00Z"))
+
+	err := env.points.ExpireGift(env.ctx(merchantIdentity), "g1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	env.advance(8 * 24 * time.Hour)
+
+	err = env.points.AcceptGift(env.ctx(bob), "g1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	require.NoError(t, env.points.ExpireGift(env.ctx(merchantIdentity), "g1"))
+	env.requireEvent(giftExpiredEvent, nil)
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	err = env.points.ExpireGift(env.ctx(merchantIdentity), "g1")
+	requireErrorCode(t, err, ErrInvalidState)
+}
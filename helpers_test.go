@@ -0,0 +1,181 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testChannel = "points"
+
+// testIdentity is the client identity of the transactions of a test
+type testIdentity struct {
+	id         string
+	mspID      string
+	attributes map[string]string
+}
+
+func (i *testIdentity) GetID() (string, error) {
+	return i.id, nil
+}
+
+func (i *testIdentity) GetMSPID() (string, error) {
+	return i.mspID, nil
+}
+
+func (i *testIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := i.attributes[name]
+	return value, found, nil
+}
+
+func (i *testIdentity) AssertAttributeValue(name string, value string) error {
+	if i.attributes[name] != value {
+		return fmt.Errorf("attribute %s of %s is not %s", name, i.id, value)
+	}
+
+	return nil
+}
+
+func (i *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, nil
+}
+
+var (
+	adminIdentity    = &testIdentity{id: "admin", mspID: "Org1MSP", attributes: map[string]string{adminAttribute: adminRole}}
+	merchantIdentity = &testIdentity{id: "clerk", mspID: "Org1MSP"}
+	otherMSPIdentity = &testIdentity{id: "intruder", mspID: "Org2MSP"}
+	otherAdmin       = &testIdentity{id: "admin2", mspID: "Org2MSP", attributes: map[string]string{adminAttribute: adminRole}}
+	supportIdentity  = &testIdentity{id: "support", mspID: "Org1MSP", attributes: map[string]string{adminAttribute: supportRole}}
+	bridgeIdentity   = &testIdentity{id: "bridge", mspID: "Org1MSP", attributes: map[string]string{adminAttribute: bridgeRole}}
+)
+
+// customerIdentity returns the identity of a customer enrolled with Org1MSP
+func customerIdentity(id string) *testIdentity {
+	return &testIdentity{id: "customer-" + id, mspID: "Org1MSP"}
+}
+
+// testEnv holds the world state of a test and the contracts run against it
+type testEnv struct {
+	t         *testing.T
+	stub      *mockStub
+	points    *PointsContract
+	merchants *MerchantContract
+	admin     *AdminContract
+	txCount   int
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	return &testEnv{
+		t:         t,
+		stub:      newMockStub(testChannel, time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)),
+		points:    new(PointsContract),
+		merchants: new(MerchantContract),
+		admin:     new(AdminContract),
+	}
+}
+
+// ctx starts a new transaction submitted by the identity
+func (e *testEnv) ctx(identity *testIdentity) *mockContext {
+	e.txCount++
+	e.stub.startTransaction(fmt.Sprintf("tx%d", e.txCount))
+	return &mockContext{stub: e.stub, identity: identity}
+}
+
+// advance moves the time of the following transactions forward
+func (e *testEnv) advance(d time.Duration) {
+	e.stub.now = e.stub.now.Add(d)
+}
+
+// lastTxID returns the ID of the last transaction started
+func (e *testEnv) lastTxID() string {
+	return fmt.Sprintf("tx%d", e.txCount)
+}
+
+// registerMerchant registers a merchant of Org1MSP, which it makes the operator if none is set
+func (e *testEnv) registerMerchant(id string) {
+	e.t.Helper()
+	operator, err := e.admin.GetOperatorMSP(e.ctx(adminIdentity))
+	require.NoError(e.t, err)
+	if operator == "" {
+		require.NoError(e.t, e.admin.SetOperatorMSP(e.ctx(adminIdentity), "Org1MSP"))
+	}
+	require.NoError(e.t, e.merchants.RegisterMerchant(e.ctx(adminIdentity), id, "Merchant "+id, "Org1MSP", "en"))
+}
+
+// reward credits a customer with points of a merchant for an order
+func (e *testEnv) reward(merchant string, owner string, value int) string {
+	e.t.Helper()
+	e.txCount++
+	id := fmt.Sprintf("order%d", e.txCount)
+	_, err := e.points.CreateTransaction(e.ctx(merchantIdentity), id, merchant, owner, value, merchant, "", TypeOrder, id)
+	require.NoError(e.t, err)
+	return id
+}
+
+// registerAccount binds a customer identity to its member account with the approval of an admin
+func (e *testEnv) registerAccount(owner string) *testIdentity {
+	e.t.Helper()
+	identity := customerIdentity(owner)
+	require.NoError(e.t, e.points.RegisterAccount(e.ctx(identity), owner))
+	require.NoError(e.t, e.merchants.ApproveAccount(e.ctx(adminIdentity), owner, identity.id))
+	return identity
+}
+
+// member returns a member with its balance deltas applied
+func (e *testEnv) member(id string) *Member {
+	e.t.Helper()
+	member, err := e.points.GetMember(e.ctx(adminIdentity), id)
+	require.NoError(e.t, err)
+	return member
+}
+
+// balance returns the points a customer holds of a merchant
+func (e *testEnv) balance(owner string, merchant string) int {
+	e.t.Helper()
+	return e.member(owner).MerchantPoints[merchant]
+}
+
+// requireErrorCode checks that err is a ContractError with the code
+func requireErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	require.Error(t, err)
+	require.Truef(t, hasErrorCode(err, code), "expected a %s error, got %v", code, err)
+}
+
+// requireEvent checks that the last transaction emitted an event and decodes the payload of
+// its last one
+func (e *testEnv) requireEvent(name string, payload interface{}) {
+	e.t.Helper()
+	events := e.events()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Name != name {
+			continue
+		}
+
+		if payload != nil {
+			require.NoError(e.t, json.Unmarshal(events[i].Payload, payload))
+		}
+		return
+	}
+
+	require.Failf(e.t, "missing event", "%s was not emitted", name)
+}
+
+// events returns the events listed in the envelope event of the last transaction
+func (e *testEnv) events() []Event {
+	e.t.Helper()
+	require.NotNil(e.t, e.stub.event, "no event was set")
+	require.Equal(e.t, transactionEvent, e.stub.event.EventName)
+
+	var envelope EventEnvelope
+	require.NoError(e.t, json.Unmarshal(e.stub.event.Payload, &envelope))
+	return envelope.Events
+}
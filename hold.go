@@ -0,0 +1,305 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const holdObjectType = "hold"
+
+// Hold statuses
+const (
+	HoldActive   = "held"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+)
+
+// Hold reserves points of a customer for a checkout until they are captured by the merchant or released
+type Hold struct {
+	Schema
+	ID        string `json:"ID"`
+	Owner     string `json:"owner"`
+	Merchant  string `json:"merchant"`
+	Value     int    `json:"value"`
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// HoldPoints reserves value points of the owner for ttl seconds, referencing the checkout.
+// Held points are taken off the balance so that no other transaction can spend them.
+// It returns the ID of the hold.
+func (s *PointsContract) HoldPoints(ctx TransactionContext, owner string, value int, reference string, ttl int) (string, error) {
+	if ttl <= 0 {
+		return "", newError(ErrInvalidArgument, "ttl must be positive")
+	}
+
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	if member.Merchant == "" {
+		return "", newError(ErrInvalidArgument, "%s is a merchant and cannot hold points", owner)
+	}
+
+	err = assertMerchantActive(ctx, member.Merchant)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertAccountOwner(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertNotFrozen(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	if spendablePoints(member) < value {
+		return "", newError(ErrInsufficientPoints, "%s does not have enough points", owner)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	member.Points -= value
+	member.MerchantPoints[member.Merchant] -= value
+
+	err = putMember(ctx, member)
+	if err != nil {
+		return "", err
+	}
+
+	hold := Hold{
+		ID:        ctx.GetStub().GetTxID(),
+		Owner:     owner,
+		Merchant:  member.Merchant,
+		Value:     value,
+		Reference: reference,
+		Status:    HoldActive,
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: now.Add(time.Duration(ttl) * time.Second).Format(time.RFC3339),
+	}
+
+	err = putObject(ctx, holdObjectType, hold.ID, &hold)
+	if err != nil {
+		return "", err
+	}
+
+	err = putObjectOwnerIndex(ctx, holdObjectType, hold.ID, hold.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	return hold.ID, nil
+}
+
+// CapturePoints redeems the held points at the merchant once the payment settled
+func (s *PointsContract) CapturePoints(ctx TransactionContext, holdID string) error {
+	hold, err := getActiveHold(ctx, holdID)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantMSP(ctx, hold.Merchant)
+	if err != nil {
+		return err
+	}
+
+	// An account frozen after the hold was placed cannot redeem it, the hold can still be released
+	err = assertNotFrozen(ctx, hold.Owner)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if holdExpired(hold, now) {
+		return newError(ErrInvalidState, "hold %s has expired", holdID)
+	}
+
+	merchant, err := getMember(ctx, hold.Merchant)
+	if err != nil {
+		return err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     hold.Value,
+		Merchant:  hold.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    hold.Owner,
+		Receiver:  hold.Merchant,
+		Source:    &Source{Type: TypeRedemption, ID: hold.Reference},
+		Status:    StatusConfirmed,
+	}
+
+	merchant.Points -= hold.Value
+
+	err = putMember(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	err = recordSettlement(ctx, hold.Merchant, settlementRedeemed, transaction.ID, hold.Value)
+	if err != nil {
+		return err
+	}
+
+	err = updateProgramStats(ctx, hold.Merchant, programRedeemed, hold.Value)
+	if err != nil {
+		return err
+	}
+
+	err = settlePayment(ctx, &transaction, hold.Reference)
+	if err != nil {
+		return err
+	}
+
+	err = putTransaction(ctx, &transaction)
+	if err != nil {
+		return err
+	}
+
+	hold.Status = HoldCaptured
+	return putObject(ctx, holdObjectType, hold.ID, hold)
+}
+
+// ReleaseHold returns the held points to the owner, the owner or the merchant may release a hold
+func (s *PointsContract) ReleaseHold(ctx TransactionContext, holdID string) error {
+	hold, err := getActiveHold(ctx, holdID)
+	if err != nil {
+		return err
+	}
+
+	if assertAccountOwner(ctx, hold.Owner) != nil {
+		err = assertMerchantMSP(ctx, hold.Merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	return releaseHold(ctx, hold)
+}
+
+// ReleaseExpiredHolds returns the points of up to limit expired holds to their owners.
+// It returns the number of released holds.
+func (s *AdminContract) ReleaseExpiredHolds(ctx TransactionContext, limit int) (int, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= 0 {
+		return 0, newError(ErrInvalidArgument, "limit must be positive")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(holdObjectType, []string{})
+	if err != nil {
+		return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	// Each owner is released once per call, as writes are not visible to later reads
+	owners := map[string]bool{}
+	released := 0
+	for released < limit && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var hold Hold
+		err = unmarshalState(holdObjectType, queryResponse.Key, queryResponse.Value, &hold)
+		if err != nil {
+			return 0, err
+		}
+
+		if hold.Status != HoldActive || !holdExpired(&hold, now) || owners[hold.Owner] {
+			continue
+		}
+
+		err = releaseHold(ctx, &hold)
+		if err != nil {
+			return 0, err
+		}
+
+		owners[hold.Owner] = true
+		released++
+	}
+
+	return released, nil
+}
+
+// GetHold returns the hold stored in the world state with given id
+func (s *PointsContract) GetHold(ctx TransactionContext, id string) (*Hold, error) {
+	var hold Hold
+	found, err := getObject(ctx, holdObjectType, id, &hold)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "hold %s does not exist in world state", id)
+	}
+
+	return &hold, nil
+}
+
+// getActiveHold returns a hold which was neither captured nor released
+func getActiveHold(ctx TransactionContext, id string) (*Hold, error) {
+	var hold Hold
+	found, err := getObject(ctx, holdObjectType, id, &hold)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "hold %s does not exist in world state", id)
+	}
+
+	if hold.Status != HoldActive {
+		return nil, newError(ErrInvalidState, "hold %s is already %s", id, hold.Status)
+	}
+
+	return &hold, nil
+}
+
+// releaseHold credits the held points back to the owner and closes the hold
+func releaseHold(ctx TransactionContext, hold *Hold) error {
+	owner, err := getMember(ctx, hold.Owner)
+	if err != nil {
+		return err
+	}
+
+	owner.Points += hold.Value
+	owner.MerchantPoints[hold.Merchant] += hold.Value
+
+	err = putMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	hold.Status = HoldReleased
+	return putObject(ctx, holdObjectType, hold.ID, hold)
+}
+
+func holdExpired(hold *Hold, now time.Time) bool {
+	expiry, err := time.Parse(time.RFC3339, hold.ExpiresAt)
+	return err != nil || !now.Before(expiry)
+}
@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	id, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 600)
+	require.NoError(t, err)
+	require.Equal(t, 60, env.balance("alice", "m1"), "held points are taken off the balance")
+
+	hold, err := env.points.GetHold(env.ctx(alice), id)
+	require.NoError(t, err)
+	require.Equal(t, HoldActive, hold.Status)
+	require.Equal(t, "2024-03-15T10:10:00Z", hold.ExpiresAt)
+
+	_, err = env.points.HoldPoints(env.ctx(alice), "alice", 61, "checkout2", 600)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.HoldPoints(env.ctx(alice), "alice", 10, "checkout2", 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.HoldPoints(env.ctx(customerIdentity("bob")), "alice", 10, "checkout2", 600)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.GetHold(env.ctx(alice), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestHoldPointsChecksMerchantBalance(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	env.reward("m1", "alice", 30)
+	env.reward("m2", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	// The points of m2 cannot be held at m1
+	_, err := env.points.HoldPoints(env.ctx(alice), "alice", 31, "checkout1", 600)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.HoldPoints(env.ctx(alice), "alice", 30, "checkout1", 600)
+	require.NoError(t, err)
+	require.Equal(t, 0, env.balance("alice", "m1"))
+	require.Equal(t, 100, env.balance("alice", "m2"))
+}
+
+func TestCapturePoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	id, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 600)
+	require.NoError(t, err)
+
+	err = env.points.CapturePoints(env.ctx(otherMSPIdentity), id)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.FreezeAccount(env.ctx(adminIdentity), "alice", "fraud"))
+	err = env.points.CapturePoints(env.ctx(merchantIdentity), id)
+	requireErrorCode(t, err, ErrAccountFrozen)
+	require.NoError(t, env.admin.UnfreezeAccount(env.ctx(adminIdentity), "alice"))
+
+	require.NoError(t, env.points.CapturePoints(env.ctx(merchantIdentity), id))
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 60, env.member("m1").Points)
+
+	err = env.points.CapturePoints(env.ctx(merchantIdentity), id)
+	requireErrorCode(t, err, ErrInvalidState)
+
+	id, err = env.points.HoldPoints(env.ctx(alice), "alice", 10, "checkout2", 60)
+	require.NoError(t, err)
+	env.advance(time.Hour)
+
+	err = env.points.CapturePoints(env.ctx(merchantIdentity), id)
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestReleaseHold(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	id, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 600)
+	require.NoError(t, err)
+
+	err = env.points.ReleaseHold(env.ctx(otherMSPIdentity), id)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.points.ReleaseHold(env.ctx(alice), id))
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	err = env.points.ReleaseHold(env.ctx(alice), id)
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	_, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 60)
+	require.NoError(t, err)
+	_, err = env.points.HoldPoints(env.ctx(alice), "alice", 10, "checkout2", 7200)
+	require.NoError(t, err)
+	env.advance(time.Hour)
+
+	_, err = env.admin.ReleaseExpiredHolds(env.ctx(merchantIdentity), 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.ReleaseExpiredHolds(env.ctx(adminIdentity), 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	released, err := env.admin.ReleaseExpiredHolds(env.ctx(adminIdentity), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, released)
+	require.Equal(t, 90, env.balance("alice", "m1"))
+}
@@ -0,0 +1,240 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// transactionRules lists the parameter checks of every function taking IDs or values
+var transactionRules = map[string]paramRules{
+	"GetMember":                        {required: []int{0}},
+	"GetCustomersByMerchant":           {required: []int{0}},
+	"CreateMember":                     {required: []int{0, 1}},
+	"CreateTransaction":                {required: []int{0, 1, 2, 4}, points: []int{3}, dates: []int{5}, enums: map[int][]string{6: transactionTypes}},
+	"GetTransaction":                   {required: []int{0}},
+	"OfferGift":                        {required: []int{0, 1, 2, 4}, points: []int{3}, dates: []int{4}},
+	"AcceptGift":                       {required: []int{0}},
+	"RejectGift":                       {required: []int{0}},
+	"ExpireGift":                       {required: []int{0}},
+	"GetGift":                          {required: []int{0}},
+	"RegisterAccount":                  {required: []int{0}},
+	"GetAccountRequests":               {required: []int{0}},
+	"ApproveAccount":                   {required: []int{0, 1}},
+	"RejectAccount":                    {required: []int{0, 1}},
+	"GetMemberPrivateDetails":          {required: []int{0, 1}},
+	"GetMemberPrivateHash":             {required: []int{0}},
+	"GetPrivateGiftBalance":            {required: []int{0}},
+	"RegisterMerchant":                 {required: []int{0, 1, 2, 3}},
+	"UpdateMerchant":                   {required: []int{0, 2}},
+	"DeactivateMerchant":               {required: []int{0}},
+	"GetMerchant":                      {required: []int{0}},
+	"SetMerchantMSP":                   {required: []int{0, 1}},
+	"GetMerchantMSP":                   {required: []int{0}},
+	"ReverseTransaction":               {required: []int{0, 1}},
+	"UpdateStatus":                     {required: []int{0, 1}, enums: map[int][]string{1: transactionStatuses}},
+	"FreezeAccount":                    {required: []int{0, 1}},
+	"UnfreezeAccount":                  {required: []int{0}},
+	"GetFreeze":                        {required: []int{0}},
+	"ProposeAdjustment":                {required: []int{0, 1, 2, 4}},
+	"ApproveAdjustment":                {required: []int{0}},
+	"RejectAdjustment":                 {required: []int{0}},
+	"GetAdjustment":                    {required: []int{0}},
+	"CreateTransactionsBatch":          {required: []int{0}, long: []int{0}},
+	"SetExchangeRate":                  {required: []int{0, 1}},
+	"GetExchangeRate":                  {required: []int{0, 1}},
+	"ConvertPoints":                    {required: []int{0, 1, 2}, points: []int{3}},
+	"SetPointTypeRule":                 {required: []int{0, 1}},
+	"IssuePoints":                      {required: []int{0, 1, 2, 3}, points: []int{4}},
+	"RedeemPoints":                     {required: []int{0, 1, 2, 3}, points: []int{4}},
+	"CreateCampaign":                   {required: []int{0, 1, 2, 3, 4}, dates: []int{3, 4}, long: []int{6}},
+	"GetCampaign":                      {required: []int{0}},
+	"GetCampaignSpend":                 {required: []int{0}},
+	"AwardCampaignPoints":              {required: []int{0, 1, 2, 3}, points: []int{4}},
+	"SetBirthdayPoints":                {required: []int{0}},
+	"GrantBirthdayPoints":              {required: []int{0, 1}},
+	"GetBirthdayGrant":                 {required: []int{0, 1}},
+	"SetReferralPoints":                {required: []int{0}},
+	"RecordReferral":                   {required: []int{0, 1, 2}},
+	"GetReferral":                      {required: []int{0, 1}},
+	"SetStockistCommission":            {required: []int{0}},
+	"CreateOrderTransaction":           {required: []int{0, 1, 2, 5, 6}, points: []int{3}, dates: []int{4}},
+	"GetStockistBalance":               {required: []int{0, 1}},
+	"QueryStockistCommissions":         {required: []int{0, 1}, points: []int{2}},
+	"GetTier":                          {required: []int{0, 1}},
+	"GetOrderReward":                   {required: []int{0, 1}},
+	"GetSettlementReport":              {required: []int{0, 1, 2}},
+	"Approve":                          {required: []int{0, 1}},
+	"GetAllowance":                     {required: []int{0, 1}},
+	"TransferFrom":                     {required: []int{0, 1, 2}, points: []int{3}},
+	"HoldPoints":                       {required: []int{0, 2}, points: []int{1}},
+	"CapturePoints":                    {required: []int{0}},
+	"ReleaseHold":                      {required: []int{0}},
+	"GetHold":                          {required: []int{0}},
+	"IssueVoucher":                     {required: []int{0, 1, 2, 3}, points: []int{4}},
+	"RedeemVoucher":                    {required: []int{0}},
+	"GetVoucher":                       {required: []int{0}},
+	"GetVouchersByOwner":               {required: []int{0}, enums: map[int][]string{1: voucherStatuses}},
+	"GetVouchersByStatus":              {required: []int{0}, enums: map[int][]string{0: voucherStatuses}},
+	"BurnPoints":                       {required: []int{0, 1, 3}, points: []int{2}},
+	"ExpirePoints":                     {required: []int{0}},
+	"SetAccountEndorsement":            {required: []int{0}, long: []int{1}},
+	"GetAccountEndorsement":            {required: []int{0}},
+	"SetApprovalPolicy":                {required: []int{0}},
+	"ApproveTransaction":               {required: []int{0}},
+	"ArchivePeriod":                    {required: []int{0, 1}, dates: []int{1}},
+	"GetPeriodSummary":                 {required: []int{0, 1, 2}, dates: []int{2}},
+	"MigrateRange":                     {points: []int{2}, long: []int{3}},
+	"SetStateCodec":                    {required: []int{0}, enums: map[int][]string{0: {codecJSON, codecProtobuf}}},
+	"QueryTransactionsByStatus":        {required: []int{0}, points: []int{1}, enums: map[int][]string{0: transactionStatuses}},
+	"QueryTransactionsByStatusAndType": {required: []int{0, 1}, points: []int{2}, enums: map[int][]string{0: transactionStatuses, 1: transactionTypes}},
+	"GetProgramStats":                  {required: []int{0}},
+	"GetSettlementReportAsCSV":         {required: []int{0, 1, 2}},
+	"GetProgramStatsAsCSV":             {required: []int{0}},
+	"SetVelocityLimits":                {required: []int{0}},
+	"GetVelocityLimits":                {required: []int{0}},
+	"GetVelocityUsage":                 {required: []int{0}},
+	"AnchorDocument":                   {required: []int{0, 1}},
+	"VerifyDocument":                   {required: []int{0, 1}},
+	"MergeAccounts":                    {required: []int{0, 1}},
+	"GetAccountMerge":                  {required: []int{0}},
+	"GetMergedTransactions":            {required: []int{0}},
+	"SetLifeCardProgram":               {required: []int{0}},
+	"IssueLifeCard":                    {required: []int{0}},
+	"RenewLifeCard":                    {required: []int{0}},
+	"RevokeLifeCard":                   {required: []int{0}},
+	"GetLifeCard":                      {required: []int{0}},
+	"GetLifeCardStatus":                {required: []int{0}},
+	"AccrueLifeCardBonus":              {required: []int{0}},
+	"GetBalanceProvenance":             {required: []int{0, 1}},
+	"VerifyBalance":                    {required: []int{0, 1}},
+	"VoidTransaction":                  {required: []int{0, 1}},
+	"SetMemberLocale":                  {required: []int{0, 1}},
+	"SetOperatorMSP":                   {required: []int{0}},
+	"PurgeByPrefix":                    {required: []int{0}, points: []int{1}},
+	"Name":                             {required: []int{0}},
+	"Symbol":                           {required: []int{0}},
+	"Decimals":                         {required: []int{0}},
+	"TotalSupply":                      {required: []int{0}},
+	"BalanceOf":                        {required: []int{0, 1}},
+	"Transfer":                         {required: []int{0}, points: []int{1}},
+	"SetTokenSymbol":                   {required: []int{0, 1}},
+	"LockForBridge":                    {required: []int{0, 2, 3}, points: []int{1}},
+	"GetBridgeReceipt":                 {required: []int{0}},
+	"MintFromBridge":                   {required: []int{0, 1}, long: []int{0}},
+	"SetBridgeConfig":                  {required: []int{0, 1}, long: []int{1}},
+	"PruneDeltas":                      {required: []int{0}},
+	"QueryPointsExpiringWithin":        {required: []int{0}, points: []int{1, 2}},
+	"QueryMyTransactions":              {points: []int{0}},
+	"QueryAuditLog":                    {required: []int{0, 1}, points: []int{2}, dates: []int{0, 1}},
+}
+
+// beforeTransaction runs before every function of the chaincode: it logs the invocation,
+// validates the parameters, rejects writes while the contract is paused and records
+// privileged operations in the audit log
+func beforeTransaction(ctx TransactionContext) error {
+	function := invokedFunction(ctx)
+	_, params := ctx.GetStub().GetFunctionAndParameters()
+
+	clientID, mspID, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	logDebug("invoke", logFields{
+		"txId":     ctx.GetStub().GetTxID(),
+		"function": function,
+		"client":   clientID,
+		"msp":      mspID,
+	})
+
+	err = validateParams(function, params)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotPaused(ctx, function)
+	if err != nil {
+		return err
+	}
+
+	if auditedFunctions[function] {
+		return recordAudit(ctx, function, params)
+	}
+
+	return nil
+}
+
+// invokedFunction returns the name of the invoked function without its contract namespace
+func invokedFunction(ctx TransactionContext) string {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+
+	return function
+}
+
+// isQuery reports whether a function only reads from the world state
+func isQuery(function string) bool {
+	return strings.HasPrefix(function, "Get") || strings.HasPrefix(function, "Query") || strings.HasPrefix(function, "Verify") ||
+		tokenQueries[function]
+}
+
+// unknownTransaction returns the handler called for functions the contract does not provide,
+// the error lists the functions which are available
+func unknownTransaction(contract contractapi.ContractInterface) func(TransactionContext) error {
+	functions := strings.Join(contractFunctions(contract), ",")
+
+	return func(ctx TransactionContext) error {
+		function, _ := ctx.GetStub().GetFunctionAndParameters()
+
+		return newError(ErrFunctionNotFound, "function %s does not exist in contract %s", function, contractName(contract)).
+			WithDetail("functions", functions)
+	}
+}
+
+// contractFunctions returns the names of the functions a contract exposes
+func contractFunctions(contract contractapi.ContractInterface) []string {
+	// Methods of the contractapi interfaces are not callable
+	interfaces := []reflect.Type{
+		reflect.TypeOf((*contractapi.ContractInterface)(nil)).Elem(),
+		reflect.TypeOf((*contractapi.IgnoreContractInterface)(nil)).Elem(),
+		reflect.TypeOf((*contractapi.EvaluationContractInterface)(nil)).Elem(),
+	}
+
+	contractType := reflect.TypeOf(contract)
+
+	functions := []string{}
+	for i := 0; i < contractType.NumMethod(); i++ {
+		name := contractType.Method(i).Name
+
+		ignored := false
+		for _, interfaceType := range interfaces {
+			if _, ok := interfaceType.MethodByName(name); ok {
+				ignored = true
+			}
+		}
+
+		if !ignored {
+			functions = append(functions, name)
+		}
+	}
+
+	return functions
+}
+
+// contractName returns the name a contract is registered under
+func contractName(contract contractapi.ContractInterface) string {
+	if contract.GetName() != "" {
+		return contract.GetName()
+	}
+
+	return reflect.TypeOf(contract).Elem().Name()
+}
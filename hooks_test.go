@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChaincode(t *testing.T) {
+	chaincode, err := newChaincode()
+	require.NoError(t, err)
+	require.Equal(t, "points-transfer", chaincode.Info.Title)
+	require.Equal(t, "PointsContract", chaincode.DefaultContract)
+}
+
+func TestBeforeTransactionValidatesParams(t *testing.T) {
+	env := newTestEnv(t)
+
+	ctx := env.ctx(merchantIdentity)
+	env.stub.args = []string{"CreateTransaction", "", "m1", "alice", "10"}
+	requireErrorCode(t, beforeTransaction(ctx), ErrInvalidArgument)
+
+	ctx = env.ctx(merchantIdentity)
+	env.stub.args = []string{"CreateTransaction", "t1", "m1", "alice", "-5", "m1"}
+	requireErrorCode(t, beforeTransaction(ctx), ErrInvalidArgument)
+
+	ctx = env.ctx(adminIdentity)
+	env.stub.args = []string{"AdminContract:GetPauseState"}
+	require.NoError(t, beforeTransaction(ctx))
+	require.Equal(t, "GetPauseState", invokedFunction(ctx))
+}
+
+func TestBeforeTransactionRejectsWritesWhilePaused(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, env.admin.Pause(env.ctx(adminIdentity), "incident"))
+
+	ctx := env.ctx(merchantIdentity)
+	env.stub.args = []string{"PointsContract:CreateTransaction", "t1", "m1", "alice", "10", "m1"}
+	requireErrorCode(t, beforeTransaction(ctx), ErrInvalidState)
+}
+
+func TestIsQuery(t *testing.T) {
+	require.True(t, isQuery("GetMember"))
+	require.True(t, isQuery("QueryMyTransactions"))
+	require.False(t, isQuery("CreateTransaction"))
+}
+
+func TestUnknownTransaction(t *testing.T) {
+	env := newTestEnv(t)
+
+	ctx := env.ctx(adminIdentity)
+	env.stub.args = []string{"Missing"}
+	err := unknownTransaction(env.points)(ctx)
+	requireErrorCode(t, err, ErrFunctionNotFound)
+	require.Equal(t, "function Missing does not exist in contract PointsContract", err.(*ContractError).Message)
+	require.Contains(t, err.(*ContractError).Details["functions"], "CreateTransaction")
+}
+
+func TestCreateTransactionIsIdempotent(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	ctx := env.ctx(merchantIdentity)
+	env.stub.transient[idempotencyTransientKey] = []byte("retry-1")
+	id, err := env.points.CreateTransaction(ctx, "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	require.NoError(t, err)
+	require.Equal(t, "t1", id)
+
+	ctx = env.ctx(merchantIdentity)
+	env.stub.transient[idempotencyTransientKey] = []byte("retry-1")
+	id, err = env.points.CreateTransaction(ctx, "t2", "m1", "alice", 10, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err)
+	require.Equal(t, "t1", id, "a retry returns the transaction of the first submission")
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	ctx = env.ctx(merchantIdentity)
+	env.stub.transient[idempotencyTransientKey] = []byte("retry-2")
+	id, err = env.points.CreateTransaction(ctx, "t3", "m1", "alice", 10, "m1", "", TypeOrder, "o3")
+	require.NoError(t, err)
+	require.Equal(t, "t3", id)
+	require.Equal(t, 20, env.balance("alice", "m1"))
+}
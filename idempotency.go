@@ -0,0 +1,92 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+const (
+	idempotencyObjectType = "idempotency"
+
+	// idempotencyTransientKey is the transient field carrying the optional idempotency token
+	// of a create operation. Retries of the operation with the same token return the
+	// transaction created by the first submission instead of creating another one.
+	idempotencyTransientKey = "idempotency_key"
+)
+
+// getIdempotencyToken returns the idempotency token passed in transient data, or "" if there is none
+func getIdempotencyToken(ctx TransactionContext) (string, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", newError(ErrInternal, "failed to get transient data. %s", err.Error())
+	}
+
+	return string(transientMap[idempotencyTransientKey]), nil
+}
+
+// idempotencyKeys returns the index keys of a token, tokens are scoped to the organization
+// of the caller so two organizations cannot collide or learn each other's transactions
+func idempotencyKeys(ctx TransactionContext, token string) ([]string, error) {
+	_, mspID, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{mspID, token}, nil
+}
+
+// replayedTransaction returns the key of the transaction created by an earlier submission with
+// the idempotency token of this one, or "" if there is no token or it was not used yet
+func replayedTransaction(ctx TransactionContext) (string, error) {
+	token, err := getIdempotencyToken(ctx)
+	if err != nil || token == "" {
+		return "", err
+	}
+
+	keys, err := idempotencyKeys(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	_, err = getCompositeObject(ctx, idempotencyObjectType, keys, &id)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// idempotentCreate runs create unless the idempotency token of this submission was used before,
+// and returns the key of the transaction created now or by the earlier submission
+func idempotentCreate(ctx TransactionContext, create func() (string, error)) (string, error) {
+	replayed, err := replayedTransaction(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if replayed != "" {
+		return replayed, nil
+	}
+
+	id, err := create()
+	if err != nil {
+		return "", err
+	}
+
+	return id, putIdempotencyToken(ctx, id)
+}
+
+// putIdempotencyToken maps the idempotency token of this submission, if any, to the transaction it created
+func putIdempotencyToken(ctx TransactionContext, id string) error {
+	token, err := getIdempotencyToken(ctx)
+	if err != nil || token == "" {
+		return err
+	}
+
+	keys, err := idempotencyKeys(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return putCompositeObject(ctx, idempotencyObjectType, keys, id)
+}
@@ -0,0 +1,380 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	lifeCardObjectType = "lifeCard"
+
+	// lifeCardPeriodLayout formats the month a lifecard bonus is accrued for
+	lifeCardPeriodLayout = "2006-01"
+)
+
+// LifeCard statuses
+const (
+	LifeCardNone    = "none"
+	LifeCardActive  = "active"
+	LifeCardExpired = "expired"
+	LifeCardRevoked = "revoked"
+)
+
+// LifeCard is the membership card of a customer, issued by the customer's merchant
+type LifeCard struct {
+	Schema
+	Owner    string `json:"owner"`
+	Merchant string `json:"merchant"`
+	// Status is active or revoked as stored, cards past their expiry are returned as expired
+	Status   string `json:"status"`
+	IssuedAt string `json:"issuedAt"`
+	// ExpiresAt is empty for cards which never expire
+	ExpiresAt string `json:"expiresAt,omitempty" metadata:"expiresAt,optional"`
+	RenewedAt string `json:"renewedAt,omitempty" metadata:"renewedAt,optional"`
+	RevokedAt string `json:"revokedAt,omitempty" metadata:"revokedAt,optional"`
+	Reason    string `json:"reason,omitempty" metadata:"reason,optional"`
+	// LastAccrual is the month the last bonus was credited for and LastAccrualTransaction its transaction
+	LastAccrual            string `json:"lastAccrual,omitempty" metadata:"lastAccrual,optional"`
+	LastAccrualTransaction string `json:"lastAccrualTransaction,omitempty" metadata:"lastAccrualTransaction,optional"`
+}
+
+// SetLifeCardProgram sets the points a merchant credits each month to customers with an active
+// lifecard, and whether customers need one to transfer and redeem points
+func (s *MerchantContract) SetLifeCardProgram(ctx TransactionContext, merchantID string, monthlyBonus int, required bool) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if monthlyBonus < 0 {
+		return newError(ErrInvalidArgument, "lifecard bonus must not be negative")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.LifeCardBonus = monthlyBonus
+	merchant.Program.LifeCardRequired = required
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// IssueLifeCard issues a lifecard valid for validDays to a customer, 0 for a card which never
+// expires. A revoked or expired card is replaced, an active one must be renewed instead.
+func (s *MerchantContract) IssueLifeCard(ctx TransactionContext, owner string, validDays int) (*LifeCard, error) {
+	customer, err := assertCanManageLifeCard(ctx, owner, validDays)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil && lifeCardStatus(existing, now) == LifeCardActive {
+		return nil, newError(ErrAlreadyExists, "%s already has an active lifecard", owner)
+	}
+
+	card := LifeCard{
+		Owner:     owner,
+		Merchant:  customer.Merchant,
+		Status:    LifeCardActive,
+		IssuedAt:  now.Format(time.RFC3339),
+		ExpiresAt: lifeCardExpiry(now, validDays),
+	}
+
+	err = putObject(ctx, lifeCardObjectType, owner, &card)
+	if err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// RenewLifeCard extends the lifecard of a customer by validDays from its expiry, or from now if
+// it already expired, 0 makes it never expire
+func (s *MerchantContract) RenewLifeCard(ctx TransactionContext, owner string, validDays int) (*LifeCard, error) {
+	_, err := assertCanManageLifeCard(ctx, owner, validDays)
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if card == nil {
+		return nil, newError(ErrNotFound, "%s has no lifecard", owner)
+	}
+
+	if card.Status == LifeCardRevoked {
+		return nil, newError(ErrInvalidState, "the lifecard of %s was revoked", owner)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from := now
+	if expiresAt, err := time.Parse(time.RFC3339, card.ExpiresAt); err == nil && expiresAt.After(now) {
+		from = expiresAt
+	}
+
+	card.ExpiresAt = lifeCardExpiry(from, validDays)
+	card.RenewedAt = now.Format(time.RFC3339)
+
+	err = putObject(ctx, lifeCardObjectType, owner, card)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Status = lifeCardStatus(card, now)
+	return card, nil
+}
+
+// RevokeLifeCard revokes the lifecard of a customer
+func (s *MerchantContract) RevokeLifeCard(ctx TransactionContext, owner string, reason string) error {
+	_, err := assertCanManageLifeCard(ctx, owner, 0)
+	if err != nil {
+		return err
+	}
+
+	card, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if card == nil {
+		return newError(ErrNotFound, "%s has no lifecard", owner)
+	}
+
+	if card.Status == LifeCardRevoked {
+		return newError(ErrInvalidState, "the lifecard of %s was already revoked", owner)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	card.Status = LifeCardRevoked
+	card.RevokedAt = now.Format(time.RFC3339)
+	card.Reason = reason
+
+	return putObject(ctx, lifeCardObjectType, owner, card)
+}
+
+// GetLifeCard returns the lifecard of a customer
+func (s *PointsContract) GetLifeCard(ctx TransactionContext, owner string) (*LifeCard, error) {
+	card, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if card == nil {
+		return nil, newError(ErrNotFound, "%s has no lifecard", owner)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Status = lifeCardStatus(card, now)
+	return card, nil
+}
+
+// GetLifeCardStatus returns the status of the lifecard of a customer, none if it has no card
+func (s *PointsContract) GetLifeCardStatus(ctx TransactionContext, owner string) (string, error) {
+	card, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return lifeCardStatus(card, now), nil
+}
+
+// AccrueLifeCardBonus credits the monthly lifecard bonus of the customer's merchant, at most
+// once per calendar month and only while the card is active. It returns the key of the transaction.
+func (s *PointsContract) AccrueLifeCardBonus(ctx TransactionContext, owner string) (string, error) {
+	card, err := getLifeCard(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	if card == nil {
+		return "", newError(ErrNotFound, "%s has no lifecard", owner)
+	}
+
+	err = assertMerchantMSP(ctx, card.Merchant)
+	if err != nil {
+		return "", err
+	}
+
+	merchant, err := getMerchant(ctx, card.Merchant)
+	if err != nil {
+		return "", err
+	}
+
+	if merchant.Program.LifeCardBonus <= 0 {
+		return "", newError(ErrInvalidState, "merchant %s does not grant a lifecard bonus", card.Merchant)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	status := lifeCardStatus(card, now)
+	if status != LifeCardActive {
+		return "", newError(ErrLifeCardInactive, "the lifecard of %s is %s", owner, status).
+			WithDetail("owner", owner).
+			WithDetail("status", status)
+	}
+
+	period := now.Format(lifeCardPeriodLayout)
+	if card.LastAccrual == period {
+		return "", newError(ErrAlreadyExists, "%s already received the lifecard bonus of %s", owner, period).
+			WithDetail("transaction", card.LastAccrualTransaction)
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     merchant.Program.LifeCardBonus,
+		Merchant:  card.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    card.Merchant,
+		Receiver:  owner,
+		Source:    &Source{Type: TypeLifeCard, ID: period},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return "", err
+	}
+
+	card.LastAccrual = period
+	card.LastAccrualTransaction = transaction.ID
+
+	return transaction.ID, putObject(ctx, lifeCardObjectType, owner, card)
+}
+
+// assertCanManageLifeCard checks that owner is a customer whose lifecard the caller may manage,
+// admins or the organization of the customer's merchant, and returns the customer
+func assertCanManageLifeCard(ctx TransactionContext, owner string, validDays int) (*Member, error) {
+	if validDays < 0 {
+		return nil, newError(ErrInvalidArgument, "validity must not be negative")
+	}
+
+	customer, err := getMember(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.Merchant == "" {
+		return nil, newError(ErrInvalidArgument, "%s is a merchant and cannot hold a lifecard", owner)
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, customer.Merchant)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return customer, nil
+}
+
+// assertLifeCardEligible returns an ErrLifeCardInactive error if the merchant of a customer
+// requires a lifecard to transfer and redeem points and the customer has no active one
+func assertLifeCardEligible(ctx TransactionContext, customer *Member) error {
+	merchant, err := getMerchant(ctx, customer.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if !merchant.Program.LifeCardRequired {
+		return nil
+	}
+
+	card, err := getLifeCard(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := lifeCardStatus(card, now)
+	if status != LifeCardActive {
+		return newError(ErrLifeCardInactive, "%s needs an active lifecard of %s, the card is %s", customer.ID, customer.Merchant, status).
+			WithDetail("owner", customer.ID).
+			WithDetail("status", status)
+	}
+
+	return nil
+}
+
+// getLifeCard returns the lifecard of a customer, or nil if it has none
+func getLifeCard(ctx TransactionContext, owner string) (*LifeCard, error) {
+	var card LifeCard
+	found, err := getObject(ctx, lifeCardObjectType, owner, &card)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// lifeCardStatus returns the status of a card at now, none for a nil card
+func lifeCardStatus(card *LifeCard, now time.Time) string {
+	if card == nil {
+		return LifeCardNone
+	}
+
+	if card.Status == LifeCardRevoked {
+		return LifeCardRevoked
+	}
+
+	if expiresAt, err := time.Parse(time.RFC3339, card.ExpiresAt); err == nil && !now.Before(expiresAt) {
+		return LifeCardExpired
+	}
+
+	return LifeCardActive
+}
+
+// lifeCardExpiry returns the expiry of a card valid for validDays from from, "" if it never expires
+func lifeCardExpiry(from time.Time, validDays int) string {
+	if validDays == 0 {
+		return ""
+	}
+
+	return from.AddDate(0, 0, validDays).Format(time.RFC3339)
+}
@@ -0,0 +1,128 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueLifeCard(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	status, err := env.points.GetLifeCardStatus(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, LifeCardNone, status)
+
+	_, err = env.merchants.IssueLifeCard(env.ctx(otherMSPIdentity), "alice", 30)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "m1", 30)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	card, err := env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "alice", 30)
+	require.NoError(t, err)
+	require.Equal(t, "m1", card.Merchant)
+	require.Equal(t, LifeCardActive, card.Status)
+
+	_, err = env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "alice", 30)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	env.advance(31 * 24 * time.Hour)
+
+	status, err = env.points.GetLifeCardStatus(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, LifeCardExpired, status)
+
+	card, err = env.merchants.RenewLifeCard(env.ctx(merchantIdentity), "alice", 0)
+	require.NoError(t, err)
+	require.Empty(t, card.ExpiresAt)
+
+	card, err = env.points.GetLifeCard(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, LifeCardActive, card.Status)
+
+	_, err = env.points.GetLifeCard(env.ctx(adminIdentity), "bob")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestRevokeLifeCard(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	_, err := env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "alice", 0)
+	require.NoError(t, err)
+
+	err = env.merchants.RevokeLifeCard(env.ctx(otherMSPIdentity), "alice", "lost")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.merchants.RevokeLifeCard(env.ctx(merchantIdentity), "alice", "lost"))
+
+	err = env.merchants.RevokeLifeCard(env.ctx(merchantIdentity), "alice", "lost")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	_, err = env.merchants.RenewLifeCard(env.ctx(merchantIdentity), "alice", 30)
+	requireErrorCode(t, err, ErrInvalidState)
+
+	env.reward("m1", "bob", 10)
+	err = env.merchants.RevokeLifeCard(env.ctx(merchantIdentity), "bob", "lost")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestAccrueLifeCardBonus(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	_, err := env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "alice", 0)
+	require.NoError(t, err)
+
+	_, err = env.points.AccrueLifeCardBonus(env.ctx(merchantIdentity), "alice")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.merchants.SetLifeCardProgram(env.ctx(merchantIdentity), "m1", -1, false)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.merchants.SetLifeCardProgram(env.ctx(merchantIdentity), "m1", 5, false))
+
+	_, err = env.points.AccrueLifeCardBonus(env.ctx(otherMSPIdentity), "alice")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.AccrueLifeCardBonus(env.ctx(merchantIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 15, env.balance("alice", "m1"))
+
+	_, err = env.points.AccrueLifeCardBonus(env.ctx(merchantIdentity), "alice")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	require.NoError(t, env.merchants.RevokeLifeCard(env.ctx(merchantIdentity), "alice", "lost"))
+	env.advance(31 * 24 * time.Hour)
+
+	_, err = env.points.AccrueLifeCardBonus(env.ctx(merchantIdentity), "alice")
+	requireErrorCode(t, err, ErrLifeCardInactive)
+}
+
+func TestLifeCardRequired(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	require.NoError(t, env.merchants.SetLifeCardProgram(env.ctx(merchantIdentity), "m1", 0, true))
+
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 10, "m1", "", TypeRedemption, "")
+	requireErrorCode(t, err, ErrLifeCardInactive)
+
+	err = env.points.OfferGift(env.ctx(alice), "g1", "alice", "bob", 10, "2024-03-22T00:00:00Z")
+	requireErrorCode(t, err, ErrLifeCardInactive)
+
+	_, err = env.merchants.IssueLifeCard(env.ctx(merchantIdentity), "alice", 30)
+	require.NoError(t, err)
+
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r2", "alice", "m1", 10, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+}
@@ -0,0 +1,53 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+// SetMemberLocale sets the BCP-47 language tag a member is addressed in, such as zh-CN. Customers
+// set their own locale, merchant organizations that of their merchant.
+func (s *PointsContract) SetMemberLocale(ctx TransactionContext, id string, locale string) error {
+	member, err := getMember(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if member.Merchant == "" && !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, member.ID)
+	} else {
+		err = assertAccountOwner(ctx, member.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	member.Locale, err = validateLocale(namedField("locale"), locale)
+	if err != nil {
+		return err
+	}
+
+	return putMember(ctx, member)
+}
+
+// fillMemberLocale sets the locale of a member stored without one to the locale of its merchant.
+// Members were first created with the locale as merchant ID, such as zh-CN, before merchants were
+// registered with a separate locale.
+func fillMemberLocale(ctx TransactionContext, member *Member) error {
+	if member.Locale != "" {
+		return nil
+	}
+
+	merchantID := member.Merchant
+	if merchantID == "" {
+		merchantID = member.ID
+	}
+
+	var merchant Merchant
+	found, err := getObject(ctx, merchantObjectType, merchantID, &merchant)
+	if err != nil || !found {
+		return err
+	}
+
+	member.Locale = merchant.Locale
+	return nil
+}
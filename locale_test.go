@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMemberLocale(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	alice := env.registerAccount("alice")
+	require.Equal(t, "en", env.member("alice").Locale, "customers get the locale of their merchant")
+
+	require.NoError(t, env.points.SetMemberLocale(env.ctx(alice), "alice", "zh-CN"))
+	require.Equal(t, "zh-CN", env.member("alice").Locale)
+
+	err := env.points.SetMemberLocale(env.ctx(customerIdentity("bob")), "alice", "fr")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.SetMemberLocale(env.ctx(alice), "alice", "not a locale")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.points.SetMemberLocale(env.ctx(merchantIdentity), "m1", "ja-JP"))
+	require.Equal(t, "ja-JP", env.member("m1").Locale)
+
+	err = env.points.SetMemberLocale(env.ctx(otherMSPIdentity), "m1", "fr")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.SetMemberLocale(env.ctx(alice), "nobody", "fr")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestRegisterMerchantValidatesLocale(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, env.admin.SetOperatorMSP(env.ctx(adminIdentity), "Org1MSP"))
+
+	err := env.merchants.RegisterMerchant(env.ctx(adminIdentity), "m1", "Merchant m1", "Org1MSP", "not a locale")
+	requireFieldError(t, err, "locale", ruleLocale)
+
+	require.NoError(t, env.merchants.RegisterMerchant(env.ctx(adminIdentity), "m1", "Merchant m1", "Org1MSP", "zh-cn"))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "zh-CN", merchant.Locale, "locales are stored in their canonical form")
+}
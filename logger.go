@@ -0,0 +1,159 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log levels, from the most to the least verbose
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = map[int]string{
+	levelDebug: "debug",
+	levelInfo:  "info",
+	levelWarn:  "warn",
+	levelError: "error",
+}
+
+// Log formats
+const (
+	formatJSON = "json"
+	formatText = "text"
+)
+
+// logFields are the structured fields attached to a log entry
+type logFields map[string]interface{}
+
+// logger writes leveled log entries as JSON objects or key=value text lines
+type logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	level  int
+	format string
+}
+
+// chaincodeLogger is the logger of the chaincode process, see configureLogger
+var chaincodeLogger = &logger{out: os.Stderr, level: levelInfo, format: formatJSON}
+
+// configureLogger sets the minimum level and the format of the chaincode logger
+func configureLogger(level string, format string) error {
+	minLevel := -1
+	for value, name := range levelNames {
+		if strings.EqualFold(level, name) {
+			minLevel = value
+		}
+	}
+
+	if minLevel < 0 {
+		return fmt.Errorf("unknown log level %s", level)
+	}
+
+	format = strings.ToLower(format)
+	if format != formatJSON && format != formatText {
+		return fmt.Errorf("unknown log format %s", format)
+	}
+
+	chaincodeLogger.mu.Lock()
+	defer chaincodeLogger.mu.Unlock()
+
+	chaincodeLogger.level = minLevel
+	chaincodeLogger.format = format
+
+	return nil
+}
+
+func logDebug(msg string, fields logFields) {
+	chaincodeLogger.log(levelDebug, msg, fields)
+}
+
+func logInfo(msg string, fields logFields) {
+	chaincodeLogger.log(levelInfo, msg, fields)
+}
+
+func logWarn(msg string, fields logFields) {
+	chaincodeLogger.log(levelWarn, msg, fields)
+}
+
+func logError(msg string, fields logFields) {
+	chaincodeLogger.log(levelError, msg, fields)
+}
+
+// logPanic logs msg at error level and panics with it
+func logPanic(msg string, fields logFields) {
+	chaincodeLogger.log(levelError, msg, fields)
+	panic(msg)
+}
+
+func (l *logger) log(level int, msg string, fields logFields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level < l.level {
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	var line []byte
+	if l.format == formatText {
+		line = textEntry(now, levelNames[level], msg, fields)
+	} else {
+		line = jsonEntry(now, levelNames[level], msg, fields)
+	}
+
+	l.out.Write(append(line, '\n'))
+}
+
+func jsonEntry(ts string, level string, msg string, fields logFields) []byte {
+	entry := logFields{}
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+
+	entry["ts"] = ts
+	entry["level"] = level
+	entry["msg"] = msg
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		// Fields which cannot be marshalled are dropped rather than losing the entry
+		line, _ = json.Marshal(logFields{"ts": ts, "level": level, "msg": msg, "logError": err.Error()})
+	}
+
+	return line
+}
+
+func textEntry(ts string, level string, msg string, fields logFields) []byte {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s", ts, strings.ToUpper(level), msg)
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%q", key, fmt.Sprint(fields[key]))
+	}
+
+	return []byte(b.String())
+}
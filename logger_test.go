@@ -0,0 +1,60 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	l := &logger{out: &out, level: levelInfo, format: formatJSON}
+
+	l.log(levelDebug, "hidden", nil)
+	require.Empty(t, out.String(), "entries below the level are dropped")
+
+	l.log(levelWarn, "invoke", logFields{"function": "CreateTransaction", "error": errors.New("failed")})
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	require.Equal(t, "warn", entry["level"])
+	require.Equal(t, "invoke", entry["msg"])
+	require.Equal(t, "CreateTransaction", entry["function"])
+	require.Equal(t, "failed", entry["error"], "errors are logged with their message")
+	require.NotEmpty(t, entry["ts"])
+}
+
+func TestLoggerText(t *testing.T) {
+	var out bytes.Buffer
+	l := &logger{out: &out, level: levelDebug, format: formatText}
+
+	l.log(levelError, "invoke", logFields{"tx": "t1", "function": "GetMember"})
+
+	line := strings.TrimSpace(out.String())
+	require.True(t, strings.HasSuffix(line, `ERROR invoke function="GetMember" tx="t1"`), line)
+}
+
+func TestConfigureLogger(t *testing.T) {
+	defer configureLogger("info", formatJSON)
+
+	require.Error(t, configureLogger("verbose", formatJSON))
+	require.Error(t, configureLogger("info", "xml"))
+
+	require.NoError(t, configureLogger("DEBUG", "Text"))
+	require.Equal(t, levelDebug, chaincodeLogger.level)
+	require.Equal(t, formatText, chaincodeLogger.format)
+}
+
+func TestLogPanic(t *testing.T) {
+	require.PanicsWithValue(t, "error starting", func() {
+		logPanic("error starting", logFields{"address": "0.0.0.0:9999"})
+	})
+}
@@ -0,0 +1,230 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const merchantObjectType = "merchant"
+
+// PointProgram holds the rules of a merchant's points program
+type PointProgram struct {
+	// PointsPerUnit is the number of points awarded per currency unit spent
+	PointsPerUnit int `json:"pointsPerUnit"`
+	// ExpiryDays is the number of days points stay valid, 0 if they never expire
+	ExpiryDays int `json:"expiryDays"`
+	// MinRedemption is the smallest number of points which may be redeemed at once
+	MinRedemption int `json:"minRedemption"`
+	// BirthdayPoints is the number of points granted to a customer once a year on their birthday, 0 if none
+	BirthdayPoints int `json:"birthdayPoints"`
+	// StockistCommission is the commission accrued by the stockist of an order, in basis points of its points, 0 if none
+	StockistCommission int `json:"stockistCommission"`
+	// ApprovalThreshold is the largest issuance credited without approval, 0 if none is needed
+	ApprovalThreshold int `json:"approvalThreshold"`
+	// RequiredApprovals is the number of other organizations which must approve a larger issuance
+	RequiredApprovals int `json:"requiredApprovals"`
+	// LifeCardBonus is the number of points credited each month to customers with an active lifecard, 0 if none
+	LifeCardBonus int `json:"lifeCardBonus"`
+	// LifeCardRequired restricts transferring and redeeming points to customers with an active lifecard
+	LifeCardRequired bool `json:"lifeCardRequired"`
+	// ReferrerPoints is the number of points credited to a customer whose referral places a first order, 0 if none
+	ReferrerPoints int `json:"referrerPoints"`
+	// RefereePoints is the number of points credited to a referred customer on their first order, 0 if none
+	RefereePoints int `json:"refereePoints"`
+	// Symbol is the ticker of the merchant's points in the token interface, the upper-cased merchant ID if empty
+	Symbol string `json:"symbol,omitempty" metadata:"symbol,optional"`
+}
+
+// PointTypeRule holds the redemption rules of one class of points
+type PointTypeRule struct {
+	// MinRedemption is the smallest number of points which may be redeemed at once
+	MinRedemption int `json:"minRedemption"`
+	// MaxRedemption is the largest number of points which may be redeemed at once, 0 if unlimited
+	MaxRedemption int `json:"maxRedemption"`
+	// ExpiryDays is the number of days points stay valid, 0 if they never expire
+	ExpiryDays int `json:"expiryDays"`
+}
+
+// Merchant is a brand running a points program, owned by one organization of the network
+type Merchant struct {
+	Schema
+	ID      string       `json:"ID"`
+	Name    string       `json:"name"`
+	MSP     string       `json:"msp"`
+	Locale  string       `json:"locale"`
+	Active  bool         `json:"active"`
+	Program PointProgram `json:"program"`
+	// PointTypes are the classes of points the merchant issues
+	PointTypes map[string]PointTypeRule `json:"pointTypes"`
+	CreatedAt  string                   `json:"created_at"`
+	UpdatedAt  string                   `json:"updated_at"`
+}
+
+// RegisterMerchant onboards a new merchant owned by the organization mspID, which only admins of
+// the operator may do. The locale is the BCP-47 language tag of the merchant's customers, such as
+// zh-CN, and is distinct from its ID.
+func (s *MerchantContract) RegisterMerchant(ctx TransactionContext, id string, name string, mspID string, locale string) error {
+	err := assertOperator(ctx)
+	if err != nil {
+		return err
+	}
+
+	var existing Merchant
+	found, err := getObject(ctx, merchantObjectType, id, &existing)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return newError(ErrAlreadyExists, "merchant %s already exists", id)
+	}
+
+	locale, err = validateLocale(namedField("locale"), locale)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant := Merchant{
+		ID:         id,
+		Name:       name,
+		MSP:        mspID,
+		Locale:     locale,
+		Active:     true,
+		Program:    PointProgram{PointsPerUnit: 1},
+		PointTypes: defaultPointTypes(),
+		CreatedAt:  now.Format(time.RFC3339),
+		UpdatedAt:  now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, merchantObjectType, id, &merchant)
+}
+
+// UpdateMerchant changes the details and points program of a merchant
+func (s *MerchantContract) UpdateMerchant(ctx TransactionContext, id string, name string, locale string, pointsPerUnit int, expiryDays int, minRedemption int) error {
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pointsPerUnit < 0 || expiryDays < 0 || minRedemption < 0 {
+		return newError(ErrInvalidArgument, "points program parameters must not be negative")
+	}
+
+	locale, err = validateLocale(namedField("locale"), locale)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Name = name
+	merchant.Locale = locale
+	merchant.Program.PointsPerUnit = pointsPerUnit
+	merchant.Program.ExpiryDays = expiryDays
+	merchant.Program.MinRedemption = minRedemption
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, id, merchant)
+}
+
+// DeactivateMerchant stops a merchant from taking part in new transactions, for admins of the operator
+func (s *MerchantContract) DeactivateMerchant(ctx TransactionContext, id string) error {
+	err := assertOperator(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Active = false
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, id, merchant)
+}
+
+// SetMerchantMSP moves a merchant to another organization, for admins of the operator
+func (s *MerchantContract) SetMerchantMSP(ctx TransactionContext, id string, mspID string) error {
+	err := assertOperator(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	merchant.MSP = mspID
+	return putObject(ctx, merchantObjectType, id, merchant)
+}
+
+// GetMerchant returns the merchant stored in the world state with given id
+func (s *MerchantContract) GetMerchant(ctx TransactionContext, id string) (*Merchant, error) {
+	return getMerchant(ctx, id)
+}
+
+// GetMerchantMSP returns the organization owning a merchant
+func (s *MerchantContract) GetMerchantMSP(ctx TransactionContext, id string) (string, error) {
+	return getMerchantMSP(ctx, id)
+}
+
+func getMerchant(ctx TransactionContext, id string) (*Merchant, error) {
+	var merchant Merchant
+	found, err := getObject(ctx, merchantObjectType, id, &merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "merchant %s does not exist in world state", id)
+	}
+
+	return &merchant, nil
+}
+
+func getMerchantMSP(ctx TransactionContext, id string) (string, error) {
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return merchant.MSP, nil
+}
+
+// assertMerchantActive checks that the merchant is registered and active
+func assertMerchantActive(ctx TransactionContext, id string) error {
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !merchant.Active {
+		return newError(ErrInvalidState, "merchant %s is not active", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMerchant(t *testing.T) {
+	env := newTestEnv(t)
+
+	err := env.merchants.RegisterMerchant(env.ctx(adminIdentity), "m1", "Merchant", "Org1MSP", "en")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	require.NoError(t, env.admin.SetOperatorMSP(env.ctx(adminIdentity), "Org1MSP"))
+
+	err = env.merchants.RegisterMerchant(env.ctx(merchantIdentity), "m1", "Merchant", "Org1MSP", "en")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.RegisterMerchant(env.ctx(otherAdmin), "m1", "Merchant", "Org2MSP", "en")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.merchants.RegisterMerchant(env.ctx(adminIdentity), "m1", "Merchant", "Org1MSP", "en"))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.True(t, merchant.Active)
+	require.Equal(t, "Org1MSP", merchant.MSP)
+	require.Equal(t, 1, merchant.Program.PointsPerUnit)
+	require.Equal(t, "2024-03-15T10:00:00Z", merchant.CreatedAt)
+
+	err = env.merchants.RegisterMerchant(env.ctx(adminIdentity), "m1", "Merchant", "Org1MSP", "en")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	_, err = env.merchants.GetMerchant(env.ctx(merchantIdentity), "m2")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestUpdateMerchant(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	require.NoError(t, env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "m1", "Renamed", "fr", 2, 90, 10))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "Renamed", merchant.Name)
+	require.Equal(t, PointProgram{PointsPerUnit: 2, ExpiryDays: 90, MinRedemption: 10}, merchant.Program)
+
+	err = env.merchants.UpdateMerchant(env.ctx(otherMSPIdentity), "m1", "Renamed", "fr", 2, 90, 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "m1", "Renamed", "fr", -1, 90, 10)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "unknown", "Renamed", "fr", 2, 90, 10)
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestDeactivateMerchant(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	err := env.merchants.DeactivateMerchant(env.ctx(merchantIdentity), "m1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.DeactivateMerchant(env.ctx(otherAdmin), "m1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.merchants.DeactivateMerchant(env.ctx(adminIdentity), "m1"))
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.merchants.DeactivateMerchant(env.ctx(adminIdentity), "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestSetMerchantMSP(t *testing.T) {
+	env := newTestEnv(t)
+	require.NoError(t, env.admin.SetOperatorMSP(env.ctx(adminIdentity), "Org1MSP"))
+
+	err := env.merchants.SetMerchantMSP(env.ctx(adminIdentity), "m1", "Org2MSP")
+	requireErrorCode(t, err, ErrNotFound)
+
+	env.registerMerchant("m1")
+
+	err = env.merchants.SetMerchantMSP(env.ctx(merchantIdentity), "m1", "Org2MSP")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	// Admins of other organizations cannot take over the merchant
+	err = env.merchants.SetMerchantMSP(env.ctx(otherAdmin), "m1", "Org2MSP")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.merchants.SetMerchantMSP(env.ctx(adminIdentity), "m1", "Org2MSP"))
+
+	msp, err := env.merchants.GetMerchantMSP(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "Org2MSP", msp)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.merchants.GetMerchantMSP(env.ctx(merchantIdentity), "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
@@ -0,0 +1,259 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	accountMergeObjectType = "accountMerge"
+
+	// mergedTransactionIndex links the transactions of merged accounts to the account they were merged into
+	mergedTransactionIndex = "mergedTransaction"
+
+	// accountsMergedEvent is emitted when an account is merged into another one
+	accountsMergedEvent = "AccountsMerged"
+)
+
+// AccountMerge records that a duplicate customer account was merged into another one and closed
+type AccountMerge struct {
+	Schema
+	Source         string                    `json:"source"`
+	Target         string                    `json:"target"`
+	Points         int                       `json:"points"`
+	MerchantPoints map[string]int            `json:"merchantPoints"`
+	TypedPoints    map[string]map[string]int `json:"typedPoints,omitempty" metadata:"typedPoints,optional"`
+	Transactions   int                       `json:"transactions"`
+	MergedBy       string                    `json:"mergedBy"`
+	MergedAt       string                    `json:"mergedAt"`
+}
+
+// MergeAccounts moves the balances of a duplicate customer account to another account of the
+// same merchant, links the transactions of the duplicate to that account and closes the duplicate
+func (s *AdminContract) MergeAccounts(ctx TransactionContext, sourceOwner string, targetOwner string) (*AccountMerge, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceOwner == targetOwner {
+		return nil, newError(ErrInvalidArgument, "cannot merge %s into itself", sourceOwner)
+	}
+
+	source, err := getMember(ctx, sourceOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := getMember(ctx, targetOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Merchant == "" || target.Merchant == "" {
+		return nil, newError(ErrInvalidArgument, "only customer accounts can be merged")
+	}
+
+	if source.Merchant != target.Merchant {
+		return nil, newError(ErrInvalidArgument, "%s and %s belong to different merchants", sourceOwner, targetOwner)
+	}
+
+	// Also rejects accounts closed by an earlier merge
+	err = assertNotFrozen(ctx, sourceOwner, targetOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	merge := AccountMerge{
+		Source:         sourceOwner,
+		Target:         targetOwner,
+		Points:         source.Points,
+		MerchantPoints: source.MerchantPoints,
+		TypedPoints:    source.TypedPoints,
+		MergedBy:       clientID,
+		MergedAt:       now.Format(time.RFC3339),
+	}
+
+	target.Points += source.Points
+	if target.MerchantPoints == nil {
+		target.MerchantPoints = map[string]int{}
+	}
+	for merchant, points := range source.MerchantPoints {
+		target.MerchantPoints[merchant] += points
+	}
+	for merchant, types := range source.TypedPoints {
+		for pointType, points := range types {
+			addTypedPoints(target, merchant, pointType, points)
+		}
+	}
+
+	source.Points = 0
+	source.MerchantPoints = map[string]int{}
+	source.TypedPoints = nil
+
+	merge.Transactions, err = linkMergedTransactions(ctx, sourceOwner, targetOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putMember(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putMember(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unbindAccount(ctx, sourceOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putObject(ctx, accountMergeObjectType, sourceOwner, &merge)
+	if err != nil {
+		return nil, err
+	}
+
+	err = emitEvent(ctx, accountsMergedEvent, &AccountsMergedEvent{AccountMerge: merge})
+	if err != nil {
+		return nil, err
+	}
+
+	return &merge, nil
+}
+
+// GetAccountMerge returns the merge which closed an account, or nil if the account was not merged
+func (s *AdminContract) GetAccountMerge(ctx TransactionContext, owner string) (*AccountMerge, error) {
+	return getAccountMerge(ctx, owner)
+}
+
+// GetMergedTransactions returns the keys of the transactions of accounts merged into an owner
+func (s *PointsContract) GetMergedTransactions(ctx TransactionContext, owner string) ([]string, error) {
+	return getMergedTransactions(ctx, owner)
+}
+
+func getMergedTransactions(ctx TransactionContext, owner string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(mergedTransactionIndex, []string{owner})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	ids := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		ids = append(ids, attributes[1])
+	}
+
+	return ids, nil
+}
+
+func getAccountMerge(ctx TransactionContext, owner string) (*AccountMerge, error) {
+	var merge AccountMerge
+	found, err := getObject(ctx, accountMergeObjectType, owner, &merge)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &merge, nil
+}
+
+// linkMergedTransactions adds the transactions sent or received by source, and those linked to it
+// by earlier merges, to the index of target. It returns the number of linked transactions.
+func linkMergedTransactions(ctx TransactionContext, source string, target string) (int, error) {
+	transactions, err := readTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := []string{}
+	for _, transaction := range transactions {
+		if transaction.Sender == source || transaction.Receiver == source {
+			ids = append(ids, transaction.ID)
+		}
+	}
+
+	linked, err := getMergedTransactions(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range linked {
+		key, err := ctx.GetStub().CreateCompositeKey(mergedTransactionIndex, []string{source, id})
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+	}
+
+	ids = append(ids, linked...)
+
+	for _, id := range ids {
+		key, err := ctx.GetStub().CreateCompositeKey(mergedTransactionIndex, []string{target, id})
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to put to world state. %s", err.Error())
+		}
+	}
+
+	return len(ids), nil
+}
+
+// unbindAccount removes the binding between a member and its enrollment identity, if any,
+// so the identity can be registered for another account
+func unbindAccount(ctx TransactionContext, memberID string) error {
+	clientID, err := getAccountIdentity(ctx, memberID)
+	if err != nil || clientID == "" {
+		return err
+	}
+
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountObjectType, []string{clientID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	ownerKey, err := ctx.GetStub().CreateCompositeKey(accountOwnerType, []string{memberID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	for _, key := range []string{accountKey, ownerKey} {
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	first := env.reward("m1", "alice", 10)
+	env.reward("m1", "alice2", 20)
+	env.reward("m2", "bob", 5)
+
+	_, err := env.admin.MergeAccounts(env.ctx(merchantIdentity), "alice2", "alice")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.MergeAccounts(env.ctx(adminIdentity), "alice", "alice")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.MergeAccounts(env.ctx(adminIdentity), "bob", "alice")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.MergeAccounts(env.ctx(adminIdentity), "nobody", "alice")
+	requireErrorCode(t, err, ErrNotFound)
+
+	merge, err := env.admin.MergeAccounts(env.ctx(adminIdentity), "alice", "alice2")
+	require.NoError(t, err)
+	require.Equal(t, 10, merge.Points)
+	env.requireEvent(accountsMergedEvent, nil)
+
+	require.Equal(t, 30, env.balance("alice2", "m1"))
+
+	merged, err := env.points.GetMergedTransactions(env.ctx(adminIdentity), "alice2")
+	require.NoError(t, err)
+	require.Contains(t, merged, first)
+
+	recorded, err := env.admin.GetAccountMerge(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, "alice2", recorded.Target)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrAccountClosed)
+
+	_, err = env.admin.MergeAccounts(env.ctx(adminIdentity), "alice", "alice2")
+	requireErrorCode(t, err, ErrAccountClosed)
+}
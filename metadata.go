@@ -0,0 +1,53 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+)
+
+// contractVersion is reported in the metadata of every contract
+const contractVersion = "1.0.0"
+
+// GetEvaluateTransactions marks the query functions as evaluate-only in the metadata
+func (s *PointsContract) GetEvaluateTransactions() []string {
+	return queryFunctions(s)
+}
+
+// GetEvaluateTransactions marks the query functions as evaluate-only in the metadata
+func (s *MerchantContract) GetEvaluateTransactions() []string {
+	return queryFunctions(s)
+}
+
+// GetEvaluateTransactions marks the query functions as evaluate-only in the metadata
+func (s *AdminContract) GetEvaluateTransactions() []string {
+	return queryFunctions(s)
+}
+
+// queryFunctions returns the functions of a contract which only read from the world state
+func queryFunctions(contract contractapi.ContractInterface) []string {
+	functions := []string{}
+
+	for _, function := range contractFunctions(contract) {
+		if isQuery(function) {
+			functions = append(functions, function)
+		}
+	}
+
+	return functions
+}
+
+// contractInfo returns the metadata info of a contract
+func contractInfo(title string, description string) metadata.InfoMetadata {
+	return metadata.InfoMetadata{
+		Title:       title,
+		Description: description,
+		Version:     contractVersion,
+		License: &metadata.LicenseMetadata{
+			Name: "Apache-2.0",
+		},
+	}
+}
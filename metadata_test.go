@@ -0,0 +1,30 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEvaluateTransactions(t *testing.T) {
+	env := newTestEnv(t)
+
+	functions := env.points.GetEvaluateTransactions()
+	require.Contains(t, functions, "GetMember")
+	require.Contains(t, functions, "GetTransaction")
+	require.NotContains(t, functions, "CreateTransaction")
+	require.NotContains(t, functions, "GetEvaluateTransactions", "functions of the contractapi interfaces are not listed")
+
+	require.Contains(t, env.merchants.GetEvaluateTransactions(), "GetMerchantMSP")
+	require.Contains(t, env.admin.GetEvaluateTransactions(), "GetAdjustment")
+}
+
+func TestContractInfo(t *testing.T) {
+	info := contractInfo("PointsContract", "Members earn, transfer and redeem points")
+	require.Equal(t, contractVersion, info.Version)
+	require.Equal(t, "Apache-2.0", info.License.Name)
+}
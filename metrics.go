@@ -0,0 +1,203 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "points_transfer"
+
+var (
+	invocationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "invocations_total",
+		Help:      "Number of invocations of each chaincode function.",
+	}, []string{"function"})
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "errors_total",
+		Help:      "Number of invocations of each chaincode function which returned an error.",
+	}, []string{"function"})
+
+	invocationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "invocation_duration_seconds",
+		Help:      "Time spent executing each chaincode function.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"function"})
+
+	stateReadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_reads_total",
+		Help:      "Number of world state and private data reads made by each chaincode function.",
+	}, []string{"function"})
+
+	stateWritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_writes_total",
+		Help:      "Number of world state and private data writes and deletes made by each chaincode function.",
+	}, []string{"function"})
+)
+
+// metricsRegistry holds the chaincode metrics served by the operations server
+var metricsRegistry = prometheus.NewRegistry()
+
+func init() {
+	metricsRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		invocationsTotal,
+		errorsTotal,
+		invocationDuration,
+		stateReadsTotal,
+		stateWritesTotal,
+	)
+}
+
+// instrumentedChaincode records the metrics and logs the outcome of every invocation of the wrapped chaincode
+type instrumentedChaincode struct {
+	shim.Chaincode
+}
+
+// Init records the metrics and logs the outcome of the chaincode initialisation
+func (c *instrumentedChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return c.observe(stub, c.Chaincode.Init)
+}
+
+// Invoke records the metrics and logs the outcome of a chaincode invocation
+func (c *instrumentedChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	return c.observe(stub, c.Chaincode.Invoke)
+}
+
+func (c *instrumentedChaincode) observe(stub shim.ChaincodeStubInterface, call func(shim.ChaincodeStubInterface) peer.Response) peer.Response {
+	function := metricsFunction(stub)
+
+	if !invocations.start() {
+		return shim.Error("chaincode server is shutting down")
+	}
+	defer invocations.done()
+
+	start := time.Now()
+
+	response := call(&instrumentedStub{ChaincodeStubInterface: stub, function: function})
+	duration := time.Since(start)
+
+	invocationsTotal.WithLabelValues(function).Inc()
+	invocationDuration.WithLabelValues(function).Observe(duration.Seconds())
+
+	fields := logFields{
+		"txId":       stub.GetTxID(),
+		"channel":    stub.GetChannelID(),
+		"function":   function,
+		"status":     response.Status,
+		"durationMs": duration.Milliseconds(),
+	}
+
+	if response.Status >= shim.ERRORTHRESHOLD {
+		errorsTotal.WithLabelValues(function).Inc()
+		fields["error"] = response.Message
+	}
+
+	logInfo("invocation completed", fields)
+
+	return response
+}
+
+// metricsFunction returns the function label of an invocation, with its contract namespace
+// so functions of the same name in different contracts are told apart
+func metricsFunction(stub shim.ChaincodeStubInterface) string {
+	function, _ := stub.GetFunctionAndParameters()
+	if function == "" {
+		return "none"
+	}
+
+	// Functions of the default contract may be called with or without namespace
+	if !strings.Contains(function, ":") {
+		function = "PointsContract:" + function
+	}
+
+	return function
+}
+
+// instrumentedStub counts the state reads and writes made by a function
+type instrumentedStub struct {
+	shim.ChaincodeStubInterface
+	function string
+}
+
+func (s *instrumentedStub) read() {
+	stateReadsTotal.WithLabelValues(s.function).Inc()
+}
+
+func (s *instrumentedStub) write() {
+	stateWritesTotal.WithLabelValues(s.function).Inc()
+}
+
+func (s *instrumentedStub) GetState(key string) ([]byte, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetState(key)
+}
+
+func (s *instrumentedStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetStateByRange(startKey, endKey)
+}
+
+func (s *instrumentedStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+}
+
+func (s *instrumentedStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetStateByPartialCompositeKey(objectType, keys)
+}
+
+func (s *instrumentedStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetStateByPartialCompositeKeyWithPagination(objectType, keys, pageSize, bookmark)
+}
+
+func (s *instrumentedStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetQueryResult(query)
+}
+
+func (s *instrumentedStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetHistoryForKey(key)
+}
+
+func (s *instrumentedStub) GetPrivateData(collection, key string) ([]byte, error) {
+	s.read()
+	return s.ChaincodeStubInterface.GetPrivateData(collection, key)
+}
+
+func (s *instrumentedStub) PutState(key string, value []byte) error {
+	s.write()
+	return s.ChaincodeStubInterface.PutState(key, value)
+}
+
+func (s *instrumentedStub) DelState(key string) error {
+	s.write()
+	return s.ChaincodeStubInterface.DelState(key)
+}
+
+func (s *instrumentedStub) PutPrivateData(collection string, key string, value []byte) error {
+	s.write()
+	return s.ChaincodeStubInterface.PutPrivateData(collection, key, value)
+}
+
+func (s *instrumentedStub) DelPrivateData(collection, key string) error {
+	s.write()
+	return s.ChaincodeStubInterface.DelPrivateData(collection, key)
+}
@@ -0,0 +1,60 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// stateChaincode reads and writes one key, and fails when invoked with "Fail"
+type stateChaincode struct{}
+
+func (c *stateChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (c *stateChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, _ := stub.GetFunctionAndParameters()
+
+	_, err := stub.GetState("key")
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	err = stub.PutState("key", []byte("value"))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if function == "Fail" {
+		return shim.Error("failed")
+	}
+
+	return shim.Success(nil)
+}
+
+func TestInstrumentedChaincode(t *testing.T) {
+	stub := shimtest.NewMockStub("metrics", &instrumentedChaincode{Chaincode: new(stateChaincode)})
+
+	response := stub.MockInvoke("tx1", [][]byte{[]byte("MerchantContract:Succeed")})
+	require.Equal(t, int32(shim.OK), response.Status)
+
+	response = stub.MockInvoke("tx2", [][]byte{[]byte("Fail")})
+	require.Equal(t, int32(shim.ERROR), response.Status)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(invocationsTotal.WithLabelValues("MerchantContract:Succeed")))
+	require.Equal(t, 0.0, testutil.ToFloat64(errorsTotal.WithLabelValues("MerchantContract:Succeed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(stateReadsTotal.WithLabelValues("MerchantContract:Succeed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(stateWritesTotal.WithLabelValues("MerchantContract:Succeed")))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(invocationsTotal.WithLabelValues("PointsContract:Fail")), "functions of the default contract are namespaced")
+	require.Equal(t, 1.0, testutil.ToFloat64(errorsTotal.WithLabelValues("PointsContract:Fail")))
+}
@@ -0,0 +1,55 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "encoding/json"
+
+// MigrateFlatKeys moves up to limit members stored under their raw ID to namespaced
+// member keys. It returns the number of migrated members, call it again until it returns 0.
+func (s *AdminContract) MigrateFlatKeys(ctx TransactionContext, limit int) (int, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= 0 {
+		return 0, newError(ErrInvalidArgument, "limit must be positive")
+	}
+
+	// Composite keys are not returned by range queries, so only flat keys are scanned
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	migrated := 0
+	for migrated < limit && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		var member Member
+		err = json.Unmarshal(queryResponse.Value, &member)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to unmarshal %s. %s", queryResponse.Key, err.Error())
+		}
+
+		err = putMember(ctx, &member)
+		if err != nil {
+			return 0, err
+		}
+
+		err = ctx.GetStub().DelState(queryResponse.Key)
+		if err != nil {
+			return 0, newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		migrated++
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,52 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateFlatKeys(t *testing.T) {
+	env := newTestEnv(t)
+	for _, member := range []Member{{ID: "m1", MerchantPoints: map[string]int{}}, {ID: "alice", Merchant: "m1", Points: 10, MerchantPoints: map[string]int{"m1": 10}}} {
+		memberAsBytes, err := json.Marshal(member)
+		require.NoError(t, err)
+		env.stub.startTransaction("flat")
+		require.NoError(t, env.stub.PutState(member.ID, memberAsBytes))
+	}
+
+	require.Equal(t, 10, env.balance("alice", "m1"), "flat members are read until they are migrated")
+
+	members, err := env.points.GetAllMembers(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	_, err = env.admin.MigrateFlatKeys(env.ctx(merchantIdentity), 10)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.MigrateFlatKeys(env.ctx(adminIdentity), 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	migrated, err := env.admin.MigrateFlatKeys(env.ctx(adminIdentity), 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+
+	migrated, err = env.admin.MigrateFlatKeys(env.ctx(adminIdentity), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+
+	migrated, err = env.admin.MigrateFlatKeys(env.ctx(adminIdentity), 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, migrated)
+
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	members, err = env.points.GetAllMembers(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+}
@@ -0,0 +1,347 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// mockStub is an in-memory Stub holding the world state and private data of a test, the
+// proposal of the current transaction and the chaincodes it may invoke. Its queries behave as
+// those of Fabric. A method named in errs fails with that error instead, so tests can check how
+// the contracts surface failures of the ledger.
+type mockStub struct {
+	state      map[string][]byte
+	private    map[string]map[string][]byte
+	validation map[string][]byte
+	chaincodes map[string]func(args [][]byte) peer.Response
+	errs       map[string]error
+
+	txID      string
+	channelID string
+	now       time.Time
+	args      []string
+	transient map[string][]byte
+	event     *peer.ChaincodeEvent
+}
+
+func newMockStub(channelID string, now time.Time) *mockStub {
+	return &mockStub{
+		state:      map[string][]byte{},
+		private:    map[string]map[string][]byte{},
+		validation: map[string][]byte{},
+		chaincodes: map[string]func(args [][]byte) peer.Response{},
+		errs:       map[string]error{},
+		channelID:  channelID,
+		now:        now,
+		transient:  map[string][]byte{},
+	}
+}
+
+// startTransaction starts a new transaction with a fresh proposal
+func (s *mockStub) startTransaction(txID string) {
+	s.txID = txID
+	s.args = nil
+	s.transient = map[string][]byte{}
+	s.event = nil
+}
+
+// failWith makes the named method fail with err, a nil err makes it succeed again
+func (s *mockStub) failWith(method string, err error) {
+	if err == nil {
+		delete(s.errs, method)
+		return
+	}
+
+	s.errs[method] = err
+}
+
+// GetFunctionAndParameters returns the function and parameters invoked by the test
+func (s *mockStub) GetFunctionAndParameters() (string, []string) {
+	if len(s.args) == 0 {
+		return "", []string{}
+	}
+
+	return s.args[0], s.args[1:]
+}
+
+func (s *mockStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *mockStub) GetChannelID() string {
+	return s.channelID
+}
+
+func (s *mockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if err := s.errs["GetTxTimestamp"]; err != nil {
+		return nil, err
+	}
+
+	return &timestamp.Timestamp{Seconds: s.now.Unix()}, nil
+}
+
+func (s *mockStub) GetTransient() (map[string][]byte, error) {
+	if err := s.errs["GetTransient"]; err != nil {
+		return nil, err
+	}
+
+	return s.transient, nil
+}
+
+// InvokeChaincode calls a chaincode registered in chaincodes, an empty channel being the
+// channel of the transaction
+func (s *mockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	if err := s.errs["InvokeChaincode"]; err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if channel != "" && channel != s.channelID {
+		chaincodeName = chaincodeName + "/" + channel
+	}
+
+	chaincode, ok := s.chaincodes[chaincodeName]
+	if !ok {
+		return shim.Error(fmt.Sprintf("chaincode %s is not installed", chaincodeName))
+	}
+
+	return chaincode(args)
+}
+
+func (s *mockStub) GetState(key string) ([]byte, error) {
+	if err := s.errs["GetState"]; err != nil {
+		return nil, err
+	}
+
+	return s.state[key], nil
+}
+
+// PutState writes a key in the current transaction, an empty value deletes it as Fabric does
+func (s *mockStub) PutState(key string, value []byte) error {
+	if err := s.errs["PutState"]; err != nil {
+		return err
+	}
+
+	if s.txID == "" {
+		return fmt.Errorf("cannot put %s outside of a transaction", key)
+	}
+
+	if len(value) == 0 {
+		delete(s.state, key)
+		return nil
+	}
+
+	s.state[key] = value
+	return nil
+}
+
+func (s *mockStub) DelState(key string) error {
+	if err := s.errs["DelState"]; err != nil {
+		return err
+	}
+
+	delete(s.state, key)
+	return nil
+}
+
+// GetStateByRange leaves out composite keys when the range starts at "" and reads to the last
+// key when it ends at "", as Fabric does
+func (s *mockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if err := s.errs["GetStateByRange"]; err != nil {
+		return nil, err
+	}
+
+	if startKey == "" {
+		startKey = "\x01"
+	}
+
+	if endKey == "" {
+		endKey = string(utf8.MaxRune)
+	}
+
+	return s.iterator(startKey, endKey), nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if err := s.errs["GetStateByPartialCompositeKey"]; err != nil {
+		return nil, err
+	}
+
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.iterator(prefix, prefix+string(utf8.MaxRune)), nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination pages through the keys as Fabric does, the
+// bookmark is the first key of the next page
+func (s *mockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.errs["GetStateByPartialCompositeKeyWithPagination"]; err != nil {
+		return nil, nil, err
+	}
+
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	startKey := prefix
+	if bookmark > startKey {
+		startKey = bookmark
+	}
+
+	iterator := s.iterator(startKey, prefix+string(utf8.MaxRune))
+
+	next := ""
+	if int32(len(iterator.records)) > pageSize {
+		next = iterator.records[pageSize].Key
+		iterator.records = iterator.records[:pageSize]
+	}
+
+	metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(iterator.records)), Bookmark: next}
+	return iterator, metadata, nil
+}
+
+func (s *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := s.errs["CreateCompositeKey"]; err != nil {
+		return "", err
+	}
+
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *mockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if err := s.errs["SplitCompositeKey"]; err != nil {
+		return "", nil, err
+	}
+
+	if !strings.HasPrefix(compositeKey, "\x00") || !strings.HasSuffix(compositeKey, "\x00") {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+
+	components := strings.Split(compositeKey[1:len(compositeKey)-1], "\x00")
+	return components[0], components[1:], nil
+}
+
+func (s *mockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	if err := s.errs["GetStateValidationParameter"]; err != nil {
+		return nil, err
+	}
+
+	return s.validation[key], nil
+}
+
+func (s *mockStub) SetStateValidationParameter(key string, ep []byte) error {
+	if err := s.errs["SetStateValidationParameter"]; err != nil {
+		return err
+	}
+
+	s.validation[key] = ep
+	return nil
+}
+
+func (s *mockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if err := s.errs["GetPrivateData"]; err != nil {
+		return nil, err
+	}
+
+	return s.private[collection][key], nil
+}
+
+func (s *mockStub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := s.errs["PutPrivateData"]; err != nil {
+		return err
+	}
+
+	if s.private[collection] == nil {
+		s.private[collection] = map[string][]byte{}
+	}
+
+	s.private[collection][key] = value
+	return nil
+}
+
+func (s *mockStub) SetEvent(name string, payload []byte) error {
+	if err := s.errs["SetEvent"]; err != nil {
+		return err
+	}
+
+	s.event = &peer.ChaincodeEvent{EventName: name, Payload: payload}
+	return nil
+}
+
+// iterator returns the records of the keys from startKey until endKey exclusive, in key order
+func (s *mockStub) iterator(startKey string, endKey string) *mockIterator {
+	keys := []string{}
+	for key := range s.state {
+		if key >= startKey && key < endKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	records := []*queryresult.KV{}
+	for _, key := range keys {
+		records = append(records, &queryresult.KV{Key: key, Value: s.state[key]})
+	}
+
+	return &mockIterator{records: records, err: s.errs["Next"]}
+}
+
+// mockIterator iterates over the records read by a query, failing on Next with err if set
+type mockIterator struct {
+	records []*queryresult.KV
+	err     error
+}
+
+func (i *mockIterator) HasNext() bool {
+	return len(i.records) > 0
+}
+
+func (i *mockIterator) Next() (*queryresult.KV, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+
+	record := i.records[0]
+	i.records = i.records[1:]
+	return record, nil
+}
+
+func (i *mockIterator) Close() error {
+	return nil
+}
+
+// mockContext is the TransactionContext of a test, built over a mockStub instead of by
+// contractapi, which collects the events of the transaction
+type mockContext struct {
+	stub     Stub
+	identity ClientIdentity
+	events   []Event
+}
+
+func (ctx *mockContext) GetStub() Stub {
+	return ctx.stub
+}
+
+func (ctx *mockContext) GetClientIdentity() ClientIdentity {
+	return ctx.identity
+}
+
+func (ctx *mockContext) collectEvent(event Event) []Event {
+	ctx.events = append(ctx.events, event)
+	return ctx.events
+}
@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// readinessTimeout bounds how long the readiness probe waits for the chaincode server
+const readinessTimeout = time.Second
+
+// startOperationsServer serves the liveness and readiness probes and, if metrics is set, the metrics
+// of the chaincode process on address. The process is ready once the chaincode server accepts
+// connections on chaincodeAddress, until it starts shutting down.
+func startOperationsServer(address string, chaincodeAddress string, metrics bool) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if invocations.isDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("chaincode server is shutting down"))
+			return
+		}
+
+		conn, err := net.DialTimeout("tcp", chaincodeAddress, readinessTimeout)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("chaincode server is not accepting connections"))
+			return
+		}
+		conn.Close()
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	if metrics {
+		mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	}
+
+	go func() {
+		err := http.ListenAndServe(address, mux)
+		if err != nil {
+			logPanic("error starting operations server", logFields{"address": address, "error": err})
+		}
+	}()
+}
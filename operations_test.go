@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddress returns a local address no server listens on
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+// getProbe requests a probe of the operations server, waiting for the server to start
+func getProbe(t *testing.T, url string) (int, string) {
+	var response *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		response, err = http.Get(url)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestOperationsServer(t *testing.T) {
+	chaincode, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer chaincode.Close()
+
+	address := freeAddress(t)
+	startOperationsServer(address, chaincode.Addr().String(), true)
+
+	status, body := getProbe(t, "http://"+address+"/healthz")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "OK", body)
+
+	status, _ = getProbe(t, "http://"+address+"/readyz")
+	require.Equal(t, http.StatusOK, status)
+
+	status, body = getProbe(t, "http://"+address+"/metrics")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "go_goroutines")
+}
+
+func TestOperationsServerNotReady(t *testing.T) {
+	address := freeAddress(t)
+	startOperationsServer(address, freeAddress(t), true)
+
+	status, body := getProbe(t, "http://"+address+"/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "chaincode server is not accepting connections", body)
+
+	status, _ = getProbe(t, "http://"+address+"/healthz")
+	require.Equal(t, http.StatusOK, status, "a process waiting for its chaincode server is alive")
+}
+
+func TestOperationsServerWithoutMetrics(t *testing.T) {
+	address := freeAddress(t)
+	startOperationsServer(address, freeAddress(t), false)
+
+	status, _ := getProbe(t, "http://"+address+"/metrics")
+	require.Equal(t, http.StatusNotFound, status)
+}
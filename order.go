@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+// orderObjectType indexes the transaction which rewarded an order, keyed by the issuing
+// merchant and order ID
+const orderObjectType = "order"
+
+// isOrderReward reports whether the transaction awards points of its merchant for an order.
+// Merchants only issue their own points, so the sender of a reward is its merchant.
+func isOrderReward(transaction *PointsTransaction) bool {
+	return transaction.Source != nil && transaction.Source.Type == TypeOrder && transaction.Source.ID != "" &&
+		transaction.Sender == transaction.Merchant
+}
+
+// orderRewardKey returns the attributes of the order index entry of a reward, the
+// merchant which sent the points and the order ID
+func orderRewardKey(transaction *PointsTransaction) []string {
+	return []string{transaction.Sender, transaction.Source.ID}
+}
+
+// assertOrderNotRewarded checks that no points were awarded yet for the order of the transaction
+func assertOrderNotRewarded(ctx TransactionContext, transaction *PointsTransaction) error {
+	var rewardedBy string
+	found, err := getCompositeObject(ctx, orderObjectType, orderRewardKey(transaction), &rewardedBy)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return newError(ErrAlreadyExists, "order %s of merchant %s was already rewarded by transaction %s", transaction.Source.ID, transaction.Sender, rewardedBy).
+			WithDetail("transaction", rewardedBy)
+	}
+
+	return nil
+}
+
+// putOrderReward records the transaction as the reward of its order
+func putOrderReward(ctx TransactionContext, transaction *PointsTransaction) error {
+	return putCompositeObject(ctx, orderObjectType, orderRewardKey(transaction), transaction.ID)
+}
+
+// GetOrderReward returns the ID of the transaction which rewarded an order of a merchant
+func (s *PointsContract) GetOrderReward(ctx TransactionContext, merchant string, orderID string) (string, error) {
+	var rewardedBy string
+	found, err := getCompositeObject(ctx, orderObjectType, []string{merchant, orderID}, &rewardedBy)
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", newError(ErrNotFound, "order %s of merchant %s was not rewarded", orderID, merchant)
+	}
+
+	return rewardedBy, nil
+}
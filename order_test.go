@@ -0,0 +1,54 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrderReward(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	id := env.reward("m1", "alice", 10)
+
+	rewardedBy, err := env.points.GetOrderReward(env.ctx(adminIdentity), "m1", id)
+	require.NoError(t, err)
+	require.Equal(t, id, rewardedBy)
+
+	_, err = env.points.GetOrderReward(env.ctx(adminIdentity), "m1", "o-unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestCreateTransactionRejectsRewardedOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	require.NoError(t, err)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrAlreadyExists)
+	require.Equal(t, "t1", err.(*ContractError).Details["transaction"])
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t3", "m1", "bob", 10, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err)
+}
+
+func TestOrderRewardKeyedOnIssuingMerchant(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 10, "m1", "", TypeOrder, "o1")
+	require.NoError(t, err)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 10, "m2", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+	require.Equal(t, 10, env.balance("alice", "m1"))
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t3", "m2", "alice", 10, "m2", "", TypeOrder, "o1")
+	require.NoError(t, err, "order IDs are scoped by the issuing merchant")
+}
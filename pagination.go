@@ -0,0 +1,38 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "github.com/hyperledger/fabric-protos-go/peer"
+
+// PageInfo describes a page of a paginated query, embedded in the page of each record type
+type PageInfo struct {
+	FetchedRecordsCount int32 `json:"fetchedRecordsCount"`
+	// Bookmark is passed to the query to fetch the next page, it is empty on the last page
+	Bookmark string `json:"bookmark"`
+	HasMore  bool   `json:"hasMore"`
+}
+
+// compositeKeyPageInfo returns the PageInfo of a page of a partial composite key query. Fabric
+// returns a bookmark after every full page, so whether more pages exist is checked by fetching
+// the first record of the next page.
+func compositeKeyPageInfo(ctx TransactionContext, objectType string, attributes []string, pageSize int32, metadata *peer.QueryResponseMetadata) (PageInfo, error) {
+	info := PageInfo{FetchedRecordsCount: metadata.FetchedRecordsCount}
+	if metadata.FetchedRecordsCount < pageSize || metadata.Bookmark == "" {
+		return info, nil
+	}
+
+	resultsIterator, _, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(objectType, attributes, 1, metadata.Bookmark)
+	if err != nil {
+		return PageInfo{}, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	if resultsIterator.HasNext() {
+		info.Bookmark = metadata.Bookmark
+		info.HasMore = true
+	}
+
+	return info, nil
+}
@@ -0,0 +1,91 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	configObjectType = "config"
+	pauseConfigID    = "pause"
+)
+
+// PauseState records whether the contract is halted and by whom
+type PauseState struct {
+	Schema
+	Paused   bool   `json:"paused"`
+	Reason   string `json:"reason"`
+	PausedBy string `json:"pausedBy"`
+	PausedAt string `json:"pausedAt"`
+}
+
+// Pause halts all writes to the contract, queries are still allowed
+func (s *AdminContract) Pause(ctx TransactionContext, reason string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := PauseState{
+		Paused:   true,
+		Reason:   reason,
+		PausedBy: clientID,
+		PausedAt: now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, configObjectType, pauseConfigID, &state)
+}
+
+// Unpause resumes writes to the contract
+func (s *AdminContract) Unpause(ctx TransactionContext) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, configObjectType, pauseConfigID, &PauseState{})
+}
+
+// GetPauseState returns whether the contract is currently paused
+func (s *AdminContract) GetPauseState(ctx TransactionContext) (*PauseState, error) {
+	return getPauseState(ctx)
+}
+
+func getPauseState(ctx TransactionContext) (*PauseState, error) {
+	var state PauseState
+	_, err := getObject(ctx, configObjectType, pauseConfigID, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// assertNotPaused rejects every mutating function while the contract is paused
+func assertNotPaused(ctx TransactionContext, function string) error {
+	if isQuery(function) || function == "Pause" || function == "Unpause" {
+		return nil
+	}
+
+	state, err := getPauseState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if state.Paused {
+		return newError(ErrInvalidState, "contract is paused, %s is not allowed", function)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPause(t *testing.T) {
+	env := newTestEnv(t)
+
+	err := env.admin.Pause(env.ctx(merchantIdentity), "incident")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.Pause(env.ctx(adminIdentity), "incident"))
+
+	state, err := env.admin.GetPauseState(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.True(t, state.Paused)
+	require.Equal(t, "incident", state.Reason)
+	require.Equal(t, "admin", state.PausedBy)
+
+	ctx := env.ctx(merchantIdentity)
+	requireErrorCode(t, assertNotPaused(ctx, "CreateTransaction"), ErrInvalidState)
+	require.NoError(t, assertNotPaused(ctx, "GetMember"), "queries are allowed while paused")
+	require.NoError(t, assertNotPaused(ctx, "Unpause"))
+
+	err = env.admin.Unpause(env.ctx(merchantIdentity))
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.admin.Unpause(env.ctx(adminIdentity)))
+	require.NoError(t, assertNotPaused(env.ctx(merchantIdentity), "CreateTransaction"))
+}
@@ -0,0 +1,106 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+const paymentsConfigID = "payments"
+
+// PaymentsIntegration names the payments chaincode called when points are redeemed. The
+// integration is stored in the world state rather than in the process configuration, so that
+// every endorsing peer calls the same chaincode.
+type PaymentsIntegration struct {
+	Schema
+	// Chaincode is the name of the payments chaincode on the same channel, "" if disabled
+	Chaincode string `json:"chaincode"`
+	// Function is called with the transaction ID, order reference, merchant, owner and value
+	Function  string `json:"function"`
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// SetPaymentsIntegration sets the chaincode and function which record the discount of every
+// redemption. An empty chaincode disables the integration.
+func (s *AdminContract) SetPaymentsIntegration(ctx TransactionContext, chaincode string, function string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if chaincode != "" && function == "" {
+		return newError(ErrInvalidArgument, "function of payments chaincode %s must not be empty", chaincode)
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	integration := PaymentsIntegration{
+		Chaincode: chaincode,
+		Function:  function,
+		UpdatedBy: clientID,
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+
+	return putObject(ctx, configObjectType, paymentsConfigID, &integration)
+}
+
+// GetPaymentsIntegration returns the payments chaincode called on redemptions
+func (s *AdminContract) GetPaymentsIntegration(ctx TransactionContext) (*PaymentsIntegration, error) {
+	return getPaymentsIntegration(ctx)
+}
+
+func getPaymentsIntegration(ctx TransactionContext) (*PaymentsIntegration, error) {
+	var integration PaymentsIntegration
+	_, err := getObject(ctx, configObjectType, paymentsConfigID, &integration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &integration, nil
+}
+
+// settlePayment records the discount of a redemption in the payments chaincode. The call is
+// part of the same transaction, so a failure of the payments chaincode fails the redemption.
+func settlePayment(ctx TransactionContext, transaction *PointsTransaction, reference string) error {
+	integration, err := getPaymentsIntegration(ctx)
+	if err != nil {
+		return err
+	}
+
+	if integration.Chaincode == "" {
+		return nil
+	}
+
+	args := [][]byte{
+		[]byte(integration.Function),
+		[]byte(transaction.ID),
+		[]byte(reference),
+		[]byte(transaction.Merchant),
+		[]byte(transaction.Sender),
+		[]byte(strconv.Itoa(transaction.Value)),
+	}
+
+	// An empty channel name calls the chaincode on the channel of this transaction
+	response := ctx.GetStub().InvokeChaincode(integration.Chaincode, args, "")
+	if response.Status >= shim.ERRORTHRESHOLD {
+		return newError(ErrPaymentFailed, "payments chaincode %s failed to settle %s. %s", integration.Chaincode, transaction.ID, response.Message).
+			WithDetail("chaincode", integration.Chaincode).
+			WithDetail("status", strconv.Itoa(int(response.Status)))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// testPayments is a payments chaincode which records the settled values and fails above a limit
+type testPayments struct {
+	limit   int
+	settled []int
+}
+
+func (p *testPayments) settle(args [][]byte) peer.Response {
+	value, err := strconv.Atoi(string(args[len(args)-1]))
+	if err != nil || value > p.limit {
+		return shim.Error("payment declined")
+	}
+
+	p.settled = append(p.settled, value)
+	return shim.Success(nil)
+}
+
+func TestSetPaymentsIntegration(t *testing.T) {
+	env := newTestEnv(t)
+
+	integration, err := env.admin.GetPaymentsIntegration(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Empty(t, integration.Chaincode)
+
+	err = env.admin.SetPaymentsIntegration(env.ctx(merchantIdentity), "payments", "Settle")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.SetPaymentsIntegration(env.ctx(adminIdentity), "payments", "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.admin.SetPaymentsIntegration(env.ctx(adminIdentity), "payments", "Settle"))
+
+	integration, err = env.admin.GetPaymentsIntegration(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Equal(t, "payments", integration.Chaincode)
+	require.Equal(t, "Settle", integration.Function)
+}
+
+func TestCapturePointsSettlesPayment(t *testing.T) {
+	env := newTestEnv(t)
+	payments := &testPayments{limit: 50}
+	env.stub.chaincodes["payments"] = payments.settle
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	require.NoError(t, env.admin.SetPaymentsIntegration(env.ctx(adminIdentity), "payments", "Settle"))
+
+	id, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 600)
+	require.NoError(t, err)
+	require.NoError(t, env.points.CapturePoints(env.ctx(merchantIdentity), id))
+	require.Equal(t, []int{40}, payments.settled)
+
+	id, err = env.points.HoldPoints(env.ctx(alice), "alice", 60, "checkout2", 600)
+	require.NoError(t, err)
+
+	err = env.points.CapturePoints(env.ctx(merchantIdentity), id)
+	requireErrorCode(t, err, ErrPaymentFailed)
+}
@@ -0,0 +1,340 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// Point types issued by default by every merchant
+const (
+	PointTypeStandard = "standard"
+	PointTypePremium  = "premium"
+	PointTypePromo    = "promo"
+)
+
+func defaultPointTypes() map[string]PointTypeRule {
+	return map[string]PointTypeRule{
+		PointTypeStandard: {},
+		PointTypePremium:  {MinRedemption: 100},
+		PointTypePromo:    {ExpiryDays: 30},
+	}
+}
+
+// SetPointTypeRule adds or changes a class of points of a merchant
+func (s *MerchantContract) SetPointTypeRule(ctx TransactionContext, merchantID string, pointType string, minRedemption int, maxRedemption int, expiryDays int) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if minRedemption < 0 || maxRedemption < 0 || expiryDays < 0 {
+		return newError(ErrInvalidArgument, "point type rules must not be negative")
+	}
+
+	if maxRedemption > 0 && maxRedemption < minRedemption {
+		return newError(ErrInvalidArgument, "maximum redemption must not be lower than minimum redemption")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if merchant.PointTypes == nil {
+		merchant.PointTypes = map[string]PointTypeRule{}
+	}
+
+	merchant.PointTypes[pointType] = PointTypeRule{
+		MinRedemption: minRedemption,
+		MaxRedemption: maxRedemption,
+		ExpiryDays:    expiryDays,
+	}
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// IssuePoints credits value points of pointType of a merchant to a customer and returns the key
+// of the transaction, or of the transaction of an earlier submission with the same idempotency token
+func (s *PointsContract) IssuePoints(ctx TransactionContext, id string, merchantID string, owner string, pointType string, value int) (string, error) {
+	return idempotentCreate(ctx, func() (string, error) {
+		return id, issuePoints(ctx, id, merchantID, owner, pointType, value)
+	})
+}
+
+func issuePoints(ctx TransactionContext, id string, merchantID string, owner string, pointType string, value int) error {
+	_, err := getPointTypeRule(ctx, merchantID, pointType)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantMSP(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := newTypedTransaction(ctx, id, merchantID, merchantID, owner, value, pointType, TypeIssue)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := createMember(ctx, merchantID, merchantID)
+	if err != nil {
+		return err
+	}
+
+	customer, err := createMember(ctx, owner, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if customer.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot receive points", owner)
+	}
+
+	err = assertNotFrozen(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	pending, err := holdForApproval(ctx, transaction)
+	if err != nil || pending {
+		return err
+	}
+
+	customer.Points += value
+	customer.MerchantPoints[merchantID] += value
+	addTypedPoints(customer, merchantID, pointType, value)
+	customer.Transaction = transaction
+
+	merchant.Points += value
+
+	// Set before the tier is updated so that a TierChanged event replaces it
+	err = emitEvent(ctx, pointIssuedEvent, &PointIssuedEvent{Transaction: id, Merchant: merchantID, Owner: owner, PointType: pointType, Value: value})
+	if err != nil {
+		return err
+	}
+
+	err = recordEarnedPoints(ctx, owner, merchantID, value)
+	if err != nil {
+		return err
+	}
+
+	err = recordVelocity(ctx, owner, value, 0)
+	if err != nil {
+		return err
+	}
+
+	err = recordSettlement(ctx, merchantID, settlementIssued, transaction.ID, value)
+	if err != nil {
+		return err
+	}
+
+	err = recordPointLot(ctx, transaction, owner, merchantID, value)
+	if err != nil {
+		return err
+	}
+
+	err = updateProgramStats(ctx, merchantID, programIssued, value)
+	if err != nil {
+		return err
+	}
+
+	return putTypedTransaction(ctx, transaction, customer, merchant)
+}
+
+// RedeemPoints debits value points of pointType of a merchant from a customer, checking the
+// redemption rules of the point type. It returns the key of the transaction, or of the
+// transaction of an earlier submission with the same idempotency token.
+func (s *PointsContract) RedeemPoints(ctx TransactionContext, id string, owner string, merchantID string, pointType string, value int) (string, error) {
+	return idempotentCreate(ctx, func() (string, error) {
+		return id, redeemPoints(ctx, id, owner, merchantID, pointType, value)
+	})
+}
+
+func redeemPoints(ctx TransactionContext, id string, owner string, merchantID string, pointType string, value int) error {
+	rule, err := getPointTypeRule(ctx, merchantID, pointType)
+	if err != nil {
+		return err
+	}
+
+	if value < rule.MinRedemption {
+		return newError(ErrInvalidArgument, "at least %d %s points must be redeemed", rule.MinRedemption, pointType)
+	}
+
+	if rule.MaxRedemption > 0 && value > rule.MaxRedemption {
+		return newError(ErrInvalidArgument, "at most %d %s points may be redeemed", rule.MaxRedemption, pointType)
+	}
+
+	err = assertAccountOwner(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := newTypedTransaction(ctx, id, merchantID, owner, merchantID, value, pointType, TypeRedemption)
+	if err != nil {
+		return err
+	}
+
+	customer, err := getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if customer.TypedPoints[merchantID][pointType] < value {
+		return newError(ErrInsufficientPoints, "%s does not have enough %s points", owner, pointType)
+	}
+
+	err = assertLifeCardEligible(ctx, customer)
+	if err != nil {
+		return err
+	}
+
+	merchant, err := createMember(ctx, merchantID, merchantID)
+	if err != nil {
+		return err
+	}
+
+	customer.Points -= value
+	customer.MerchantPoints[merchantID] -= value
+	addTypedPoints(customer, merchantID, pointType, -value)
+	customer.Transaction = transaction
+
+	merchant.Points -= value
+
+	err = recordSettlement(ctx, merchantID, settlementRedeemed, transaction.ID, value)
+	if err != nil {
+		return err
+	}
+
+	err = updateProgramStats(ctx, merchantID, programRedeemed, value)
+	if err != nil {
+		return err
+	}
+
+	// Redemptions are identified by the order they are made against
+	err = settlePayment(ctx, transaction, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	err = emitEvent(ctx, pointsRedeemedEvent, &PointsRedeemedEvent{Transaction: id, Merchant: merchantID, Owner: owner, PointType: pointType, Value: value})
+	if err != nil {
+		return err
+	}
+
+	return putTypedTransaction(ctx, transaction, customer, merchant)
+}
+
+// getPointTypeRule returns the rules of a point type of an active merchant
+func getPointTypeRule(ctx TransactionContext, merchantID string, pointType string) (*PointTypeRule, error) {
+	err := assertMerchantActive(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, ok := merchant.PointTypes[pointType]
+	if !ok {
+		return nil, newError(ErrNotFound, "merchant %s has no %s points", merchantID, pointType)
+	}
+
+	return &rule, nil
+}
+
+// newTypedTransaction returns a new confirmed transaction, checking its ID is not taken
+func newTypedTransaction(ctx TransactionContext, id string, merchantID string, sender string, receiver string, value int, pointType string, transactionType string) (*PointsTransaction, error) {
+	var existing PointsTransaction
+	exists, err := getObject(ctx, transactionObjectType, id, &existing)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, newError(ErrAlreadyExists, "transaction %s already exists", id)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PointsTransaction{
+		ID:        id,
+		Value:     value,
+		Merchant:  merchantID,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    sender,
+		Receiver:  receiver,
+		Source:    &Source{Type: transactionType},
+		Status:    StatusConfirmed,
+		PointType: pointType,
+	}, nil
+}
+
+func putTypedTransaction(ctx TransactionContext, transaction *PointsTransaction, members ...*Member) error {
+	for _, member := range members {
+		err := putMember(ctx, member)
+		if err != nil {
+			return err
+		}
+	}
+
+	return putTransaction(ctx, transaction)
+}
+
+func addTypedPoints(member *Member, merchantID string, pointType string, value int) {
+	if member.TypedPoints == nil {
+		member.TypedPoints = map[string]map[string]int{}
+	}
+
+	if member.TypedPoints[merchantID] == nil {
+		member.TypedPoints[merchantID] = map[string]int{}
+	}
+
+	member.TypedPoints[merchantID][pointType] += value
+}
+
+// typedPointsOf returns the points of a merchant a member holds as typed points
+func typedPointsOf(member *Member, merchantID string) int {
+	total := 0
+	for _, points := range member.TypedPoints[merchantID] {
+		total += points
+	}
+
+	return total
+}
+
+// untypedPoints returns the points of a member which were not issued as a point type,
+// typed points may only be spent through RedeemPoints
+func untypedPoints(member *Member) int {
+	points := member.Points
+	for merchantID := range member.TypedPoints {
+		points -= typedPointsOf(member, merchantID)
+	}
+
+	return points
+}
+
+// spendablePoints returns the untyped points a customer may spend at its own merchant: its
+// balance of the merchant less the typed points
+func spendablePoints(member *Member) int {
+	return member.MerchantPoints[member.Merchant] - typedPointsOf(member, member.Merchant)
+}
@@ -0,0 +1,84 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPointTypeRule(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	err := env.merchants.SetPointTypeRule(env.ctx(otherMSPIdentity), "m1", "gold", 0, 0, 0)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetPointTypeRule(env.ctx(merchantIdentity), "m1", "gold", 10, 5, 0)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.SetPointTypeRule(env.ctx(merchantIdentity), "unknown", "gold", 0, 0, 0)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.merchants.SetPointTypeRule(env.ctx(merchantIdentity), "m1", "gold", 10, 50, 90))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, PointTypeRule{MinRedemption: 10, MaxRedemption: 50, ExpiryDays: 90}, merchant.PointTypes["gold"])
+	require.Contains(t, merchant.PointTypes, PointTypeStandard)
+}
+
+func TestIssuePoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	_, err := env.points.IssuePoints(env.ctx(merchantIdentity), "i1", "m1", "alice", PointTypePremium, 150)
+	require.NoError(t, err)
+
+	var event PointIssuedEvent
+	env.requireEvent(pointIssuedEvent, &event)
+	require.Equal(t, PointTypePremium, event.PointType)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), "i1")
+	require.NoError(t, err)
+	require.Equal(t, PointTypePremium, transaction.PointType)
+
+	alice := env.member("alice")
+	require.Equal(t, 150, alice.MerchantPoints["m1"])
+	require.Equal(t, 150, alice.TypedPoints["m1"][PointTypePremium])
+
+	_, err = env.points.IssuePoints(env.ctx(merchantIdentity), "i1", "m1", "alice", PointTypePremium, 150)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	_, err = env.points.IssuePoints(env.ctx(otherMSPIdentity), "i2", "m1", "alice", PointTypePremium, 150)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.IssuePoints(env.ctx(merchantIdentity), "i3", "m1", "alice", "gold", 150)
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestRedeemPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	_, err := env.points.IssuePoints(env.ctx(merchantIdentity), "i1", "m1", "alice", PointTypePremium, 150)
+	require.NoError(t, err)
+	alice := env.registerAccount("alice")
+
+	_, err = env.points.RedeemPoints(env.ctx(alice), "r1", "alice", "m1", PointTypePremium, 99)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.RedeemPoints(env.ctx(alice), "r2", "alice", "m1", PointTypePremium, 151)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.RedeemPoints(env.ctx(customerIdentity("bob")), "r3", "alice", "m1", PointTypePremium, 100)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.RedeemPoints(env.ctx(alice), "r4", "alice", "m1", PointTypePremium, 100)
+	require.NoError(t, err)
+	env.requireEvent(pointsRedeemedEvent, nil)
+	require.Equal(t, 50, env.member("alice").TypedPoints["m1"][PointTypePremium])
+	require.Equal(t, 50, env.member("m1").Points)
+}
@@ -6,121 +6,206 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
+	"flag"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
-type serverConfig struct {
-	CCID    string
-	Address string
+const (
+	memberObjectType      = "member"
+	transactionObjectType = "transaction"
+)
+
+// PointsContract provides functions for members to earn, transfer and redeem points
+type PointsContract struct {
+	contractapi.Contract
+}
+
+// MerchantContract provides functions for merchants to administer their points program
+type MerchantContract struct {
+	contractapi.Contract
 }
 
-// SmartContract provides functions for managing an asset
-type SmartContract struct {
+// AdminContract provides functions for operators of the network
+type AdminContract struct {
 	contractapi.Contract
 }
 
 type Source struct {
 	Type string `json:"type"`
 	ID   string `json:"ID"`
+	// Stockist is the reseller which sold the order of an Order source, accruing commission on it
+	Stockist string `json:"stockist,omitempty" metadata:"stockist,optional"`
 }
 
 // Asset describes basic details of what makes up a simple asset
 type PointsTransaction struct {
-	ID 		   string  `json:"ID"`
-	Value     int     `json:"value"`
-	// Merchant   string  `json:"merchant"`
-	CreatedAt  string  `json:"created_at"`
-	Sender     string  `json:"sender"`
-	Receiver   string  `json:"receiver"`
-	Source     *Source `json:"source"`
+	Schema
+	ID           string   `json:"ID"`
+	Value        int      `json:"value"`
+	Merchant     string   `json:"merchant"`
+	CreatedAt    string   `json:"created_at"`
+	Sender       string   `json:"sender"`
+	Receiver     string   `json:"receiver"`
+	Source       *Source  `json:"source"`
+	Status       string   `json:"status"`
+	PointType    string   `json:"pointType,omitempty" metadata:"pointType,optional"`
+	Reason       string   `json:"reason,omitempty" metadata:"reason,optional"`
+	ReversedBy   string   `json:"reversedBy,omitempty" metadata:"reversedBy,optional"`
+	Approvals    []string `json:"approvals,omitempty" metadata:"approvals,optional"`
+	DocumentHash string   `json:"documentHash,omitempty" metadata:"documentHash,optional"`
+	DocumentURI  string   `json:"documentURI,omitempty" metadata:"documentURI,optional"`
+	Converted    int      `json:"converted,omitempty" metadata:"converted,optional"`
+	VoidedBy     string   `json:"voidedBy,omitempty" metadata:"voidedBy,optional"`
+	VoidedAt     string   `json:"voidedAt,omitempty" metadata:"voidedAt,optional"`
+	VoidReason   string   `json:"voidReason,omitempty" metadata:"voidReason,optional"`
 }
 
 type MerchantPoints struct {
-	ID   string 	`json:"ID"`
-	Value int 		`json:"value"`
+	ID    string `json:"ID"`
+	Value int    `json:"value"`
 }
 
 // Customer or Merchant
 type Member struct {
-	ID					string 				`json:"ID"`
-	Merchant   			string  			`json:"merchant"`
-	MerchantPoints  	map[string]int      `json:"merchantPoints"`
-	Points 				int 				`json:"points"`
-	Transaction 		*PointsTransaction 	`json:"transaction"`
+	Schema
+	ID             string                    `json:"ID"`
+	Merchant       string                    `json:"merchant"`
+	MerchantPoints map[string]int            `json:"merchantPoints"`
+	Points         int                       `json:"points"`
+	Transaction    *PointsTransaction        `json:"transaction"`
+	TypedPoints    map[string]map[string]int `json:"typedPoints,omitempty" metadata:"typedPoints,optional"`
+	Locale         string                    `json:"locale,omitempty" metadata:"locale,optional"`
+	// deltaKeys are the balance deltas added to the balances read, deleted when the member is written
+	deltaKeys []string
 }
 
+// InitLedger adds a base set of points transactions to the ledger. A LedgerSeed passed in
+// the ledger_seed transient field replaces the sample data.
+func (s *PointsContract) InitLedger(ctx TransactionContext) error {
+	seed, err := getLedgerSeed(ctx)
+	if err != nil {
+		return err
+	}
+
+	if seed != nil {
+		return seedLedger(ctx, seed)
+	}
 
-// InitLedger adds a base set of points transactions to the ledger
-func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	// transaction1 := PointsTransaction{
 	// 	ID: "12738647",
 	// 	Value: 1000,
-	// 	CreatedAt: "20210929",
-	// 	Sender: "zh-CN",
+	// 	CreatedAt: "2021-09-29T00:00:00Z",
+	// 	Sender: "merchant-cn",
 	// 	Receiver: "jin.xiaoming@ekohe.com",
 	// 	Source: &Source{Type: "Birthday"},
 	// }
 
 	transaction2 := PointsTransaction{
-		ID: "12738648",
-		Value: 500,
-		CreatedAt: "20211009",
-		Sender: "zh-TW",
-		Receiver: "maxime@ekohe.com",
-		Source: &Source{Type: "Order", ID: "737463747"},
+		ID:        "12738648",
+		Value:     500,
+		CreatedAt: "2021-10-09T00:00:00Z",
+		Sender:    "merchant-tw",
+		Receiver:  "maxime@ekohe.com",
+		Source:    &Source{Type: TypeOrder, ID: "737463747"},
 	}
 
 	transaction3 := PointsTransaction{
-		ID: "12738649",
-		Value: 800,
-		CreatedAt: "20211011",
-		Sender: "jin.xiaoming@ekohe.com",
-		Receiver: "zh-TW",
-		Source: &Source{Type: "Order", ID: "345342523"},
+		ID:        "12738649",
+		Value:     800,
+		CreatedAt: "2021-10-11T00:00:00Z",
+		Sender:    "jin.xiaoming@ekohe.com",
+		Receiver:  "merchant-tw",
+		Source:    &Source{Type: TypeOrder, ID: "345342523"},
 	}
 
-	
 	// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
 	members := []Member{
-		Member{ID: "zh-CN", Points: 1000, MerchantPoints: map[string]int{"zh-TW": 800}},
-		Member{ID: "zh-TW", Points: 500, MerchantPoints: map[string]int{"jp": 500}},
-		Member{ID: "jp", Points: 0, MerchantPoints: map[string]int{}},
-		Member{ID: "jin.xiaoming@ekohe.com", Merchant: "zh-CN", Points: 200, Transaction: &transaction3, MerchantPoints: map[string]int{"zh-CN": 1000, "zh-TW": -800}},
-		Member{ID: "maxime@ekohe.com", Merchant: "jp", Points: 500, Transaction: &transaction2, MerchantPoints: map[string]int{"zh-TW": 500}},
+		Member{ID: "merchant-cn", Points: 1000, MerchantPoints: map[string]int{"merchant-tw": 800}, Locale: "zh-CN"},
+		Member{ID: "merchant-tw", Points: 500, MerchantPoints: map[string]int{"merchant-jp": 500}, Locale: "zh-TW"},
+		Member{ID: "merchant-jp", Points: 0, MerchantPoints: map[string]int{}, Locale: "ja-JP"},
+		Member{ID: "jin.xiaoming@ekohe.com", Merchant: "merchant-cn", Points: 200, Transaction: &transaction3, MerchantPoints: map[string]int{"merchant-cn": 1000, "merchant-tw": -800}, Locale: "zh-CN"},
+		Member{ID: "maxime@ekohe.com", Merchant: "merchant-jp", Points: 500, Transaction: &transaction2, MerchantPoints: map[string]int{"merchant-tw": 500}, Locale: "ja-JP"},
 	}
 
-	for _, member := range members {
-		memberAsBytes, _ := json.Marshal(member)
-		err := ctx.GetStub().PutState(member.ID, memberAsBytes)
+	merchants := []SeedMerchant{
+		{ID: "merchant-cn", Name: "Merchant CN", MSP: "Org1MSP", Locale: "zh-CN"},
+		{ID: "merchant-tw", Name: "Merchant TW", MSP: "Org1MSP", Locale: "zh-TW"},
+		{ID: "merchant-jp", Name: "Merchant JP", MSP: "Org2MSP", Locale: "ja-JP"},
+	}
+
+	err = registerMerchants(ctx, merchants)
+	if err != nil {
+		return err
+	}
+
+	for i := range members {
+		err := putMember(ctx, &members[i])
 
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %s", err.Error())
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (s *SmartContract) GetMember(ctx contractapi.TransactionContextInterface, id string) (*Member, error) {
-	bytes, err := ctx.GetStub().GetState(id)
+func (s *PointsContract) GetMember(ctx TransactionContext, id string) (*Member, error) {
+	return getMember(ctx, id)
+}
 
+// getMember returns a member with the balance deltas credited to it since it was last written
+func getMember(ctx TransactionContext, id string) (*Member, error) {
+	member, err := getStoredMember(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read from world state. %s", err.Error())
+		return nil, err
+	}
+
+	err = applyBalanceDeltas(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// getStoredMember returns a member as stored, without its balance deltas
+func getStoredMember(ctx TransactionContext, id string) (*Member, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(memberObjectType, []string{id})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetState(key)
+
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
 	}
 
 	if bytes == nil {
-		return nil, fmt.Errorf("%s does not exist in world state", id)
+		// Members written before keys were namespaced are stored under their ID
+		bytes, err = ctx.GetStub().GetState(id)
+
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+	}
+
+	if bytes == nil {
+		return nil, newError(ErrNotFound, "%s does not exist in world state", id)
 	}
 
 	var member Member
-	err = json.Unmarshal(bytes, &member)
+	err = unmarshalState(memberObjectType, key, bytes, &member)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fillMemberLocale(ctx, &member)
 	if err != nil {
 		return nil, err
 	}
@@ -128,36 +213,176 @@ func (s *SmartContract) GetMember(ctx contractapi.TransactionContextInterface, i
 	return &member, nil
 }
 
-func (s *SmartContract) GetAllMerchants(ctx contractapi.TransactionContextInterface) ([]Member, error) {
+// putMember writes a member to the world state, consolidating the balance deltas it was read with
+func putMember(ctx TransactionContext, member *Member) error {
+	err := deleteBalanceDeltas(ctx, member)
+	if err != nil {
+		return err
+	}
+
+	member.setSchema(memberObjectType)
+	if member.Transaction != nil {
+		member.Transaction.setSchema(transactionObjectType)
+	}
+
+	memberAsBytes, err := json.Marshal(member)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s. %s", member.ID, err.Error())
+	}
+
+	memberAsBytes, err = encodeState(ctx, memberObjectType, memberAsBytes)
+	if err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(memberObjectType, []string{member.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().PutState(key, memberAsBytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// getObject reads the object stored under the composite key of objectType and id into v,
+// it returns false if no such object exists
+func getObject(ctx TransactionContext, objectType string, id string, v interface{}) (bool, error) {
+	return getCompositeObject(ctx, objectType, []string{id}, v)
+}
+
+// putObject writes v under the composite key of objectType and id
+func putObject(ctx TransactionContext, objectType string, id string, v interface{}) error {
+	return putCompositeObject(ctx, objectType, []string{id}, v)
+}
+
+// getCompositeObject reads the object stored under the composite key of objectType and
+// attributes into v, it returns false if no such object exists
+func getCompositeObject(ctx TransactionContext, objectType string, attributes []string, v interface{}) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return false, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	if bytes == nil {
+		return false, nil
+	}
+
+	err = unmarshalState(objectType, key, bytes, v)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// putCompositeObject writes v under the composite key of objectType and attributes
+func putCompositeObject(ctx TransactionContext, objectType string, attributes []string, v interface{}) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	if asset, ok := v.(versioned); ok {
+		asset.setSchema(objectType)
+	}
+
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s %v. %s", objectType, attributes, err.Error())
+	}
+
+	bytes, err = encodeState(ctx, objectType, bytes)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(key, bytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// txTime returns the transaction timestamp, which is the same on every endorsing peer
+func txTime(ctx TransactionContext) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, newError(ErrInternal, "failed to get transaction timestamp. %s", err.Error())
+	}
+
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
+}
+
+func (s *PointsContract) GetAllMerchants(ctx TransactionContext) ([]Member, error) {
 	return nil, nil
 }
 
-func (s *SmartContract) GetCustomersByMerchant(ctx contractapi.TransactionContextInterface, merchant string) ([]Member, error) {
+func (s *PointsContract) GetCustomersByMerchant(ctx TransactionContext, merchant string) ([]Member, error) {
 	return nil, nil
 }
 
-func (s *SmartContract) GetAllMembers(ctx contractapi.TransactionContextInterface) ([]Member, error) {
-	startKey := ""
-	endKey := ""
+func (s *PointsContract) GetAllMembers(ctx TransactionContext) ([]Member, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(memberObjectType, []string{})
 
-	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
 
+	results, err := readMembers(ctx, resultsIterator)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
 
+	// Members which have not been migrated to namespaced keys yet
+	legacyIterator, err := ctx.GetStub().GetStateByRange("", "")
+
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer legacyIterator.Close()
+
+	legacy, err := readMembers(ctx, legacyIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(results, legacy...), nil
+}
+
+// readMembers unmarshals all the members returned by a state iterator
+func readMembers(ctx TransactionContext, resultsIterator shim.StateQueryIteratorInterface) ([]Member, error) {
 	results := []Member{}
 
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 
 		if err != nil {
-			return nil, err
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
 		}
 
 		member := new(Member)
-		err = json.Unmarshal(queryResponse.Value, member)
+		err = unmarshalState(memberObjectType, queryResponse.Key, queryResponse.Value, member)
+		if err != nil {
+			return nil, err
+		}
+
+		err = fillMemberLocale(ctx, member)
+		if err != nil {
+			return nil, err
+		}
+
+		err = applyBalanceDeltas(ctx, member)
 		if err != nil {
 			return nil, err
 		}
@@ -169,11 +394,25 @@ func (s *SmartContract) GetAllMembers(ctx contractapi.TransactionContextInterfac
 	return results, nil
 }
 
-func (s *SmartContract) CreateMember(ctx contractapi.TransactionContextInterface, id string, merchant string) (*Member) {
-	member, _ := s.GetMember(ctx, id)
+// CreateMember returns the member with given id, creating it as a customer of merchant, or as
+// the member of the merchant itself when id is the merchant, if it does not exist yet
+func (s *PointsContract) CreateMember(ctx TransactionContext, id string, merchant string) (*Member, error) {
+	_, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	return createMember(ctx, id, merchant)
+}
+
+func createMember(ctx TransactionContext, id string, merchant string) (*Member, error) {
+	member, err := getMember(ctx, id)
+	if err == nil {
+		return member, nil
+	}
 
-	if member != nil {
-		return member
+	if !hasErrorCode(err, ErrNotFound) {
+		return nil, err
 	}
 
 	if id == merchant {
@@ -181,41 +420,192 @@ func (s *SmartContract) CreateMember(ctx contractapi.TransactionContextInterface
 	}
 
 	member = &Member{
-		ID: id,
-		Merchant: merchant,
-		Points: 0,
-		Transaction: nil,
+		ID:             id,
+		Merchant:       merchant,
+		Points:         0,
+		Transaction:    nil,
 		MerchantPoints: map[string]int{},
 	}
 
-	memberAsBytes, _ := json.Marshal(member)
-	ctx.GetStub().PutState(member.ID, memberAsBytes)
+	err = fillMemberLocale(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putMember(ctx, member)
+	if err != nil {
+		return nil, err
+	}
 
-	return member
+	return member, nil
 }
 
-func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface, id string, senderKey string, receiverKey string, value int, merchant string, createdAt string, sourceType string, sourceId string) error {
-	// exists, err := s.AssetExists(ctx, id)
+// CreateTransaction moves value points from the sender to the receiver and returns the key of
+// the transaction. createdAt is an RFC3339 date stored in UTC, the transaction timestamp when
+// empty. Retries passing the idempotency token of an earlier submission in transient
+// data return the key of the transaction it created.
+func (s *PointsContract) CreateTransaction(ctx TransactionContext, id string, senderKey string, receiverKey string, value int, merchant string, createdAt string, sourceType string, sourceId string) (string, error) {
+	createdAt, err := inputDate(ctx, createdAt)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = getMerchant(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
 	transaction := PointsTransaction{
-		ID: id,
-		Value: value,
+		ID:        id,
+		Value:     value,
+		Merchant:  merchant,
 		CreatedAt: createdAt,
-		Sender: senderKey,
-		Receiver: receiverKey,
+		Sender:    senderKey,
+		Receiver:  receiverKey,
 		Source: &Source{
 			Type: sourceType,
-			ID: sourceId,
+			ID:   sourceId,
 		},
+		Status: StatusConfirmed,
+	}
+
+	err = assertCanSend(ctx, senderKey, merchant)
+	if err != nil {
+		return "", err
 	}
 
-	sender := s.CreateMember(ctx, senderKey, merchant)
-	receiver := s.CreateMember(ctx, receiverKey, merchant)
+	return idempotentCreate(ctx, func() (string, error) {
+		return id, createTransaction(ctx, &transaction)
+	})
+}
+
+// createTransaction applies a new transaction to the members' balances and stores it
+func createTransaction(ctx TransactionContext, transaction *PointsTransaction) error {
+	err := assertMerchantActive(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	var existing PointsTransaction
+	exists, err := getObject(ctx, transactionObjectType, transaction.ID, &existing)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return newError(ErrAlreadyExists, "transaction %s already exists", transaction.ID)
+	}
+
+	err = assertOwnIssuance(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if isOrderReward(transaction) {
+		err = assertOrderNotRewarded(ctx, transaction)
+		if err != nil {
+			return err
+		}
+
+		err = putOrderReward(ctx, transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	pending, err := holdForApproval(ctx, transaction)
+	if err != nil || pending {
+		return err
+	}
+
+	// Emitted first so that it precedes the events of the effects of the transaction, such as TierChanged
+	err = emitEvent(ctx, pointsTransferredEvent, &PointsTransferredEvent{
+		Transaction: transaction.ID,
+		Type:        transactionType(transaction),
+		Merchant:    transaction.Merchant,
+		Sender:      transaction.Sender,
+		Receiver:    transaction.Receiver,
+		Value:       transaction.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = applyTransaction(ctx, transaction, transaction.Value, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	err = putTransaction(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	err = accrueCommission(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	return rewardReferral(ctx, transaction)
+}
+
+// GetTransaction returns the transaction stored in the world state with given id
+func (s *PointsContract) GetTransaction(ctx TransactionContext, id string) (*PointsTransaction, error) {
+	return getTransaction(ctx, id)
+}
+
+func getTransaction(ctx TransactionContext, id string) (*PointsTransaction, error) {
+	var transaction PointsTransaction
+	found, err := getObject(ctx, transactionObjectType, id, &transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, newError(ErrNotFound, "transaction %s does not exist in world state", id)
+	}
+
+	return &transaction, nil
+}
+
+// applyTransaction moves value between the sender and receiver of a transaction and
+// records the transaction on both members. A negative value undoes a previous transaction.
+func applyTransaction(ctx TransactionContext, transaction *PointsTransaction, value int, merchant string) error {
+	err := assertNotFrozen(ctx, transaction.Sender, transaction.Receiver)
+	if err != nil {
+		return err
+	}
+
+	if value > 0 {
+		credited, err := creditFromMerchant(ctx, transaction, value)
+		if err != nil || credited {
+			return err
+		}
+	}
+
+	sender, err := createMember(ctx, transaction.Sender, merchant)
+	if err != nil {
+		return err
+	}
+
+	receiver, err := createMember(ctx, transaction.Receiver, merchant)
+	if err != nil {
+		return err
+	}
 
 	if sender.Merchant == "" && receiver.Merchant != "" {
 		// Case1: Customer get points from a merchant
 		receiver.Points += value
-		receiver.Transaction = &transaction
+		receiver.Transaction = transaction
 		receiver.MerchantPoints[sender.ID] += value
+		if transaction.PointType != "" {
+			addTypedPoints(receiver, sender.ID, transaction.PointType, value)
+		}
+
+		err = recordIssuance(ctx, transaction, sender, receiver, value, false)
+		if err != nil {
+			return err
+		}
 
 		sender.Points += value
 		if sender.ID != receiver.Merchant {
@@ -223,115 +613,225 @@ func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInte
 		}
 	} else if sender.Merchant != "" && receiver.Merchant == "" {
 		// Case2: A merchant receive customer's points by using it in order purchase
-		if sender.Points < value {
+		if untypedPoints(sender) < value {
 			// TODO: Alert error about points is not enough to purchase
-			return fmt.Errorf("%s does not have enough points", sender.ID)
+			return newError(ErrInsufficientPoints, "%s does not have enough points", sender.ID)
+		}
+
+		if value > 0 {
+			err = assertLifeCardEligible(ctx, sender)
+			if err != nil {
+				return err
+			}
 		}
 
 		sender.Points -= value
-		sender.Transaction = &transaction
+		sender.Transaction = transaction
 		sender.MerchantPoints[receiver.ID] -= value
+		if transaction.PointType != "" {
+			addTypedPoints(sender, receiver.ID, transaction.PointType, -value)
+		}
 
 		if sender.Merchant != receiver.ID {
 			receiver.MerchantPoints[sender.Merchant] -= value
 		} else {
 			receiver.Points -= value
 		}
+
+		err = recordSettlement(ctx, receiver.ID, settlementRedeemed, transaction.ID, value)
+		if err != nil {
+			return err
+		}
+
+		err = updateProgramStats(ctx, receiver.ID, programRedeemed, value)
+		if err != nil {
+			return err
+		}
 	} else if sender.Merchant == "" && receiver.Merchant == "" {
 		// Case 3: Transaction between two merchants
 		sender.Points += value
 		sender.MerchantPoints[receiver.ID] += value
+
+		err = recordSettlement(ctx, sender.ID, settlementTransferred, transaction.ID, value)
+		if err != nil {
+			return err
+		}
 	} else if sender.Merchant != "" && receiver.Merchant != "" {
 		// Case 4: Customer give points to others as gift
-		if sender.Points < value {
+		if untypedPoints(sender) < value {
 			// TODO: Alert error about points is not enough to purchase
-			return fmt.Errorf("%s does not have enough points", sender.ID)
+			return newError(ErrInsufficientPoints, "%s does not have enough points", sender.ID)
+		}
+
+		// Undoing a transfer does not count as another one
+		if value > 0 {
+			err = assertLifeCardEligible(ctx, sender)
+			if err != nil {
+				return err
+			}
+
+			err = recordVelocity(ctx, sender.ID, 0, 1)
+			if err != nil {
+				return err
+			}
 		}
 
 		sender.Points -= value
-		sender.Transaction = &transaction
+		sender.Transaction = transaction
 		sender.MerchantPoints[sender.Merchant] -= value
 
 		receiver.Points += value
-		receiver.Transaction = &transaction
+		receiver.Transaction = transaction
 		receiver.MerchantPoints[sender.Merchant] += value
+
+		err = recordSettlement(ctx, sender.Merchant, settlementTransferred, transaction.ID, value)
+		if err != nil {
+			return err
+		}
 	}
 
+	for _, member := range []*Member{sender, receiver} {
+		if member.Merchant != "" && member.Points < 0 {
+			return newError(ErrInsufficientPoints, "%s does not have enough points", member.ID)
+		}
+	}
 
-	senderAsBytes, _ := json.Marshal(sender)
-	senderErr := ctx.GetStub().PutState(sender.ID, senderAsBytes)
+	err = putMember(ctx, sender)
 
-	if senderErr != nil {
-		return senderErr
+	if err != nil {
+		return err
 	}
 
-	receiverAsBytes, _ := json.Marshal(receiver)
-	receiverErr := ctx.GetStub().PutState(receiver.ID, receiverAsBytes)
+	return putMember(ctx, receiver)
+}
 
-	return receiverErr
+// recordIssuance records the points a merchant issued to a customer in the statistics kept
+// besides the balances. Deferred issuances, credited as balance deltas, add deltas to the
+// tier, velocity and program statistics counters instead of reading them.
+func recordIssuance(ctx TransactionContext, transaction *PointsTransaction, sender *Member, receiver *Member, value int, deferred bool) error {
+	if deferred {
+		err := deferEarnedPoints(ctx, transaction.ID, receiver.ID, sender.ID, value)
+		if err != nil {
+			return err
+		}
+
+		err = deferVelocity(ctx, transaction.ID, receiver.ID, value)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := recordEarnedPoints(ctx, receiver.ID, sender.ID, value)
+		if err != nil {
+			return err
+		}
+
+		err = recordVelocity(ctx, receiver.ID, value, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := recordSettlement(ctx, sender.ID, settlementIssued, transaction.ID, value)
+	if err != nil {
+		return err
+	}
+
+	err = recordPointLot(ctx, transaction, receiver.ID, sender.ID, value)
+	if err != nil {
+		return err
+	}
+
+	if deferred {
+		return deferProgramIssuance(ctx, transaction.ID, sender.ID, value)
+	}
+
+	return updateProgramStats(ctx, sender.ID, programIssued, value)
 }
 
 func main() {
-	// See chaincode.env.example
-	config := serverConfig{
-		CCID:    os.Getenv("CHAINCODE_ID"),
-		Address: os.Getenv("CHAINCODE_SERVER_ADDRESS"),
+	configFile := flag.String("config", os.Getenv("CHAINCODE_CONFIG_FILE"), "YAML or JSON configuration file, environment variables override its settings")
+	flag.Parse()
+
+	config, err := loadServerConfig(*configFile)
+	if err != nil {
+		logPanic("error loading the configuration", logFields{"error": err})
 	}
 
-	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	err = configureLogger(config.Log.Level, config.Log.Format)
+	if err != nil {
+		logPanic("error configuring the logger", logFields{"error": err})
+	}
 
+	chaincode, err := newChaincode()
 	if err != nil {
-		log.Panicf("error create points-transfer chaincode: %s", err)
+		logPanic("error create points-transfer chaincode", logFields{"error": err})
 	}
 
-	server := &shim.ChaincodeServer{
-		CCID:    config.CCID,
-		Address: config.Address,
-		CC:      chaincode,
-		TLSProps: getTLSProperties(),
+	if config.OperationsAddress != "" {
+		startOperationsServer(config.OperationsAddress, config.Address, config.Features.Metrics)
 	}
 
-	if err := server.Start(); err != nil {
-		log.Panicf("error starting points-transfer chaincode: %s", err)
+	server, listener, err := newChaincodeServer(config.CCID, config.Address, &instrumentedChaincode{Chaincode: chaincode}, getTLSReloader(config.TLS), config.GRPC)
+	if err != nil {
+		logPanic("error starting points-transfer chaincode", logFields{"address": config.Address, "error": err})
 	}
-}
 
-func getTLSProperties() shim.TLSProperties {
-	// Check if chaincode is TLS enabled
-	tlsDisabledStr := getEnvOrDefault("CHAINCODE_TLS_DISABLED", "true")
-	key := getEnvOrDefault("CHAINCODE_TLS_KEY", "")
-	cert := getEnvOrDefault("CHAINCODE_TLS_CERT", "")
-	clientCACert := getEnvOrDefault("CHAINCODE_CLIENT_CA_CERT", "")
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
 
-	// convert tlsDisabledStr to boolean
-	tlsDisabled := getBoolOrDefault(tlsDisabledStr, false)
-	var keyBytes, certBytes, clientCACertBytes []byte
-	var err error
+	os.Exit(waitForShutdown(server, served, config.ShutdownTimeout))
+}
 
-	if !tlsDisabled {
-		keyBytes, err = ioutil.ReadFile(key)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
-		certBytes, err = ioutil.ReadFile(cert)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
+// newChaincode returns the points-transfer chaincode with its contracts and their hooks
+func newChaincode() (*contractapi.ContractChaincode, error) {
+	pointsContract := new(PointsContract)
+	pointsContract.Info = contractInfo("PointsContract", "Members earn, transfer and redeem points")
+	pointsContract.TransactionContextHandler = new(transactionContext)
+	pointsContract.BeforeTransaction = beforeTransaction
+	pointsContract.UnknownTransaction = unknownTransaction(pointsContract)
+
+	merchantContract := new(MerchantContract)
+	merchantContract.Info = contractInfo("MerchantContract", "Merchants administer their points program")
+	merchantContract.TransactionContextHandler = new(transactionContext)
+	merchantContract.BeforeTransaction = beforeTransaction
+	merchantContract.UnknownTransaction = unknownTransaction(merchantContract)
+
+	adminContract := new(AdminContract)
+	adminContract.Info = contractInfo("AdminContract", "Operators of the network")
+	adminContract.TransactionContextHandler = new(transactionContext)
+	adminContract.BeforeTransaction = beforeTransaction
+	adminContract.UnknownTransaction = unknownTransaction(adminContract)
+
+	// PointsContract is the default contract, its functions may be called without namespace
+	chaincode, err := contractapi.NewChaincode(pointsContract, merchantContract, adminContract)
+	if err != nil {
+		return nil, err
 	}
-	// Did not request for the peer cert verification
-	if clientCACert != "" {
-		clientCACertBytes, err = ioutil.ReadFile(clientCACert)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
+
+	chaincode.Info.Title = "points-transfer"
+	chaincode.Info.Version = contractVersion
+
+	return chaincode, nil
+}
+
+// getTLSReloader loads the TLS files of the chaincode server and watches them for changes,
+// it returns nil when TLS is disabled
+func getTLSReloader(config tlsConfig) *tlsReloader {
+	if config.Disabled {
+		return nil
 	}
 
-	return shim.TLSProperties{
-		Disabled: tlsDisabled,
-		Key: keyBytes,
-		Cert: certBytes,
-		ClientCACerts: clientCACertBytes,
+	// Did not request for the peer cert verification when ClientCACert is empty
+	reloader, err := newTLSReloader(config.Key, config.Cert, config.ClientCACert)
+	if err != nil {
+		logPanic("error while reading the crypto file", logFields{"error": err})
 	}
+
+	reloader.watch(config.ReloadInterval)
+
+	return reloader
 }
 
 func getEnvOrDefault(env, defaultVal string) string {
@@ -346,8 +846,38 @@ func getEnvOrDefault(env, defaultVal string) string {
 // cannot be parsed!
 func getBoolOrDefault(value string, defaultVal bool) bool {
 	parsed, err := strconv.ParseBool(value)
-	if err!= nil {
+	if err != nil {
+		return defaultVal
+	}
+	return parsed
+}
+
+// getDurationOrDefault parses the duration in env, such as 30s or 1m
+func getDurationOrDefault(env string, defaultVal time.Duration) time.Duration {
+	value, ok := os.LookupEnv(env)
+	if !ok {
+		return defaultVal
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		logPanic("error parsing "+env, logFields{"value": value, "error": err})
+	}
+
+	return parsed
+}
+
+// getIntOrDefault parses the integer in env
+func getIntOrDefault(env string, defaultVal int) int {
+	value, ok := os.LookupEnv(env)
+	if !ok {
 		return defaultVal
 	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logPanic("error parsing "+env, logFields{"value": value, "error": err})
+	}
+
 	return parsed
 }
@@ -0,0 +1,153 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitLedger(t *testing.T) {
+	env := newTestEnv(t)
+
+	require.NoError(t, env.points.InitLedger(env.ctx(adminIdentity)))
+
+	member := env.member("merchant-cn")
+	require.Equal(t, 1000, member.Points)
+
+	customer := env.member("maxime@ekohe.com")
+	require.Equal(t, "merchant-jp", customer.Merchant)
+	require.Equal(t, 500, customer.MerchantPoints["merchant-tw"])
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(adminIdentity), "merchant-jp")
+	require.NoError(t, err)
+	require.Equal(t, "Org2MSP", merchant.MSP)
+}
+
+func TestCreateMember(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	member, err := env.points.CreateMember(env.ctx(merchantIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, "m1", member.Merchant)
+	require.Equal(t, 0, member.Points)
+
+	member, err = env.points.CreateMember(env.ctx(merchantIdentity), "m1", "m1")
+	require.NoError(t, err)
+	require.Equal(t, "", member.Merchant, "the member of a merchant has no merchant")
+
+	env.reward("m1", "alice", 10)
+	member, err = env.points.CreateMember(env.ctx(merchantIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, 10, member.Points, "an existing member is returned as is")
+
+	_, err = env.points.CreateMember(env.ctx(merchantIdentity), "bob", "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestGetMember(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 25)
+
+	member := env.member("alice")
+	require.Equal(t, 25, member.Points)
+	require.Equal(t, 25, member.MerchantPoints["m1"])
+
+	_, err := env.points.GetMember(env.ctx(adminIdentity), "nobody")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestGetAllMembers(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.reward("m1", "bob", 20)
+
+	members, err := env.points.GetAllMembers(env.ctx(adminIdentity))
+	require.NoError(t, err)
+
+	points := map[string]int{}
+	for _, member := range members {
+		points[member.ID] = member.Points
+	}
+	require.Equal(t, map[string]int{"m1": 30, "alice": 10, "bob": 20}, points)
+}
+
+func TestCreateTransactionIssues(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 100, "m1", "", "Order", "o1")
+	require.NoError(t, err)
+
+	var event PointsTransferredEvent
+	env.requireEvent(pointsTransferredEvent, &event)
+	require.Equal(t, "alice", event.Receiver)
+	require.Equal(t, 100, event.Value)
+
+	alice := env.member("alice")
+	require.Equal(t, 100, alice.MerchantPoints["m1"])
+	require.Equal(t, "o1", alice.Transaction.Source.ID)
+	require.Equal(t, 100, env.member("m1").Points)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 50, "m1", "", "Order", "o2")
+	require.NoError(t, err)
+	require.Equal(t, 150, env.balance("alice", "m1"))
+}
+
+func TestCreateTransactionRedeems(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 40, "m1", "", "Redemption", "")
+	require.NoError(t, err)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 60, env.member("m1").Points)
+
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r2", "alice", "m1", 61, "m1", "", "Redemption", "")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+}
+
+func TestCreateTransactionTransfersBetweenCustomers(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 1)
+	alice := env.registerAccount("alice")
+
+	_, err := env.points.CreateTransaction(env.ctx(alice), "g1", "alice", "bob", 30, "m1", "", "Gift", "")
+	require.NoError(t, err)
+	require.Equal(t, 70, env.balance("alice", "m1"))
+	require.Equal(t, 31, env.balance("bob", "m1"))
+}
+
+func TestCreateTransactionRejectsDuplicates(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	existing := env.reward("m1", "alice", 5)
+
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), existing, "m1", "alice", 5, "m1", "", "Order", "o2")
+	requireErrorCode(t, err, ErrAlreadyExists)
+	require.Equal(t, 5, env.balance("alice", "m1"))
+}
+
+func TestGetTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 5)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, "m1", transaction.Merchant)
+	require.Equal(t, 5, transaction.Value)
+
+	_, err = env.points.GetTransaction(env.ctx(adminIdentity), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
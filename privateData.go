@@ -0,0 +1,383 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+const (
+	memberHashObjectType = "memberHash"
+	giftHashObjectType   = "giftHash"
+
+	// giftBalanceObjectType keys the private gift balances in the private collections
+	giftBalanceObjectType = "giftBalance"
+
+	// privateCollectionSuffix is appended to the MSP ID of an organization to name its collection
+	privateCollectionSuffix = "PrivateCollection"
+
+	// memberDetailsTransientKey is the transient field carrying MemberPrivateDetails
+	memberDetailsTransientKey = "member_details"
+
+	// giftDetailsTransientKey is the transient field carrying GiftPrivateDetails
+	giftDetailsTransientKey = "gift_details"
+)
+
+// PrivateGiftBalance holds the net points a customer sent, as a negative number, or received
+// with private gifts, kept in the private collection of the gifter's merchant
+type PrivateGiftBalance struct {
+	Schema
+	Member   string `json:"member"`
+	Merchant string `json:"merchant"`
+	Points   int    `json:"points"`
+}
+
+// MemberPrivateDetails holds the personal data of a member, kept in the merchant's private collection
+type MemberPrivateDetails struct {
+	ID       string `json:"ID"`
+	Merchant string `json:"merchant"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Salt     string `json:"salt"`
+}
+
+// GiftPrivateDetails holds the confidential part of a gift, kept in the merchant's private collection
+type GiftPrivateDetails struct {
+	ID        string `json:"ID"`
+	Gifter    string `json:"gifter"`
+	Giftee    string `json:"giftee"`
+	Value     int    `json:"value"`
+	CreatedAt string `json:"created_at"`
+	Salt      string `json:"salt"`
+}
+
+// PutMemberPrivateDetails stores the member details passed in transient data
+// in the merchant's private collection and a salted hash on the public ledger
+func (s *PointsContract) PutMemberPrivateDetails(ctx TransactionContext) error {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return newError(ErrInternal, "failed to get transient data. %s", err.Error())
+	}
+
+	detailsAsBytes, ok := transientMap[memberDetailsTransientKey]
+	if !ok {
+		return newError(ErrInvalidArgument, "%s must be passed in transient data", memberDetailsTransientKey)
+	}
+
+	var details MemberPrivateDetails
+	err = json.Unmarshal(detailsAsBytes, &details)
+	if err != nil {
+		return newError(ErrInvalidArgument, "failed to parse transient data. %s", err.Error())
+	}
+
+	if details.ID == "" || details.Merchant == "" || details.Salt == "" {
+		return newError(ErrInvalidArgument, "ID, merchant and salt must not be empty")
+	}
+
+	collection, err := getMerchantCollection(ctx, details.Merchant)
+	if err != nil {
+		return err
+	}
+
+	detailsAsBytes, err = json.Marshal(details)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s. %s", details.ID, err.Error())
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, details.ID, detailsAsBytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to put to private data. %s", err.Error())
+	}
+
+	hashKey, err := ctx.GetStub().CreateCompositeKey(memberHashObjectType, []string{details.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	hash := sha256.Sum256(detailsAsBytes)
+	err = ctx.GetStub().PutState(hashKey, []byte(hex.EncodeToString(hash[:])))
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetMemberPrivateDetails reads the member details from the merchant's private collection
+func (s *PointsContract) GetMemberPrivateDetails(ctx TransactionContext, id string, merchant string) (*MemberPrivateDetails, error) {
+	collection, err := getMerchantCollection(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetPrivateData(collection, id)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from private data. %s", err.Error())
+	}
+
+	if bytes == nil {
+		return nil, newError(ErrNotFound, "%s does not exist in collection %s", id, collection)
+	}
+
+	var details MemberPrivateDetails
+	err = json.Unmarshal(bytes, &details)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to unmarshal %s. %s", id, err.Error())
+	}
+
+	return &details, nil
+}
+
+// GetMemberPrivateHash returns the salted hash of a member's private details from the public ledger
+func (s *PointsContract) GetMemberPrivateHash(ctx TransactionContext, id string) (string, error) {
+	hashKey, err := ctx.GetStub().CreateCompositeKey(memberHashObjectType, []string{id})
+	if err != nil {
+		return "", newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetState(hashKey)
+	if err != nil {
+		return "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	if bytes == nil {
+		return "", newError(ErrNotFound, "no private details hash for %s", id)
+	}
+
+	return string(bytes), nil
+}
+
+// getMerchantCollection returns the private collection of a merchant, only
+// members of the merchant's organization may access it
+func getMerchantCollection(ctx TransactionContext, merchant string) (string, error) {
+	mspID, err := getMerchantMSP(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", newError(ErrInternal, "failed to get client MSP ID. %s", err.Error())
+	}
+
+	if clientMSPID != mspID {
+		return "", newError(ErrUnauthorized, "client from %s is not a member of the private collection of merchant %s", clientMSPID, merchant)
+	}
+
+	return mspID + privateCollectionSuffix, nil
+}
+
+// CreateGiftTransactionPrivate moves points between two customers of a merchant using gift
+// details passed in transient data, so the amount never appears in the proposal payload. The
+// details and the balance effects are written to the gifter's merchant private collection and
+// only the hash of the details is recorded publicly. The public balances do not change and public
+// functions never read the collection, which only peers of the merchant's organization can: a
+// customer may gift privately its spendable public points plus its net private gifts, and points
+// received privately can only be gifted privately again.
+func (s *PointsContract) CreateGiftTransactionPrivate(ctx TransactionContext) error {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return newError(ErrInternal, "failed to get transient data. %s", err.Error())
+	}
+
+	detailsAsBytes, ok := transientMap[giftDetailsTransientKey]
+	if !ok {
+		return newError(ErrInvalidArgument, "%s must be passed in transient data", giftDetailsTransientKey)
+	}
+
+	var details GiftPrivateDetails
+	err = json.Unmarshal(detailsAsBytes, &details)
+	if err != nil {
+		return newError(ErrInvalidArgument, "failed to parse transient data. %s", err.Error())
+	}
+
+	if details.ID == "" || details.Gifter == "" || details.Giftee == "" || details.Salt == "" {
+		return newError(ErrInvalidArgument, "ID, gifter, giftee and salt must not be empty")
+	}
+
+	if details.Gifter == details.Giftee {
+		return newError(ErrInvalidArgument, "%s cannot gift points to itself", details.Gifter)
+	}
+
+	if details.Value <= 0 {
+		return newError(ErrInvalidArgument, "gift value must be positive")
+	}
+
+	details.CreatedAt, err = inputDate(ctx, details.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	gifter, err := getMember(ctx, details.Gifter)
+	if err != nil {
+		return err
+	}
+
+	if gifter.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot offer gifts", gifter.ID)
+	}
+
+	giftee, err := getMember(ctx, details.Giftee)
+	if err != nil {
+		return err
+	}
+
+	if giftee.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot receive gifts", giftee.ID)
+	}
+
+	err = assertMerchantActive(ctx, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	collection, err := getMerchantCollection(ctx, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	err = assertCanSend(ctx, details.Gifter, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(ctx, details.Gifter, details.Giftee)
+	if err != nil {
+		return err
+	}
+
+	err = assertLifeCardEligible(ctx, gifter)
+	if err != nil {
+		return err
+	}
+
+	hashKey, err := ctx.GetStub().CreateCompositeKey(giftHashObjectType, []string{details.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	existing, err := ctx.GetStub().GetState(hashKey)
+	if err != nil {
+		return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+
+	if existing != nil {
+		return newError(ErrAlreadyExists, "private gift %s already exists", details.ID)
+	}
+
+	gifterBalance, err := getPrivateGiftBalance(ctx, collection, gifter.ID, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if spendablePoints(gifter)+gifterBalance.Points < details.Value {
+		return newError(ErrInsufficientPoints, "%s does not have enough points", gifter.ID)
+	}
+
+	gifteeBalance, err := getPrivateGiftBalance(ctx, collection, giftee.ID, gifter.Merchant)
+	if err != nil {
+		return err
+	}
+
+	gifterBalance.Points -= details.Value
+	gifteeBalance.Points += details.Value
+
+	for _, balance := range []*PrivateGiftBalance{gifterBalance, gifteeBalance} {
+		err = putPrivateGiftBalance(ctx, collection, balance)
+		if err != nil {
+			return err
+		}
+	}
+
+	detailsAsBytes, err = json.Marshal(details)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal %s. %s", details.ID, err.Error())
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, details.ID, detailsAsBytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to put to private data. %s", err.Error())
+	}
+
+	hash := sha256.Sum256(detailsAsBytes)
+	err = ctx.GetStub().PutState(hashKey, []byte(hex.EncodeToString(hash[:])))
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetPrivateGiftBalance returns the net points a customer sent or received with private gifts,
+// read from the private collection of its merchant
+func (s *PointsContract) GetPrivateGiftBalance(ctx TransactionContext, id string) (*PrivateGiftBalance, error) {
+	member, err := getMember(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if member.Merchant == "" {
+		return nil, newError(ErrInvalidArgument, "%s is a merchant and has no private gifts", id)
+	}
+
+	collection, err := getMerchantCollection(ctx, member.Merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPrivateGiftBalance(ctx, collection, id, member.Merchant)
+}
+
+func getPrivateGiftBalance(ctx TransactionContext, collection string, id string, merchant string) (*PrivateGiftBalance, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(giftBalanceObjectType, []string{id})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	bytes, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from private data. %s", err.Error())
+	}
+
+	balance := PrivateGiftBalance{Member: id, Merchant: merchant}
+	if bytes == nil {
+		return &balance, nil
+	}
+
+	bytes, err = upgradeRecord(giftBalanceObjectType, bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(bytes, &balance)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to unmarshal the private gift balance of %s. %s", id, err.Error())
+	}
+
+	return &balance, nil
+}
+
+func putPrivateGiftBalance(ctx TransactionContext, collection string, balance *PrivateGiftBalance) error {
+	key, err := ctx.GetStub().CreateCompositeKey(giftBalanceObjectType, []string{balance.Member})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	balance.setSchema(giftBalanceObjectType)
+	bytes, err := json.Marshal(balance)
+	if err != nil {
+		return newError(ErrInternal, "failed to marshal the private gift balance of %s. %s", balance.Member, err.Error())
+	}
+
+	err = ctx.GetStub().PutPrivateData(collection, key, bytes)
+	if err != nil {
+		return newError(ErrInternal, "failed to put to private data. %s", err.Error())
+	}
+
+	return nil
+}
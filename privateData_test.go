@@ -0,0 +1,125 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutMemberPrivateDetails(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	details, err := json.Marshal(MemberPrivateDetails{ID: "alice", Merchant: "m1", Name: "Alice", Email: "alice@example.com", Salt: "s1"})
+	require.NoError(t, err)
+
+	err = env.points.PutMemberPrivateDetails(env.ctx(merchantIdentity))
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	ctx := env.ctx(otherMSPIdentity)
+	env.stub.transient[memberDetailsTransientKey] = details
+	requireErrorCode(t, env.points.PutMemberPrivateDetails(ctx), ErrUnauthorized)
+
+	ctx = env.ctx(merchantIdentity)
+	env.stub.transient[memberDetailsTransientKey] = details
+	require.NoError(t, env.points.PutMemberPrivateDetails(ctx))
+
+	stored, err := env.points.GetMemberPrivateDetails(env.ctx(merchantIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", stored.Email)
+
+	hash, err := env.points.GetMemberPrivateHash(env.ctx(otherMSPIdentity), "alice")
+	require.NoError(t, err)
+	require.Len(t, hash, 64)
+
+	_, err = env.points.GetMemberPrivateDetails(env.ctx(otherMSPIdentity), "alice", "m1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.GetMemberPrivateDetails(env.ctx(merchantIdentity), "bob", "m1")
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.points.GetMemberPrivateHash(env.ctx(merchantIdentity), "bob")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestCreateGiftTransactionPrivate(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 1)
+	alice := env.registerAccount("alice")
+	gift := func(value int) []byte {
+		details, err := json.Marshal(GiftPrivateDetails{ID: "pg1", Gifter: "alice", Giftee: "bob", Value: value, CreatedAt: "2024-03-15T10:00:00Z", Salt: "s1"})
+		require.NoError(t, err)
+		return details
+	}
+
+	ctx := env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey] = gift(0)
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrInvalidArgument)
+
+	ctx = env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey] = gift(101)
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrInsufficientPoints)
+
+	ctx = env.ctx(otherMSPIdentity)
+	env.stub.transient[giftDetailsTransientKey] = gift(30)
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrUnauthorized)
+
+	ctx = env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey] = gift(30)
+	require.NoError(t, env.points.CreateGiftTransactionPrivate(ctx))
+	require.Equal(t, 100, env.balance("alice", "m1"), "public balances do not change")
+	require.Equal(t, 1, env.balance("bob", "m1"))
+	require.NotEqual(t, "pg1", env.member("bob").Transaction.ID)
+
+	hashKey, err := env.stub.CreateCompositeKey(giftHashObjectType, []string{"pg1"})
+	require.NoError(t, err)
+	hash, err := env.stub.GetState(hashKey)
+	require.NoError(t, err)
+	require.NotEmpty(t, hash)
+
+	balance, err := env.points.GetPrivateGiftBalance(env.ctx(merchantIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, -30, balance.Points)
+
+	balance, err = env.points.GetPrivateGiftBalance(env.ctx(merchantIdentity), "bob")
+	require.NoError(t, err)
+	require.Equal(t, 30, balance.Points)
+
+	_, err = env.points.GetPrivateGiftBalance(env.ctx(otherMSPIdentity), "bob")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	ctx = env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey] = gift(10)
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrAlreadyExists)
+
+	// Public spending does not read the private collection, the public points spent are no
+	// longer giftable privately
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 71, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	ctx = env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey], err = json.Marshal(GiftPrivateDetails{ID: "pg2", Gifter: "alice", Giftee: "bob", Value: 1, CreatedAt: "2024-03-15T10:00:00Z", Salt: "s2"})
+	require.NoError(t, err)
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrInsufficientPoints)
+}
+
+func TestCreateGiftTransactionPrivateValidatesDate(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 1)
+	alice := env.registerAccount("alice")
+
+	details, err := json.Marshal(GiftPrivateDetails{ID: "pg1", Gifter: "alice", Giftee: "bob", Value: 10, CreatedAt: "20240315", Salt: "s1"})
+	require.NoError(t, err)
+
+	ctx := env.ctx(alice)
+	env.stub.transient[giftDetailsTransientKey] = details
+	requireErrorCode(t, env.points.CreateGiftTransactionPrivate(ctx), ErrInvalidArgument)
+}
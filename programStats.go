@@ -0,0 +1,133 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const programStatsObjectType = "programStats"
+
+// Kinds of changes to the program statistics of a merchant
+const (
+	programIssued   = "issued"
+	programRedeemed = "redeemed"
+	programBurned   = "burned"
+	programExpired  = "expired"
+	// programAdjusted changes the outstanding points only, such as adjustments and conversions
+	programAdjusted = "adjusted"
+)
+
+// ProgramStats holds running totals of the points program of a merchant
+type ProgramStats struct {
+	Schema
+	Merchant string `json:"merchant"`
+	Issued   int    `json:"issued"`
+	Redeemed int    `json:"redeemed"`
+	Burned   int    `json:"burned"`
+	Expired  int    `json:"expired"`
+	// Outstanding is the liability of the merchant, the points held by customers
+	Outstanding int    `json:"outstanding"`
+	UpdatedAt   string `json:"updated_at"`
+	// deltaKeys are the issuances added to the statistics read, deleted when they are written
+	deltaKeys []string
+}
+
+// GetProgramStats returns the running totals of the points program of a merchant
+func (s *MerchantContract) GetProgramStats(ctx TransactionContext, merchant string) (*ProgramStats, error) {
+	_, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	return getProgramStats(ctx, merchant)
+}
+
+// updateProgramStats adds value points of a kind of change to the statistics of a merchant
+func updateProgramStats(ctx TransactionContext, merchant string, kind string, value int) error {
+	stats, err := getProgramStats(ctx, merchant)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case programIssued:
+		stats.Issued += value
+		stats.Outstanding += value
+	case programRedeemed:
+		stats.Redeemed += value
+		stats.Outstanding -= value
+	case programBurned:
+		stats.Burned += value
+		stats.Outstanding -= value
+	case programExpired:
+		stats.Expired += value
+		stats.Outstanding -= value
+	case programAdjusted:
+		stats.Outstanding += value
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats.UpdatedAt = now.Format(time.RFC3339)
+
+	return putProgramStats(ctx, stats)
+}
+
+// deferProgramIssuance adds points issued by a transaction to the statistics of a merchant
+// as a delta, without reading the statistics
+func deferProgramIssuance(ctx TransactionContext, transactionID string, merchant string, value int) error {
+	return putCounterDelta(ctx, programStatsObjectType, []string{merchant}, transactionID, &CounterDelta{Value: value})
+}
+
+// putProgramStats writes the statistics of a merchant with the deltas they were read with
+func putProgramStats(ctx TransactionContext, stats *ProgramStats) error {
+	err := deleteDeltas(ctx, stats.deltaKeys)
+	if err != nil {
+		return err
+	}
+
+	stats.deltaKeys = nil
+	return putObject(ctx, programStatsObjectType, stats.Merchant, stats)
+}
+
+// getProgramStats returns the statistics of a merchant with the issuances recorded as deltas.
+// Merchants without statistics start from the points they had outstanding when the
+// statistics were introduced.
+func getProgramStats(ctx TransactionContext, merchant string) (*ProgramStats, error) {
+	var stats ProgramStats
+	found, err := getObject(ctx, programStatsObjectType, merchant, &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		stats.Merchant = merchant
+
+		// The points of the balance deltas are counted by the deltas of the statistics
+		member, err := getStoredMember(ctx, merchant)
+		if err != nil && !hasErrorCode(err, ErrNotFound) {
+			return nil, err
+		}
+
+		if member != nil {
+			stats.Outstanding = member.Points
+		}
+	}
+
+	deltas, keys, err := getCounterDeltas(ctx, programStatsObjectType, []string{merchant})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delta := range deltas {
+		stats.Issued += delta.Value
+		stats.Outstanding += delta.Value
+	}
+	stats.deltaKeys = keys
+
+	return &stats, nil
+}
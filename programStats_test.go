@@ -0,0 +1,52 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProgramStats(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 40, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+	_, err = env.admin.BurnPoints(env.ctx(adminIdentity), "alice", "m1", 10, "fraud")
+	require.NoError(t, err)
+
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 100, stats.Issued)
+	require.Equal(t, 40, stats.Redeemed)
+	require.Equal(t, 10, stats.Burned)
+	require.Equal(t, 50, stats.Outstanding)
+
+	_, err = env.merchants.GetProgramStats(env.ctx(merchantIdentity), "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestProgramStatsCountExpiredPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.UpdateMerchant(env.ctx(merchantIdentity), "m1", "Merchant m1", "en", 1, 30, 0))
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 20)
+	env.advance(31 * 24 * time.Hour)
+
+	expired, err := env.admin.ExpirePoints(env.ctx(adminIdentity), "m1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, expired)
+
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 120, stats.Issued)
+	require.Equal(t, 120, stats.Expired)
+	require.Equal(t, 0, stats.Outstanding)
+}
@@ -0,0 +1,552 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// objectOwnerIndex lists the gifts, adjustments and holds of each account, keyed by owner,
+// object type and ID
+const objectOwnerIndex = "objectOwner"
+
+// Types of the provenance entries which do not stand for a transaction
+const (
+	// provenanceArchived stands for the transactions rolled into a period summary
+	provenanceArchived = "Archived"
+	// provenanceHold stands for points held for a checkout which was not captured yet
+	provenanceHold = "Hold"
+)
+
+// ProvenanceEntry is a transaction which changed a customer's balance of a merchant's points
+type ProvenanceEntry struct {
+	// Transaction is the key of the transaction, empty for archived periods
+	Transaction string `json:"transaction,omitempty" metadata:"transaction,optional"`
+	Type        string `json:"type"`
+	CreatedAt   string `json:"created_at"`
+	Sender      string `json:"sender,omitempty" metadata:"sender,optional"`
+	Receiver    string `json:"receiver,omitempty" metadata:"receiver,optional"`
+	Status      string `json:"status,omitempty" metadata:"status,optional"`
+	// Delta is the change of the balance, negative for points leaving the account
+	Delta int `json:"delta"`
+	// Balance is the balance derived from the entries up to this one
+	Balance int `json:"balance"`
+}
+
+// BalanceProvenance derives a customer's balance of a merchant's points from the ledger
+type BalanceProvenance struct {
+	Owner    string `json:"owner"`
+	Merchant string `json:"merchant"`
+	// Balance is the balance stored on the member
+	Balance int `json:"balance"`
+	// Derived is the sum of the entries, equal to Balance unless the ledger is inconsistent
+	Derived int               `json:"derived"`
+	Entries []ProvenanceEntry `json:"entries"`
+}
+
+// GetBalanceProvenance returns the transactions making up a customer's balance of a merchant's
+// points in the order they were created: issues, transfers in and out, gifts, conversions,
+// redemptions, burns, reversals and adjustments, including those of accounts merged into the
+// customer. Archived transactions are listed as one entry per archived period and points held
+// for a checkout as one entry per active hold.
+func (s *PointsContract) GetBalanceProvenance(ctx TransactionContext, owner string, merchant string) (*BalanceProvenance, error) {
+	return getBalanceProvenance(ctx, owner, merchant)
+}
+
+func getBalanceProvenance(ctx TransactionContext, owner string, merchant string) (*BalanceProvenance, error) {
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if member.Merchant == "" {
+		return nil, newError(ErrInvalidArgument, "%s is a merchant, provenance is only derived for customers", owner)
+	}
+
+	accounts, err := provenanceAccounts(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []ProvenanceEntry{}
+	for _, collect := range []func(TransactionContext, string, map[string]bool) ([]ProvenanceEntry, error){
+		archivedEntries,
+		transactionEntries,
+		giftEntries,
+		adjustmentEntries,
+		holdEntries,
+	} {
+		collected, err := collect(ctx, merchant, accounts)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, collected...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		left, _ := parseTransactionDate(entries[i].CreatedAt)
+		right, _ := parseTransactionDate(entries[j].CreatedAt)
+		if left.Equal(right) {
+			return entries[i].Transaction < entries[j].Transaction
+		}
+
+		return left.Before(right)
+	})
+
+	provenance := BalanceProvenance{
+		Owner:    owner,
+		Merchant: merchant,
+		Balance:  member.MerchantPoints[merchant],
+		Entries:  entries,
+	}
+
+	for i := range provenance.Entries {
+		provenance.Derived += provenance.Entries[i].Delta
+		provenance.Entries[i].Balance = provenance.Derived
+	}
+
+	return &provenance, nil
+}
+
+// archivedEntries returns an entry for each period of transactions archived by the accounts
+func archivedEntries(ctx TransactionContext, merchant string, accounts map[string]bool) ([]ProvenanceEntry, error) {
+	entries := []ProvenanceEntry{}
+	for account := range accounts {
+		summaries, err := getPeriodSummaries(ctx, merchant, account)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, summary := range summaries {
+			entries = append(entries, ProvenanceEntry{
+				Type:      provenanceArchived,
+				CreatedAt: summary.Before,
+				Delta:     summary.Credited - summary.Debited,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// transactionEntries returns an entry for each stored transaction which changed the balance of
+// the accounts, read through the owner index of each account
+func transactionEntries(ctx TransactionContext, merchant string, accounts map[string]bool) ([]ProvenanceEntry, error) {
+	ids, err := indexedIDs(ctx, transactionOwnerIndex, accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []ProvenanceEntry{}
+	for _, id := range ids {
+		transaction := new(PointsTransaction)
+		found, err := getObject(ctx, transactionObjectType, id, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		// Archived transactions are counted in the period summaries
+		if !found {
+			continue
+		}
+
+		delta, ok := balanceDelta(transaction, merchant, accounts)
+		if !ok {
+			continue
+		}
+
+		entries = append(entries, ProvenanceEntry{
+			Transaction: transaction.ID,
+			Type:        transactionType(transaction),
+			CreatedAt:   transaction.CreatedAt,
+			Sender:      transaction.Sender,
+			Receiver:    transaction.Receiver,
+			Status:      transactionStatus(transaction),
+			Delta:       delta,
+		})
+	}
+
+	return entries, nil
+}
+
+// giftEntries returns an entry for each gift offered or accepted by the accounts, the points
+// of rejected and expired gifts went back to the gifter
+func giftEntries(ctx TransactionContext, merchant string, accounts map[string]bool) ([]ProvenanceEntry, error) {
+	entries := []ProvenanceEntry{}
+	err := ownedObjects(ctx, giftObjectType, accounts, func() interface{} { return new(Gift) }, func(v interface{}) {
+		gift := v.(*Gift)
+		if gift.Merchant != merchant || gift.Status == GiftRejected || gift.Status == GiftExpired {
+			return
+		}
+
+		delta := 0
+		if accounts[gift.Gifter] {
+			delta -= gift.Value
+		}
+		if accounts[gift.Giftee] && gift.Status == GiftAccepted {
+			delta += gift.Value
+		}
+		if delta == 0 {
+			return
+		}
+
+		entries = append(entries, ProvenanceEntry{
+			Transaction: gift.ID,
+			Type:        TypeGift,
+			CreatedAt:   gift.CreatedAt,
+			Sender:      gift.Gifter,
+			Receiver:    gift.Giftee,
+			Status:      gift.Status,
+			Delta:       delta,
+		})
+	})
+
+	return entries, err
+}
+
+// adjustmentEntries returns an entry for each adjustment of the accounts approved before
+// adjustments were recorded as transactions, later ones are listed with the transactions
+func adjustmentEntries(ctx TransactionContext, merchant string, accounts map[string]bool) ([]ProvenanceEntry, error) {
+	entries := []ProvenanceEntry{}
+	err := ownedObjects(ctx, adjustmentObjectType, accounts, func() interface{} { return new(Adjustment) }, func(v interface{}) {
+		adjustment := v.(*Adjustment)
+		if adjustment.Merchant != merchant || adjustment.Status != AdjustmentApproved || adjustment.Transaction != "" ||
+			!accounts[adjustment.Member] {
+			return
+		}
+
+		entries = append(entries, ProvenanceEntry{
+			Transaction: adjustment.ID,
+			Type:        TypeAdjustment,
+			CreatedAt:   adjustment.ReviewedAt,
+			Sender:      adjustment.Merchant,
+			Receiver:    adjustment.Member,
+			Status:      adjustment.Status,
+			Delta:       adjustment.Value,
+		})
+	})
+
+	return entries, err
+}
+
+// holdEntries returns an entry for each active hold of the accounts, released holds went back
+// to the owner and captured ones are redemption transactions
+func holdEntries(ctx TransactionContext, merchant string, accounts map[string]bool) ([]ProvenanceEntry, error) {
+	entries := []ProvenanceEntry{}
+	err := ownedObjects(ctx, holdObjectType, accounts, func() interface{} { return new(Hold) }, func(v interface{}) {
+		hold := v.(*Hold)
+		if hold.Merchant != merchant || hold.Status != HoldActive || !accounts[hold.Owner] {
+			return
+		}
+
+		entries = append(entries, ProvenanceEntry{
+			Transaction: hold.ID,
+			Type:        provenanceHold,
+			CreatedAt:   hold.CreatedAt,
+			Sender:      hold.Owner,
+			Receiver:    hold.Merchant,
+			Status:      hold.Status,
+			Delta:       -hold.Value,
+		})
+	})
+
+	return entries, err
+}
+
+// BalanceVerification compares a customer's stored balance of a merchant's points with the
+// balance derived from the ledger
+type BalanceVerification struct {
+	Owner    string `json:"owner"`
+	Merchant string `json:"merchant"`
+	Stored   int    `json:"stored"`
+	Derived  int    `json:"derived"`
+	// Discrepancy is the stored balance minus the derived one, 0 if they match
+	Discrepancy int  `json:"discrepancy"`
+	Consistent  bool `json:"consistent"`
+	// Entries is the number of provenance entries the balance was derived from
+	Entries    int    `json:"entries"`
+	VerifiedAt string `json:"verifiedAt"`
+}
+
+// VerifyBalance recomputes a customer's balance of a merchant's points from the ledger, as
+// GetBalanceProvenance does, and reports any discrepancy with the stored balance
+func (s *AdminContract) VerifyBalance(ctx TransactionContext, owner string, merchant string) (*BalanceVerification, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance, err := getBalanceProvenance(ctx, owner, merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BalanceVerification{
+		Owner:       owner,
+		Merchant:    merchant,
+		Stored:      provenance.Balance,
+		Derived:     provenance.Derived,
+		Discrepancy: provenance.Balance - provenance.Derived,
+		Consistent:  provenance.Balance == provenance.Derived,
+		Entries:     len(provenance.Entries),
+		VerifiedAt:  now.Format(time.RFC3339),
+	}, nil
+}
+
+// provenanceAccounts returns owner and the accounts merged into it, directly or through earlier merges
+func provenanceAccounts(ctx TransactionContext, owner string) (map[string]bool, error) {
+	accounts := map[string]bool{owner: true}
+
+	ids, err := getMergedTransactions(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		var transaction PointsTransaction
+		found, err := getObject(ctx, transactionObjectType, id, &transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			continue
+		}
+
+		for _, party := range []string{transaction.Sender, transaction.Receiver} {
+			if accounts[party] {
+				continue
+			}
+
+			merge, err := getAccountMerge(ctx, party)
+			if err != nil {
+				return nil, err
+			}
+
+			if merge != nil {
+				accounts[party] = true
+			}
+		}
+	}
+
+	return accounts, nil
+}
+
+// balanceDelta returns the change a transaction made to the balance of a merchant's points
+// held by accounts, it returns false if the transaction did not change it
+func balanceDelta(transaction *PointsTransaction, merchant string, accounts map[string]bool) (int, bool) {
+	status := transactionStatus(transaction)
+	if status == StatusPending || status == StatusCancelled || status == StatusVoided {
+		return 0, false
+	}
+
+	received := accounts[transaction.Receiver]
+	sent := accounts[transaction.Sender]
+
+	// Conversions move the points of the owner from the transaction merchant to another one
+	if transactionType(transaction) == TypeConversion && sent {
+		if transaction.Merchant == merchant {
+			return -transaction.Value, true
+		}
+
+		if transaction.Source.ID == merchant {
+			return transaction.Converted, true
+		}
+
+		return 0, false
+	}
+
+	if transaction.Merchant != merchant || received == sent {
+		return 0, false
+	}
+
+	// Reversals are stored with the parties of the original transaction
+	delta := transaction.Value
+	if transactionType(transaction) == TypeReversal {
+		delta = -delta
+	}
+
+	if sent {
+		delta = -delta
+	}
+
+	return delta, true
+}
+
+// ownedObjects reads every object of a type listed in the owner index of the accounts into a
+// value returned by newValue and passes it to visit, once even if it lists several accounts
+func ownedObjects(ctx TransactionContext, objectType string, accounts map[string]bool, newValue func() interface{}, visit func(interface{})) error {
+	ids, err := indexedIDs(ctx, objectOwnerIndex, accounts, objectType)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		v := newValue()
+		found, err := getObject(ctx, objectType, id, v)
+		if err != nil {
+			return err
+		}
+
+		if found {
+			visit(v)
+		}
+	}
+
+	return nil
+}
+
+// indexedIDs returns the sorted, distinct IDs ending the keys of an owner index for the
+// accounts, the index keys are the owner, then attributes and the ID
+func indexedIDs(ctx TransactionContext, index string, accounts map[string]bool, attributes ...string) ([]string, error) {
+	seen := map[string]bool{}
+	ids := []string{}
+	for account := range accounts {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, append([]string{account}, attributes...))
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+			}
+
+			_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+			if err != nil {
+				resultsIterator.Close()
+				return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+			}
+
+			id := keyAttributes[len(keyAttributes)-1]
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		resultsIterator.Close()
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// putObjectOwnerIndex adds an object to the owner index of each of its owners
+func putObjectOwnerIndex(ctx TransactionContext, objectType string, id string, owners ...string) error {
+	for _, owner := range owners {
+		key, err := ctx.GetStub().CreateCompositeKey(objectOwnerIndex, []string{owner, objectType, id})
+		if err != nil {
+			return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// reindexObjectOwners adds every stored gift, adjustment and hold to the owner index
+func reindexObjectOwners(ctx TransactionContext) error {
+	var err error
+	visit := func(objectType string, id string, owners ...string) {
+		if err == nil {
+			err = putObjectOwnerIndex(ctx, objectType, id, owners...)
+		}
+	}
+
+	scanErr := scanObjects(ctx, giftObjectType, func() interface{} { return new(Gift) }, func(v interface{}) {
+		gift := v.(*Gift)
+		visit(giftObjectType, gift.ID, gift.Gifter, gift.Giftee)
+	})
+	if scanErr != nil {
+		return scanErr
+	}
+
+	scanErr = scanObjects(ctx, adjustmentObjectType, func() interface{} { return new(Adjustment) }, func(v interface{}) {
+		adjustment := v.(*Adjustment)
+		visit(adjustmentObjectType, adjustment.ID, adjustment.Member)
+	})
+	if scanErr != nil {
+		return scanErr
+	}
+
+	scanErr = scanObjects(ctx, holdObjectType, func() interface{} { return new(Hold) }, func(v interface{}) {
+		hold := v.(*Hold)
+		visit(holdObjectType, hold.ID, hold.Owner)
+	})
+	if scanErr != nil {
+		return scanErr
+	}
+
+	return err
+}
+
+// scanObjects reads every object of a type into a value returned by newValue and passes it to visit
+func scanObjects(ctx TransactionContext, objectType string, newValue func() interface{}, visit func(interface{})) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		v := newValue()
+		err = unmarshalState(objectType, queryResponse.Key, queryResponse.Value, v)
+		if err != nil {
+			return err
+		}
+
+		visit(v)
+	}
+
+	return nil
+}
+
+// getPeriodSummaries returns the period summaries of an owner with a merchant
+func getPeriodSummaries(ctx TransactionContext, merchant string, owner string) ([]*PeriodSummary, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(periodSummaryObjectType, []string{merchant, owner})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	summaries := []*PeriodSummary{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		summary := new(PeriodSummary)
+		err = unmarshalState(periodSummaryObjectType, queryResponse.Key, queryResponse.Value, summary)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
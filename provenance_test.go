@@ -0,0 +1,100 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceProvenance(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	first := env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 40, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	provenance, err := env.points.GetBalanceProvenance(env.ctx(alice), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, 60, provenance.Balance)
+	require.Equal(t, 60, provenance.Derived)
+	require.Len(t, provenance.Entries, 2)
+	require.Equal(t, first, provenance.Entries[0].Transaction)
+	require.Equal(t, 100, provenance.Entries[0].Delta)
+	require.Equal(t, -40, provenance.Entries[1].Delta)
+	require.Equal(t, 60, provenance.Entries[1].Balance)
+
+	_, err = env.points.GetBalanceProvenance(env.ctx(alice), "m1", "m1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.points.GetBalanceProvenance(env.ctx(alice), "nobody", "m1")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestVerifyBalance(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	_, err := env.admin.VerifyBalance(env.ctx(merchantIdentity), "alice", "m1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	verification, err := env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.True(t, verification.Consistent)
+	require.Equal(t, 100, verification.Stored)
+	require.Equal(t, 1, verification.Entries)
+}
+
+func TestVerifyBalanceCountsAdjustmentsOnce(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	require.NoError(t, env.admin.ProposeAdjustment(env.ctx(adminIdentity), "a1", "alice", "m1", -30, "correction"))
+	require.NoError(t, env.admin.ApproveAdjustment(env.ctx(otherAdmin), "a1"))
+
+	verification, err := env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.True(t, verification.Consistent)
+	require.Equal(t, 70, verification.Stored)
+	require.Equal(t, 2, verification.Entries)
+}
+
+func TestVerifyBalanceReindexedHolds(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 50)
+	alice := env.registerAccount("alice")
+	id, err := env.points.HoldPoints(env.ctx(alice), "alice", 40, "checkout1", 600)
+	require.NoError(t, err)
+
+	verification, err := env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.True(t, verification.Consistent)
+	require.Equal(t, 60, verification.Stored)
+	require.Equal(t, 2, verification.Entries)
+
+	// Drop the owner index entry, as for a hold stored before the index existed
+	key, err := env.stub.CreateCompositeKey(objectOwnerIndex, []string{"alice", holdObjectType, id})
+	require.NoError(t, err)
+	env.stub.startTransaction("unindex")
+	require.NoError(t, env.stub.DelState(key))
+
+	verification, err = env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.False(t, verification.Consistent)
+	require.Equal(t, 100, verification.Derived)
+
+	_, err = env.admin.ReindexTransactions(env.ctx(adminIdentity))
+	require.NoError(t, err)
+
+	verification, err = env.admin.VerifyBalance(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.True(t, verification.Consistent)
+	require.Equal(t, 2, verification.Entries)
+}
@@ -0,0 +1,100 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// purgeSeparator separates the object type and attributes of composite keys in purge prefixes
+const purgeSeparator = "/"
+
+// PurgeResult reports a batch of PurgeByPrefix
+type PurgeResult struct {
+	Purged int `json:"purged"`
+	// Bookmark is passed to the next call to continue after this batch, it is empty once
+	// no matching keys are left
+	Bookmark string `json:"bookmark"`
+}
+
+// PurgeByPrefix deletes up to limit keys matching prefix, to remove test and seed data. A prefix
+// with a slash matches composite keys: the object type, the complete attributes and the start
+// of the next one, separated by slashes, such as "transaction/uat-" or "settlement/uat-shop/".
+// A prefix without a slash matches keys stored without object type. Only admins of the operator
+// organization may purge, and they must also purge the index entries of the records they purge.
+// The audit log cannot be purged.
+func (s *AdminContract) PurgeByPrefix(ctx TransactionContext, prefix string, limit int, bookmark string) (*PurgeResult, error) {
+	err := assertOperator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		return nil, newError(ErrInvalidArgument, "limit must be positive")
+	}
+
+	var resultsIterator shim.StateQueryIteratorInterface
+	matches := func(string) bool { return true }
+
+	if strings.Contains(prefix, purgeSeparator) {
+		parts := strings.Split(prefix, purgeSeparator)
+		objectType, attributes, partial := parts[0], parts[1:len(parts)-1], parts[len(parts)-1]
+		if objectType == "" {
+			return nil, newError(ErrInvalidArgument, "prefix %s has no object type", prefix)
+		}
+
+		if objectType == auditObjectType {
+			return nil, newError(ErrInvalidArgument, "the audit log cannot be purged")
+		}
+
+		resultsIterator, err = ctx.GetStub().GetStateByPartialCompositeKey(objectType, attributes)
+		matches = func(key string) bool {
+			_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(key)
+			return err == nil && len(keyAttributes) > len(attributes) && strings.HasPrefix(keyAttributes[len(attributes)], partial)
+		}
+	} else {
+		start := prefix
+		if bookmark > start {
+			start = bookmark
+		}
+
+		resultsIterator, err = ctx.GetStub().GetStateByRange(start, prefix+string(utf8.MaxRune))
+	}
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	result := PurgeResult{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		if queryResponse.Key <= bookmark || !matches(queryResponse.Key) {
+			continue
+		}
+
+		// The bookmark is only returned when a further matching key exists
+		if result.Purged == limit {
+			return &result, nil
+		}
+
+		err = ctx.GetStub().DelState(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		result.Purged++
+		result.Bookmark = queryResponse.Key
+	}
+
+	result.Bookmark = ""
+	return &result, nil
+}
@@ -0,0 +1,57 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeByPrefix(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.admin.PurgeByPrefix(env.ctx(adminIdentity), "transaction/uat-", 2, "")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	env.registerMerchant("m1")
+	for _, id := range []string{"uat-1", "uat-2", "uat-3", "prod-1"} {
+		_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), id, "m1", "alice", 10, "m1", "", TypeOrder, id)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, env.admin.SetOperatorMSP(env.ctx(adminIdentity), "Org1MSP"))
+
+	msp, err := env.admin.GetOperatorMSP(env.ctx(supportIdentity))
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", msp)
+
+	_, err = env.admin.PurgeByPrefix(env.ctx(otherAdmin), "transaction/uat-", 2, "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.PurgeByPrefix(env.ctx(adminIdentity), "transaction/uat-", 0, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.PurgeByPrefix(env.ctx(adminIdentity), "/uat-", 2, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	result, err := env.admin.PurgeByPrefix(env.ctx(adminIdentity), "transaction/uat-", 2, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Purged)
+	require.NotEmpty(t, result.Bookmark)
+
+	result, err = env.admin.PurgeByPrefix(env.ctx(adminIdentity), "transaction/uat-", 2, result.Bookmark)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Purged)
+	require.Empty(t, result.Bookmark)
+
+	for _, id := range []string{"uat-1", "uat-2", "uat-3"} {
+		_, err = env.points.GetTransaction(env.ctx(adminIdentity), id)
+		requireErrorCode(t, err, ErrNotFound)
+	}
+
+	_, err = env.points.GetTransaction(env.ctx(adminIdentity), "prod-1")
+	require.NoError(t, err)
+}
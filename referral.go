@@ -0,0 +1,248 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const referralObjectType = "referral"
+
+// Referral records that a customer referred another customer to a merchant. Both are credited
+// the referral points of the merchant when the first order of the referee is rewarded.
+type Referral struct {
+	Schema
+	Merchant   string `json:"merchant"`
+	Referrer   string `json:"referrer"`
+	Referee    string `json:"referee"`
+	RecordedBy string `json:"recordedBy"`
+	RecordedAt string `json:"recordedAt"`
+	// Order is the transaction which rewarded the first order of the referee, "" until then
+	Order      string `json:"order,omitempty" metadata:"order,optional"`
+	RewardedAt string `json:"rewardedAt,omitempty" metadata:"rewardedAt,optional"`
+}
+
+// SetReferralPoints sets the number of points a merchant credits to the referrer and to the
+// referee when a referred customer's first order is rewarded, 0 credits nothing
+func (s *MerchantContract) SetReferralPoints(ctx TransactionContext, merchantID string, referrerPoints int, refereePoints int) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if referrerPoints < 0 || refereePoints < 0 {
+		return newError(ErrInvalidArgument, "referral points must not be negative")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.ReferrerPoints = referrerPoints
+	merchant.Program.RefereePoints = refereePoints
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// RecordReferral records that the referrer referred the referee to a merchant. A referee can
+// be referred once per merchant, and only before it earned points of the merchant.
+func (s *PointsContract) RecordReferral(ctx TransactionContext, referrer string, referee string, merchantID string) error {
+	_, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = assertMerchantActive(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if referrer == referee {
+		return newError(ErrInvalidArgument, "%s cannot refer itself", referrer)
+	}
+
+	existing, err := getReferral(ctx, merchantID, referee)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return newError(ErrAlreadyExists, "%s was already referred to %s by %s", referee, merchantID, existing.Referrer).
+			WithDetail("referrer", existing.Referrer)
+	}
+
+	referrerMember, err := getMember(ctx, referrer)
+	if err != nil {
+		return err
+	}
+
+	if referrerMember.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant, only customers can refer", referrer)
+	}
+
+	// The referee may not have an account yet
+	refereeMember, err := getMember(ctx, referee)
+	if err == nil {
+		if refereeMember.Merchant == "" {
+			return newError(ErrInvalidArgument, "%s is a merchant, only customers can be referred", referee)
+		}
+
+		if _, earned := refereeMember.MerchantPoints[merchantID]; earned {
+			return newError(ErrInvalidState, "%s is already a customer of %s", referee, merchantID)
+		}
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	referral := Referral{
+		Merchant:   merchantID,
+		Referrer:   referrer,
+		Referee:    referee,
+		RecordedBy: clientID,
+		RecordedAt: now.Format(time.RFC3339),
+	}
+
+	return putCompositeObject(ctx, referralObjectType, []string{merchantID, referee}, &referral)
+}
+
+// GetReferral returns the referral of a customer to a merchant
+func (s *PointsContract) GetReferral(ctx TransactionContext, merchantID string, referee string) (*Referral, error) {
+	referral, err := getReferral(ctx, merchantID, referee)
+	if err != nil {
+		return nil, err
+	}
+
+	if referral == nil {
+		return nil, newError(ErrNotFound, "%s was not referred to %s", referee, merchantID)
+	}
+
+	return referral, nil
+}
+
+// getReferral returns the referral of a customer to a merchant, or nil if there is none
+func getReferral(ctx TransactionContext, merchantID string, referee string) (*Referral, error) {
+	var referral Referral
+	found, err := getCompositeObject(ctx, referralObjectType, []string{merchantID, referee}, &referral)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &referral, nil
+}
+
+// rewardReferral credits the referral points of the merchant to both customers of a referral
+// when the confirmed transaction rewards the referee's first order at the merchant
+func rewardReferral(ctx TransactionContext, order *PointsTransaction) error {
+	if !isOrderReward(order) || transactionStatus(order) != StatusConfirmed {
+		return nil
+	}
+
+	referral, err := getReferral(ctx, order.Merchant, order.Receiver)
+	if err != nil || referral == nil || referral.Order != "" {
+		return err
+	}
+
+	merchant, err := getMerchant(ctx, order.Merchant)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bonus := range []struct {
+		suffix   string
+		receiver string
+		value    int
+	}{
+		{"referrer", referral.Referrer, merchant.Program.ReferrerPoints},
+		{"referee", referral.Referee, merchant.Program.RefereePoints},
+	} {
+		if bonus.value <= 0 {
+			continue
+		}
+
+		transaction := PointsTransaction{
+			ID:        order.ID + "-" + bonus.suffix,
+			Value:     bonus.value,
+			Merchant:  order.Merchant,
+			CreatedAt: now.Format(time.RFC3339),
+			Sender:    order.Merchant,
+			Receiver:  bonus.receiver,
+			Source:    &Source{Type: TypeReferral, ID: order.ID},
+			Status:    StatusConfirmed,
+		}
+
+		err = createTransaction(ctx, &transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	referral.Order = order.ID
+	referral.RewardedAt = now.Format(time.RFC3339)
+
+	return putCompositeObject(ctx, referralObjectType, []string{referral.Merchant, referral.Referee}, referral)
+}
+
+// reverseReferral reverses the referral bonuses credited for a reversed order, with reversals
+// whose IDs append -referrer or -referee to id, so that the next order of the referee earns them
+func reverseReferral(ctx TransactionContext, order *PointsTransaction, id string, reason string) error {
+	if !isOrderReward(order) {
+		return nil
+	}
+
+	referral, err := getReferral(ctx, order.Merchant, order.Receiver)
+	if err != nil || referral == nil || referral.Order != order.ID {
+		return err
+	}
+
+	for _, suffix := range []string{"referrer", "referee"} {
+		var bonus PointsTransaction
+		found, err := getObject(ctx, transactionObjectType, order.ID+"-"+suffix, &bonus)
+		if err != nil {
+			return err
+		}
+
+		// No bonus is credited for 0 points, and a bonus may have been reversed or voided on its own
+		if !found || bonus.ReversedBy != "" || transactionStatus(&bonus) != StatusConfirmed {
+			continue
+		}
+
+		_, err = reverseTransaction(ctx, &bonus, id+"-"+suffix, reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	referral.Order = ""
+	referral.RewardedAt = ""
+
+	return putCompositeObject(ctx, referralObjectType, []string{referral.Merchant, referral.Referee}, referral)
+}
@@ -0,0 +1,107 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReferral(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	err := env.points.RecordReferral(env.ctx(otherMSPIdentity), "alice", "bob", "m1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "alice", "m1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.RecordReferral(env.ctx(merchantIdentity), "m1", "bob", "m1")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.RecordReferral(env.ctx(merchantIdentity), "nobody", "bob", "m1")
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "bob", "m1"))
+
+	referral, err := env.points.GetReferral(env.ctx(merchantIdentity), "m1", "bob")
+	require.NoError(t, err)
+	require.Equal(t, "alice", referral.Referrer)
+	require.Empty(t, referral.Order)
+
+	err = env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "bob", "m1")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	env.reward("m1", "carol", 10)
+	err = env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "carol", "m1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	_, err = env.points.GetReferral(env.ctx(merchantIdentity), "m1", "carol")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestReferralRewardsFirstOrder(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	err := env.merchants.SetReferralPoints(env.ctx(otherMSPIdentity), "m1", 50, 20)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetReferralPoints(env.ctx(merchantIdentity), "m1", -1, 20)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.merchants.SetReferralPoints(env.ctx(merchantIdentity), "m1", 50, 20))
+	require.NoError(t, env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "bob", "m1"))
+
+	order := env.reward("m1", "bob", 5)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 25, env.balance("bob", "m1"))
+
+	referral, err := env.points.GetReferral(env.ctx(merchantIdentity), "m1", "bob")
+	require.NoError(t, err)
+	require.Equal(t, order, referral.Order)
+
+	env.reward("m1", "bob", 5)
+	require.Equal(t, 60, env.balance("alice", "m1"), "only the first order is rewarded")
+}
+
+func TestReverseTransactionReversesReferralBonuses(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	require.NoError(t, env.merchants.SetReferralPoints(env.ctx(merchantIdentity), "m1", 50, 20))
+	require.NoError(t, env.points.RecordReferral(env.ctx(merchantIdentity), "alice", "bob", "m1"))
+	order := env.reward("m1", "bob", 5)
+
+	id, err := env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "refunded")
+	require.NoError(t, err)
+	require.Equal(t, 10, env.balance("alice", "m1"))
+	require.Equal(t, 0, env.balance("bob", "m1"))
+
+	bonus, err := env.points.GetTransaction(env.ctx(adminIdentity), order+"-referrer")
+	require.NoError(t, err)
+	require.Equal(t, id+"-referrer", bonus.ReversedBy)
+
+	referral, err := env.points.GetReferral(env.ctx(merchantIdentity), "m1", "bob")
+	require.NoError(t, err)
+	require.Empty(t, referral.Order)
+
+	// The next order of the referee earns the bonuses again
+	order = env.reward("m1", "bob", 5)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 25, env.balance("bob", "m1"))
+
+	// A bonus already spent cannot be taken back, so the order cannot be reversed
+	alice := env.registerAccount("alice")
+	_, err = env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 60, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "refunded")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+}
@@ -0,0 +1,104 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// ReverseTransaction undoes the balance effect of a transaction, e.g. when an order is refunded,
+// and records a reversal transaction linked to the original one. Reversing an order reward also
+// drops the commission of its stockist and reverses the referral bonuses it triggered. It returns
+// the reversal ID.
+func (s *MerchantContract) ReverseTransaction(ctx TransactionContext, originalTxKey string, reason string) (string, error) {
+	original, err := getTransaction(ctx, originalTxKey)
+	if err != nil {
+		return "", err
+	}
+
+	if original.ReversedBy != "" {
+		return "", newError(ErrInvalidState, "transaction %s has already been reversed by %s", original.ID, original.ReversedBy)
+	}
+
+	if original.Source != nil && original.Source.Type == TypeReversal {
+		return "", newError(ErrInvalidState, "transaction %s is a reversal and cannot be reversed", original.ID)
+	}
+
+	// The points of a lock are minted on another channel, a conversion moved points between two
+	// merchants and a burn removed them from the issued total, none is undone by a reversal
+	switch transactionType(original) {
+	case TypeBridgeLock:
+		return "", newError(ErrInvalidState, "transaction %s locked points bridged to channel %s and cannot be reversed", original.ID, original.Source.ID)
+	case TypeConversion, TypeBurn:
+		return "", newError(ErrInvalidState, "%s transaction %s cannot be reversed", transactionType(original), original.ID)
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, original.Merchant)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	reversal, err := reverseTransaction(ctx, original, ctx.GetStub().GetTxID(), reason)
+	if err != nil {
+		return "", err
+	}
+
+	// The refunded order no longer earns the commission of its stockist nor the referral bonuses
+	err = dropCommission(ctx, original)
+	if err != nil {
+		return "", err
+	}
+
+	err = reverseReferral(ctx, original, reversal.ID, reason)
+	if err != nil {
+		return "", err
+	}
+
+	return reversal.ID, nil
+}
+
+// reverseTransaction records the reversal id of a confirmed transaction and undoes its balance effect
+func reverseTransaction(ctx TransactionContext, original *PointsTransaction, id string, reason string) (*PointsTransaction, error) {
+	err := transitionStatus(original, StatusReversed)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal := PointsTransaction{
+		ID:        id,
+		Value:     original.Value,
+		Merchant:  original.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    original.Sender,
+		Receiver:  original.Receiver,
+		Source:    &Source{Type: TypeReversal, ID: original.ID},
+		Status:    StatusConfirmed,
+		Reason:    reason,
+	}
+
+	err = applyTransaction(ctx, &reversal, -original.Value, original.Merchant)
+	if err != nil {
+		return nil, err
+	}
+
+	original.ReversedBy = reversal.ID
+
+	err = putTransaction(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putTransaction(ctx, &reversal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reversal, nil
+}
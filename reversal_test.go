@@ -0,0 +1,84 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	order := env.reward("m1", "alice", 30)
+
+	_, err := env.merchants.ReverseTransaction(env.ctx(otherMSPIdentity), order, "returned")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), "missing", "returned")
+	requireErrorCode(t, err, ErrNotFound)
+
+	id, err := env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "returned")
+	require.NoError(t, err)
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	original, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, id, original.ReversedBy)
+
+	reversal, err := env.points.GetTransaction(env.ctx(adminIdentity), id)
+	require.NoError(t, err)
+	require.Equal(t, order, reversal.Source.ID)
+	require.Equal(t, "returned", reversal.Reason)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "returned")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), id, "returned")
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestReverseTransactionInsufficientPoints(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 90, "m1", "", "Redemption", "")
+	require.NoError(t, err)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "returned")
+	requireErrorCode(t, err, ErrInsufficientPoints)
+}
+
+func TestReverseTransactionRejectsConversionsAndBurns(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.registerMerchant("m2")
+	env.reward("m1", "alice", 100)
+	env.reward("m2", "bob", 10)
+	alice := env.registerAccount("alice")
+	require.NoError(t, env.admin.SetExchangeRate(env.ctx(adminIdentity), "m1", "m2", 1, 2))
+
+	_, err := env.points.ConvertPoints(env.ctx(alice), "alice", "m1", "m2", 40)
+	require.NoError(t, err)
+	conversion := env.lastTxID()
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), conversion, "returned")
+	requireErrorCode(t, err, ErrInvalidState)
+	require.Equal(t, 60, env.balance("alice", "m1"))
+	require.Equal(t, 20, env.balance("alice", "m2"))
+
+	burn, err := env.admin.BurnPoints(env.ctx(adminIdentity), "alice", "m1", 10, "fraud")
+	require.NoError(t, err)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), burn, "returned")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.admin.VoidTransaction(env.ctx(adminIdentity), burn, "error")
+	requireErrorCode(t, err, ErrInvalidState)
+	require.Equal(t, 50, env.balance("alice", "m1"))
+}
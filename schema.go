@@ -0,0 +1,354 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// currentSchemaVersion is the layout version of the assets written by this chaincode.
+// Version 1 records have no schema fields, may store the transaction value as a string
+// and may store the transaction status in the source type. Version 2 records may store
+// the creation date of transactions as YYYYMMDD or with a time zone offset.
+const currentSchemaVersion = 3
+
+// Schema identifies the kind and layout version of a stored asset
+type Schema struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// setSchema stamps the asset with its type and the current schema version
+func (s *Schema) setSchema(docType string) {
+	s.DocType = docType
+	s.SchemaVersion = currentSchemaVersion
+}
+
+type versioned interface {
+	setSchema(docType string)
+}
+
+// schemaUpgrades lists the object types stored by the chaincode and how to bring a record of
+// each type from its version to the current schema, nil for types which are not versioned
+var schemaUpgrades = map[string]func(record map[string]interface{}, version int64) error{
+	memberObjectType:         upgradeMember,
+	transactionObjectType:    upgradeTransaction,
+	merchantObjectType:       upgradeNone,
+	giftObjectType:           upgradeNone,
+	adjustmentObjectType:     upgradeNone,
+	campaignObjectType:       upgradeNone,
+	holdObjectType:           upgradeNone,
+	voucherObjectType:        upgradeNone,
+	configObjectType:         upgradeNone,
+	freezeObjectType:         upgradeNone,
+	exchangeRateObjectType:   upgradeNone,
+	birthdayObjectType:       upgradeNone,
+	tierObjectType:           upgradeNone,
+	periodSummaryObjectType:  upgradeNone,
+	programStatsObjectType:   upgradeNone,
+	velocityLimitsObjectType: upgradeNone,
+	velocityUsageObjectType:  upgradeNone,
+	accountMergeObjectType:   upgradeNone,
+	lifeCardObjectType:       upgradeNone,
+	bridgeReceiptObjectType:  upgradeNone,
+	bridgeMintObjectType:     upgradeNone,
+	balanceDeltaObjectType:   upgradeNone,
+	counterDeltaObjectType:   upgradeNone,
+	referralObjectType:       upgradeNone,
+	auditObjectType:          upgradeNone,
+	accountRequestObjectType: upgradeNone,
+	commissionObjectType:     upgradeNone,
+	giftBalanceObjectType:    upgradeNone,
+
+	// Records holding a plain value, such as an ID, a number of points or a hash, have no
+	// schema and are only rewritten by MigrateRange when their codec changes
+	allowanceObjectType:   nil,
+	orderObjectType:       nil,
+	settlementObjectType:  nil,
+	idempotencyObjectType: nil,
+	memberHashObjectType:  nil,
+	giftHashObjectType:    nil,
+	accountObjectType:     nil,
+	accountOwnerType:      nil,
+}
+
+// MigrationResult reports a batch of MigrateRange
+type MigrationResult struct {
+	Migrated int `json:"migrated"`
+	// Bookmark is passed to the next call to continue after this batch, it is empty once the
+	// range is fully migrated
+	Bookmark string `json:"bookmark"`
+}
+
+// MigrateRange reads up to limit records of the object types from start until end exclusive,
+// an empty end covering all remaining types, starting after the bookmark of the previous batch.
+// It rewrites the records stored with an older schema in the current schema, and the records
+// stored with another codec in the codec set with SetStateCodec.
+func (s *AdminContract) MigrateRange(ctx TransactionContext, start string, end string, limit int, bookmark string) (*MigrationResult, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		return nil, newError(ErrInvalidArgument, "limit must be positive")
+	}
+
+	// Bookmarks are composite keys, which start and end with a NUL byte
+	bookmarkType := ""
+	if bookmark != "" {
+		if len(bookmark) < 2 || bookmark[0] != 0 || bookmark[len(bookmark)-1] != 0 {
+			return nil, newError(ErrInvalidArgument, "invalid bookmark %q", bookmark)
+		}
+
+		bookmarkType, _, err = ctx.GetStub().SplitCompositeKey(bookmark)
+		if err != nil || bookmarkType == "" {
+			return nil, newError(ErrInvalidArgument, "invalid bookmark %q", bookmark)
+		}
+	}
+
+	objectTypes := []string{}
+	for objectType := range schemaUpgrades {
+		if objectType >= start && objectType >= bookmarkType && (end == "" || objectType < end) {
+			objectTypes = append(objectTypes, objectType)
+		}
+	}
+	sort.Strings(objectTypes)
+
+	result := MigrationResult{}
+	for _, objectType := range objectTypes {
+		// The next batch starts with this type
+		if limit == 0 {
+			result.Bookmark, err = ctx.GetStub().CreateCompositeKey(objectType, []string{})
+			if err != nil {
+				return nil, newError(ErrInternal, "failed to create composite key. %s", err.Error())
+			}
+			break
+		}
+
+		typeBookmark := ""
+		if objectType == bookmarkType {
+			typeBookmark = bookmark
+		}
+
+		migrated, read, next, err := migrateObjectType(ctx, objectType, limit, typeBookmark)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Migrated += migrated
+		limit -= read
+		if next != "" {
+			result.Bookmark = next
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// migrateObjectType reads up to limit records of an object type after the bookmark, the key
+// of the last record of the previous batch, and rewrites those which are outdated. It returns
+// the number of records rewritten and read, and the key of the last record read if more
+// records follow. Fabric does not allow paginated queries in transactions which write, so the
+// keys up to the bookmark are skipped without reading the records.
+func migrateObjectType(ctx TransactionContext, objectType string, limit int, bookmark string) (int, int, string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return 0, 0, "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	migrated, read, last := 0, 0, ""
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, 0, "", newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		if queryResponse.Key <= bookmark {
+			continue
+		}
+
+		// The bookmark is only returned when a further record exists
+		if read == limit {
+			return migrated, read, last, nil
+		}
+
+		read++
+		last = queryResponse.Key
+
+		upgraded, err := upgradeRecord(objectType, queryResponse.Value)
+		if err != nil {
+			return 0, 0, "", err
+		}
+
+		// Records are also rewritten when they were stored with another codec
+		encoded, err := encodeState(ctx, objectType, upgraded)
+		if err != nil {
+			return 0, 0, "", err
+		}
+
+		if bytes.Equal(encoded, queryResponse.Value) {
+			continue
+		}
+
+		err = ctx.GetStub().PutState(queryResponse.Key, encoded)
+		if err != nil {
+			return 0, 0, "", newError(ErrInternal, "failed to put to world state. %s", err.Error())
+		}
+
+		migrated++
+	}
+
+	return migrated, read, "", nil
+}
+
+// upgradeRecord returns the JSON document of a stored record of objectType in the current
+// schema, records which are already current or not versioned are returned unchanged
+func upgradeRecord(objectType string, value []byte) ([]byte, error) {
+	value, err := decodeDocument(value)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade, ok := schemaUpgrades[objectType]
+	if !ok || upgrade == nil {
+		return value, nil
+	}
+
+	record, err := decodeRecord(value)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to unmarshal %s. %s", objectType, err.Error())
+	}
+
+	version := recordVersion(record)
+	if version >= currentSchemaVersion {
+		return value, nil
+	}
+
+	err = upgrade(record, version)
+	if err != nil {
+		return nil, err
+	}
+
+	record["docType"] = objectType
+	record["schemaVersion"] = currentSchemaVersion
+
+	upgraded, err := json.Marshal(record)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to marshal %s. %s", objectType, err.Error())
+	}
+
+	return upgraded, nil
+}
+
+// decodeRecord decodes a JSON object keeping numbers exact
+func decodeRecord(value []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
+	record := map[string]interface{}{}
+	err := decoder.Decode(&record)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := record["schemaVersion"].(json.Number); !ok {
+		record["schemaVersion"] = json.Number("1")
+	}
+
+	return record, nil
+}
+
+// recordVersion returns the schema version of a decoded record, 1 for records without one
+func recordVersion(record map[string]interface{}) int64 {
+	if number, ok := record["schemaVersion"].(json.Number); ok {
+		version, _ := number.Int64()
+		return version
+	}
+
+	return 1
+}
+
+func upgradeNone(record map[string]interface{}, version int64) error {
+	return nil
+}
+
+func upgradeMember(record map[string]interface{}, version int64) error {
+	if version < 2 && record["merchantPoints"] == nil {
+		record["merchantPoints"] = map[string]interface{}{}
+	}
+
+	if transaction, ok := record["transaction"].(map[string]interface{}); ok {
+		err := upgradeTransaction(transaction, recordVersion(transaction))
+		if err != nil {
+			return err
+		}
+
+		transaction["docType"] = transactionObjectType
+		transaction["schemaVersion"] = currentSchemaVersion
+	}
+
+	return nil
+}
+
+func upgradeTransaction(record map[string]interface{}, version int64) error {
+	if version < 2 {
+		err := upgradeTransactionV1(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	if createdAt, ok := record["created_at"].(string); ok {
+		record["created_at"] = normalizeDate(createdAt)
+	}
+
+	return nil
+}
+
+func upgradeTransactionV1(record map[string]interface{}) error {
+	if value, ok := record["value"].(string); ok {
+		points, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return newError(ErrInternal, "invalid value %q of transaction %v. %s", value, record["ID"], err.Error())
+		}
+
+		record["value"] = points
+	}
+
+	// Version 1 kept the status of a transaction in its source type, the type is then derived
+	// from the direction of the transaction
+	source, _ := record["source"].(map[string]interface{})
+	status, _ := record["status"].(string)
+	if source != nil && status == "" {
+		sourceType, _ := source["type"].(string)
+		if _, ok := statusTransitions[strings.ToLower(sourceType)]; ok {
+			record["status"] = strings.ToLower(sourceType)
+			source["type"] = defaultTransactionType(record)
+		}
+	}
+
+	return nil
+}
+
+// defaultTransactionType returns the type of a transaction stored without one: points sent by
+// the merchant were issued, points sent to it were redeemed and other points were transferred
+func defaultTransactionType(record map[string]interface{}) string {
+	merchant, _ := record["merchant"].(string)
+	switch {
+	case merchant != "" && record["sender"] == merchant:
+		return TypeIssue
+	case merchant != "" && record["receiver"] == merchant:
+		return TypeRedemption
+	default:
+		return TypeTransfer
+	}
+}
@@ -0,0 +1,129 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateRange(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 10)
+
+	// A transaction written before the schema version was stored
+	key, err := env.stub.CreateCompositeKey(transactionObjectType, []string{order})
+	require.NoError(t, err)
+	env.stub.startTransaction("legacy")
+	require.NoError(t, env.stub.PutState(key, []byte(`{"ID":"`+order+`","value":"10","merchant":"m1","sender":"m1","receiver":"alice"}`)))
+
+	_, err = env.admin.MigrateRange(env.ctx(merchantIdentity), "", "", 10, "")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.admin.MigrateRange(env.ctx(adminIdentity), "", "", 0, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.admin.MigrateRange(env.ctx(adminIdentity), "", "", 10, "not a key")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	result, err := env.admin.MigrateRange(env.ctx(adminIdentity), transactionObjectType, transactionObjectType+"\x00", 10, "")
+	require.NoError(t, err)
+	require.Equal(t, MigrationResult{Migrated: 1}, *result)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, 10, transaction.Value)
+	require.Equal(t, currentSchemaVersion, transaction.SchemaVersion)
+
+	result, err = env.admin.MigrateRange(env.ctx(adminIdentity), "", "", 100, "")
+	require.NoError(t, err)
+	require.Equal(t, MigrationResult{}, *result)
+}
+
+func TestMigrateRangeBookmark(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	// Transactions written before the schema version was stored, between current ones
+	env.stub.startTransaction("legacy")
+	for _, id := range []string{"o1", "o3", "o5"} {
+		key, err := env.stub.CreateCompositeKey(transactionObjectType, []string{id})
+		require.NoError(t, err)
+		require.NoError(t, env.stub.PutState(key, []byte(`{"ID":"`+id+`","value":"10","merchant":"m1","sender":"m1","receiver":"alice"}`)))
+	}
+	env.reward("m1", "alice", 10)
+
+	migrated := 0
+	bookmark := ""
+	for batch := 0; batch == 0 || bookmark != ""; batch++ {
+		require.Less(t, batch, 3, "each batch continues after the previous one")
+
+		result, err := env.admin.MigrateRange(env.ctx(adminIdentity), transactionObjectType, transactionObjectType+"\x00", 2, bookmark)
+		require.NoError(t, err)
+		migrated += result.Migrated
+		bookmark = result.Bookmark
+	}
+	require.Equal(t, 3, migrated)
+
+	for _, id := range []string{"o1", "o3", "o5"} {
+		transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), id)
+		require.NoError(t, err)
+		require.Equal(t, currentSchemaVersion, transaction.SchemaVersion)
+	}
+
+	// A batch ending with an object type continues with the next one
+	result, err := env.admin.MigrateRange(env.ctx(adminIdentity), "", "", 1, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Bookmark)
+}
+
+func TestUpgradeRecord(t *testing.T) {
+	upgraded, err := upgradeRecord(memberObjectType, []byte(`{"ID":"alice","merchant":"m1","points":5,"transaction":{"ID":"t1","value":"5","source":{"type":"Confirmed"}}}`))
+	require.NoError(t, err)
+
+	var member Member
+	require.NoError(t, json.Unmarshal(upgraded, &member))
+	require.NotNil(t, member.MerchantPoints)
+	require.Equal(t, 5, member.Transaction.Value)
+	require.Equal(t, StatusConfirmed, member.Transaction.Status)
+	require.Equal(t, TypeTransfer, member.Transaction.Source.Type)
+	require.Equal(t, currentSchemaVersion, member.SchemaVersion)
+
+	upgraded, err = upgradeRecord(transactionObjectType, []byte(`{"ID":"t3","value":5,"merchant":"m1","sender":"m1","receiver":"alice","source":{"type":"Pending"}}`))
+	require.NoError(t, err)
+
+	var transaction PointsTransaction
+	require.NoError(t, json.Unmarshal(upgraded, &transaction))
+	require.Equal(t, StatusPending, transaction.Status)
+	require.Equal(t, TypeIssue, transaction.Source.Type)
+
+	_, err = upgradeRecord(transactionObjectType, []byte(`{"ID":"t2","value":"ten"}`))
+	requireErrorCode(t, err, ErrInternal)
+}
+
+func TestMigrateRangeKeepsPlainValues(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 10)
+	require.NoError(t, env.admin.SetStateCodec(env.ctx(adminIdentity), codecProtobuf))
+
+	for _, objectType := range []string{orderObjectType, settlementObjectType, accountOwnerType, giftHashObjectType} {
+		require.Contains(t, schemaUpgrades, objectType)
+	}
+
+	_, err := env.admin.MigrateRange(env.ctx(adminIdentity), "", "", 100, "")
+	require.NoError(t, err)
+
+	rewardedBy, err := env.points.GetOrderReward(env.ctx(merchantIdentity), "m1", order)
+	require.NoError(t, err)
+	require.Equal(t, order, rewardedBy)
+
+	report, err := env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", "2024-03", "2024-03")
+	require.NoError(t, err)
+	require.Equal(t, 10, report.Total.Issued)
+}
@@ -0,0 +1,200 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ledgerSeedTransientKey is the transient field carrying the LedgerSeed of InitLedger
+const ledgerSeedTransientKey = "ledger_seed"
+
+// SeedMerchant describes a merchant to create on InitLedger
+type SeedMerchant struct {
+	ID     string `json:"ID"`
+	Name   string `json:"name"`
+	MSP    string `json:"msp"`
+	Locale string `json:"locale"`
+	Points int    `json:"points"`
+}
+
+// LedgerSeed describes the initial merchants and customer accounts with their opening balances
+type LedgerSeed struct {
+	Merchants []SeedMerchant `json:"merchants"`
+	Members   []Member       `json:"members"`
+}
+
+// getLedgerSeed returns the seed passed in transient data, or nil if there is none
+func getLedgerSeed(ctx TransactionContext) (*LedgerSeed, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to get transient data. %s", err.Error())
+	}
+
+	seedAsBytes, ok := transientMap[ledgerSeedTransientKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var seed LedgerSeed
+	err = json.Unmarshal(seedAsBytes, &seed)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "failed to parse ledger seed. %s", err.Error())
+	}
+
+	return &seed, nil
+}
+
+// seedLedger validates the seed and writes its merchants and members
+func seedLedger(ctx TransactionContext, seed *LedgerSeed) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = validateLedgerSeed(seed)
+	if err != nil {
+		return err
+	}
+
+	err = registerMerchants(ctx, seed.Merchants)
+	if err != nil {
+		return err
+	}
+
+	// The merchants registered above are not readable before the end of the transaction, so
+	// the locales of the members are taken from the seed
+	locales := map[string]string{}
+
+	for _, merchant := range seed.Merchants {
+		locales[merchant.ID] = merchant.Locale
+
+		err = putMember(ctx, &Member{ID: merchant.ID, Points: merchant.Points, MerchantPoints: map[string]int{}, Locale: merchant.Locale})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range seed.Members {
+		member := &seed.Members[i]
+		if member.MerchantPoints == nil {
+			member.MerchantPoints = map[string]int{}
+		}
+
+		if member.Locale == "" {
+			member.Locale = locales[member.Merchant]
+		}
+
+		err = putMember(ctx, member)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registerMerchants adds the merchants to the merchant registry
+func registerMerchants(ctx TransactionContext, merchants []SeedMerchant) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, seedMerchant := range merchants {
+		merchant := Merchant{
+			ID:         seedMerchant.ID,
+			Name:       seedMerchant.Name,
+			MSP:        seedMerchant.MSP,
+			Locale:     seedMerchant.Locale,
+			Active:     true,
+			Program:    PointProgram{PointsPerUnit: 1},
+			PointTypes: defaultPointTypes(),
+			CreatedAt:  now.Format(time.RFC3339),
+			UpdatedAt:  now.Format(time.RFC3339),
+		}
+
+		err = putObject(ctx, merchantObjectType, merchant.ID, &merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateLedgerSeed(seed *LedgerSeed) error {
+	ids := map[string]bool{}
+	merchants := map[string]bool{}
+
+	for i := range seed.Merchants {
+		merchant := &seed.Merchants[i]
+		if merchant.ID == "" || merchant.MSP == "" {
+			return newError(ErrInvalidArgument, "merchant ID and MSP must not be empty")
+		}
+
+		if merchant.Locale != "" {
+			locale, err := validateLocale(namedField("locale"), merchant.Locale)
+			if err != nil {
+				return err
+			}
+
+			merchant.Locale = locale
+		}
+
+		if ids[merchant.ID] {
+			return newError(ErrInvalidArgument, "%s is duplicated in the ledger seed", merchant.ID)
+		}
+
+		ids[merchant.ID] = true
+		merchants[merchant.ID] = true
+	}
+
+	for i := range seed.Members {
+		member := &seed.Members[i]
+		if member.ID == "" {
+			return newError(ErrInvalidArgument, "member ID must not be empty")
+		}
+
+		if member.Locale != "" {
+			locale, err := validateLocale(namedField("locale"), member.Locale)
+			if err != nil {
+				return err
+			}
+
+			member.Locale = locale
+		}
+
+		if ids[member.ID] {
+			return newError(ErrInvalidArgument, "%s is duplicated in the ledger seed", member.ID)
+		}
+
+		ids[member.ID] = true
+
+		if !merchants[member.Merchant] {
+			return newError(ErrInvalidArgument, "merchant %s of %s is not in the ledger seed", member.Merchant, member.ID)
+		}
+
+		if member.Points < 0 {
+			return newError(ErrInvalidArgument, "opening balance of %s must not be negative", member.ID)
+		}
+
+		total := 0
+		for merchant, points := range member.MerchantPoints {
+			if !merchants[merchant] {
+				return newError(ErrInvalidArgument, "merchant %s in the points of %s is not in the ledger seed", merchant, member.ID)
+			}
+
+			total += points
+		}
+
+		if len(member.MerchantPoints) > 0 && total != member.Points {
+			return newError(ErrInvalidArgument, "merchant points of %s add up to %d instead of %d", member.ID, total, member.Points)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitLedgerSeed(t *testing.T) {
+	env := newTestEnv(t)
+	seed := `{
+		"merchants": [{"ID": "m1", "msp": "Org1MSP", "points": 1000}],
+		"members": [{"ID": "alice", "merchant": "m1", "points": 30, "merchantPoints": {"m1": 30}}]
+	}`
+
+	ctx := env.ctx(merchantIdentity)
+	env.stub.transient[ledgerSeedTransientKey] = []byte(seed)
+	requireErrorCode(t, env.points.InitLedger(ctx), ErrUnauthorized)
+
+	ctx = env.ctx(adminIdentity)
+	env.stub.transient[ledgerSeedTransientKey] = []byte(seed)
+	require.NoError(t, env.points.InitLedger(ctx))
+
+	require.Equal(t, 1000, env.member("m1").Points)
+	require.Equal(t, 30, env.balance("alice", "m1"))
+	_, err := env.points.CreateTransaction(env.ctx(merchantIdentity), "o1", "m1", "alice", 5, "m1", "", TypeOrder, "o1")
+	require.NoError(t, err, "the seeded MSP is registered")
+}
+
+func TestValidateLedgerSeed(t *testing.T) {
+	merchants := []SeedMerchant{{ID: "m1", MSP: "Org1MSP"}}
+
+	tests := []struct {
+		name string
+		seed LedgerSeed
+		err  string
+	}{
+		{"empty merchant", LedgerSeed{Merchants: []SeedMerchant{{}}}, "merchant ID and MSP must not be empty"},
+		{"duplicate", LedgerSeed{Merchants: merchants, Members: []Member{{ID: "m1", Merchant: "m1"}}}, "m1 is duplicated in the ledger seed"},
+		{"unknown merchant", LedgerSeed{Merchants: merchants, Members: []Member{{ID: "alice", Merchant: "m2"}}}, "merchant m2 of alice is not in the ledger seed"},
+		{"negative", LedgerSeed{Merchants: merchants, Members: []Member{{ID: "alice", Merchant: "m1", Points: -1}}}, "opening balance of alice must not be negative"},
+		{"mismatch", LedgerSeed{Merchants: merchants, Members: []Member{{ID: "alice", Merchant: "m1", Points: 5, MerchantPoints: map[string]int{"m1": 4}}}}, "merchant points of alice add up to 4 instead of 5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateLedgerSeed(&test.seed)
+			requireErrorCode(t, err, ErrInvalidArgument)
+			require.Equal(t, test.err, err.(*ContractError).Message)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+// Balance holds the points of a member, by merchant
+type Balance struct {
+	Owner          string                    `json:"owner"`
+	Points         int                       `json:"points"`
+	MerchantPoints map[string]int            `json:"merchantPoints"`
+	TypedPoints    map[string]map[string]int `json:"typedPoints,omitempty" metadata:"typedPoints,optional"`
+}
+
+// GetMyBalance returns the balance of the account bound to the caller's identity. It takes no
+// owner, so that wallets can call it directly without access to other accounts.
+func (s *PointsContract) GetMyBalance(ctx TransactionContext) (*Balance, error) {
+	account, err := getMyAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := getMember(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := Balance{
+		Owner:          member.ID,
+		Points:         member.Points,
+		MerchantPoints: member.MerchantPoints,
+		TypedPoints:    member.TypedPoints,
+	}
+	if balance.MerchantPoints == nil {
+		balance.MerchantPoints = map[string]int{}
+	}
+
+	return &balance, nil
+}
+
+// QueryMyTransactions returns a page of the transactions sent or received by the account bound
+// to the caller's identity, pass the bookmark of a page to fetch the next one
+func (s *PointsContract) QueryMyTransactions(ctx TransactionContext, pageSize int32, bookmark string) (*TransactionPage, error) {
+	account, err := getMyAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryTransactions(ctx, transactionOwnerIndex, []string{account}, pageSize, bookmark)
+}
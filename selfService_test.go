@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMyBalance(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	balance, err := env.points.GetMyBalance(env.ctx(alice))
+	require.NoError(t, err)
+	require.Equal(t, "alice", balance.Owner)
+	require.Equal(t, 100, balance.Points)
+	require.Equal(t, map[string]int{"m1": 100}, balance.MerchantPoints)
+
+	_, err = env.points.GetMyBalance(env.ctx(customerIdentity("bob")))
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestQueryMyTransactions(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	first := env.reward("m1", "alice", 10)
+	second := env.reward("m1", "alice", 20)
+	env.reward("m1", "bob", 30)
+	alice := env.registerAccount("alice")
+
+	page, err := env.points.QueryMyTransactions(env.ctx(alice), 1, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.True(t, page.HasMore)
+
+	ids := []string{page.Records[0].ID}
+	page, err = env.points.QueryMyTransactions(env.ctx(alice), 1, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	ids = append(ids, page.Records[0].ID)
+	require.ElementsMatch(t, []string{first, second}, ids)
+
+	_, err = env.points.QueryMyTransactions(env.ctx(customerIdentity("bob")), 10, "")
+	requireErrorCode(t, err, ErrNotFound)
+}
@@ -0,0 +1,250 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// settlementObjectType indexes the points moved by each transaction per merchant, month and kind
+const settlementObjectType = "settlement"
+
+// settlementTransactionIndex indexes the settlement records of each transaction, keyed by
+// transaction, merchant, month and kind
+const settlementTransactionIndex = "settlementTransaction"
+
+// settlementMonth is the layout of the months of a settlement report
+const settlementMonth = "2006-01"
+
+// Kinds of points movements counted in settlement reports
+const (
+	settlementIssued      = "issued"
+	settlementRedeemed    = "redeemed"
+	settlementExpired     = "expired"
+	settlementTransferred = "transferred"
+)
+
+// SettlementTotals holds the points of a merchant moved in a month
+type SettlementTotals struct {
+	Month       string `json:"month"`
+	Issued      int    `json:"issued"`
+	Redeemed    int    `json:"redeemed"`
+	Expired     int    `json:"expired"`
+	Transferred int    `json:"transferred"`
+}
+
+// SettlementReport holds the points of a merchant moved in a period, in total and per month
+type SettlementReport struct {
+	Merchant    string             `json:"merchant"`
+	PeriodStart string             `json:"periodStart"`
+	PeriodEnd   string             `json:"periodEnd"`
+	Total       SettlementTotals   `json:"total"`
+	Months      []SettlementTotals `json:"months"`
+}
+
+// GetSettlementReport returns the points issued, redeemed, expired and transferred by a merchant
+// in each month from periodStart until periodEnd inclusive, both given as YYYY-MM
+func (s *MerchantContract) GetSettlementReport(ctx TransactionContext, merchant string, periodStart string, periodEnd string) (*SettlementReport, error) {
+	if !isAdmin(ctx) {
+		err := assertMerchantMSP(ctx, merchant)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, err := time.Parse(settlementMonth, periodStart)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "invalid period start %s. %s", periodStart, err.Error())
+	}
+
+	end, err := time.Parse(settlementMonth, periodEnd)
+	if err != nil {
+		return nil, newError(ErrInvalidArgument, "invalid period end %s. %s", periodEnd, err.Error())
+	}
+
+	if end.Before(start) {
+		return nil, newError(ErrInvalidArgument, "period must end after it starts")
+	}
+
+	report := SettlementReport{
+		Merchant:    merchant,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Total:       SettlementTotals{Month: periodStart},
+		Months:      []SettlementTotals{},
+	}
+
+	for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+		totals, err := getSettlementTotals(ctx, merchant, month.Format(settlementMonth))
+		if err != nil {
+			return nil, err
+		}
+
+		report.Total.Issued += totals.Issued
+		report.Total.Redeemed += totals.Redeemed
+		report.Total.Expired += totals.Expired
+		report.Total.Transferred += totals.Transferred
+		report.Months = append(report.Months, *totals)
+	}
+
+	return &report, nil
+}
+
+// recordSettlement indexes the points of a merchant moved by a transaction. Each transaction
+// gets its own key, so concurrent transactions of a merchant do not conflict.
+func recordSettlement(ctx TransactionContext, merchant string, kind string, transactionID string, value int) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	attributes := []string{merchant, now.Format(settlementMonth), kind, transactionID}
+	err = putCompositeObject(ctx, settlementObjectType, attributes, value)
+	if err != nil {
+		return err
+	}
+
+	return putSettlementIndex(ctx, attributes)
+}
+
+// putSettlementIndex adds a settlement record, given by its attributes, to the index of its transaction
+func putSettlementIndex(ctx TransactionContext, attributes []string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(settlementTransactionIndex, []string{attributes[3], attributes[0], attributes[1], attributes[2]})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
+	err = ctx.GetStub().PutState(key, []byte{0x00})
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// deleteSettlement deletes a settlement record, given by its attributes, and its index entry
+func deleteSettlement(ctx TransactionContext, attributes []string) error {
+	for _, key := range []struct {
+		objectType string
+		attributes []string
+	}{
+		{settlementObjectType, attributes},
+		{settlementTransactionIndex, []string{attributes[3], attributes[0], attributes[1], attributes[2]}},
+	} {
+		key, err := ctx.GetStub().CreateCompositeKey(key.objectType, key.attributes)
+		if err != nil {
+			return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// getTransactionSettlements returns the attributes of the settlement records of a transaction
+func getTransactionSettlements(ctx TransactionContext, id string) ([][]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(settlementTransactionIndex, []string{id})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	settlements := [][]string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		settlements = append(settlements, []string{attributes[1], attributes[2], attributes[3], attributes[0]})
+	}
+
+	return settlements, nil
+}
+
+// reindexSettlements adds every settlement record to the index of its transaction, for records
+// written before the index existed
+func reindexSettlements(ctx TransactionContext) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(settlementObjectType, []string{})
+	if err != nil {
+		return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		if len(attributes) != 4 {
+			continue
+		}
+
+		err = putSettlementIndex(ctx, attributes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSettlementTotals adds up the points of a merchant moved in a month
+func getSettlementTotals(ctx TransactionContext, merchant string, month string) (*SettlementTotals, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(settlementObjectType, []string{merchant, month})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	totals := SettlementTotals{Month: month}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		var value int
+		err = json.Unmarshal(queryResponse.Value, &value)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to unmarshal %s. %s", queryResponse.Key, err.Error())
+		}
+
+		switch attributes[2] {
+		case settlementIssued:
+			totals.Issued += value
+		case settlementRedeemed:
+			totals.Redeemed += value
+		case settlementExpired:
+			totals.Expired += value
+		case settlementTransferred:
+			totals.Transferred += value
+		}
+	}
+
+	return &totals, nil
+}
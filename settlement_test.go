@@ -0,0 +1,37 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettlementReport(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 40, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	report, err := env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", "2024-02", "2024-03")
+	require.NoError(t, err)
+	require.Len(t, report.Months, 2)
+	require.Equal(t, 0, report.Months[0].Issued)
+	require.Equal(t, 100, report.Months[1].Issued)
+	require.Equal(t, 40, report.Months[1].Redeemed)
+	require.Equal(t, 100, report.Total.Issued)
+
+	_, err = env.merchants.GetSettlementReport(env.ctx(otherMSPIdentity), "m1", "2024-02", "2024-03")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", "2024-03", "2024-02")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	_, err = env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", "March", "2024-03")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
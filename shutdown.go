@@ -0,0 +1,112 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// invocationTracker counts the invocations in flight so shutdown can wait for them to finish
+type invocationTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	drained  chan struct{}
+}
+
+// invocations tracks the invocations of the chaincode served by this process
+var invocations = &invocationTracker{drained: make(chan struct{})}
+
+// start records a new invocation, it returns false once the server is shutting down
+func (t *invocationTracker) start() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return false
+	}
+
+	t.inFlight++
+	return true
+}
+
+// done records the end of an invocation
+func (t *invocationTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight--
+	if t.draining && t.inFlight == 0 {
+		close(t.drained)
+	}
+}
+
+// isDraining reports whether the server is shutting down
+func (t *invocationTracker) isDraining() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.draining
+}
+
+// drain rejects new invocations and waits up to timeout for those in flight, it returns
+// the number of invocations still running when it gave up
+func (t *invocationTracker) drain(timeout time.Duration) int {
+	t.mu.Lock()
+	t.draining = true
+	if t.inFlight == 0 {
+		t.mu.Unlock()
+		return 0
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.drained:
+		return 0
+	case <-time.After(timeout):
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.inFlight
+}
+
+// waitForShutdown blocks until the process receives SIGTERM or SIGINT or the server stops
+// on its own, and returns the exit code of the process. On a signal it stops accepting
+// connections and invocations and gives those in flight up to timeout to finish.
+func waitForShutdown(server *grpc.Server, served <-chan error, timeout time.Duration) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-served:
+		logError("chaincode server stopped", logFields{"error": err})
+		return 1
+	case sig := <-signals:
+		logInfo("shutting down", logFields{"signal": sig.String(), "timeout": timeout.String()})
+	}
+
+	// GracefulStop closes the listener at once, but waits for the stream of the peer which
+	// stays open until Stop, so the invocations are drained separately
+	go server.GracefulStop()
+
+	remaining := invocations.drain(timeout)
+	server.Stop()
+
+	if remaining > 0 {
+		logError("shutdown timed out, invocations were interrupted", logFields{"inFlight": remaining})
+		return 1
+	}
+
+	logInfo("chaincode server stopped", nil)
+	return 0
+}
@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/require"
+)
+
+// useTracker replaces the invocation tracker of the process for the duration of a test
+func useTracker(t *testing.T) *invocationTracker {
+	previous := invocations
+	t.Cleanup(func() { invocations = previous })
+
+	invocations = &invocationTracker{drained: make(chan struct{})}
+	return invocations
+}
+
+func TestDrainWithoutInvocations(t *testing.T) {
+	tracker := useTracker(t)
+
+	require.Equal(t, 0, tracker.drain(time.Second))
+	require.True(t, tracker.isDraining())
+	require.False(t, tracker.start(), "new invocations are rejected once draining")
+}
+
+func TestDrainWaitsForInvocations(t *testing.T) {
+	tracker := useTracker(t)
+
+	require.True(t, tracker.start())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		tracker.done()
+	}()
+
+	require.Equal(t, 0, tracker.drain(time.Second))
+}
+
+func TestDrainTimesOut(t *testing.T) {
+	tracker := useTracker(t)
+
+	require.True(t, tracker.start())
+	require.True(t, tracker.start())
+	tracker.done()
+
+	require.Equal(t, 1, tracker.drain(10*time.Millisecond))
+}
+
+func TestInstrumentedChaincodeRejectsWhileDraining(t *testing.T) {
+	tracker := useTracker(t)
+	tracker.drain(0)
+
+	stub := shimtest.NewMockStub("shutdown", &instrumentedChaincode{Chaincode: new(stateChaincode)})
+
+	response := stub.MockInvoke("tx1", [][]byte{[]byte("Succeed")})
+	require.Equal(t, int32(shim.ERROR), response.Status)
+	require.Equal(t, "chaincode server is shutting down", response.Message)
+}
+
+func TestOperationsServerDraining(t *testing.T) {
+	tracker := useTracker(t)
+
+	chaincode, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer chaincode.Close()
+
+	address := freeAddress(t)
+	startOperationsServer(address, chaincode.Addr().String(), true)
+
+	status, _ := getProbe(t, "http://"+address+"/readyz")
+	require.Equal(t, http.StatusOK, status)
+
+	tracker.drain(0)
+
+	status, body := getProbe(t, "http://"+address+"/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "chaincode server is shutting down", body)
+}
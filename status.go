@@ -0,0 +1,116 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+// Transaction types, stored in the transaction source
+const (
+	TypeOrder      = "Order"
+	TypeBirthday   = "Birthday"
+	TypeCampaign   = "Campaign"
+	TypeGift       = "Gift"
+	TypeAdjustment = "Adjustment"
+	TypeReversal   = "Reversal"
+	TypeConversion = "Conversion"
+	TypeIssue      = "Issue"
+	TypeRedemption = "Redemption"
+	TypeAllowance  = "Allowance"
+	TypeVoucher    = "Voucher"
+	TypeBurn       = "Burn"
+	TypeLifeCard   = "LifeCard"
+	TypeTransfer   = "Transfer"
+	TypeBridge     = "Bridge"
+	TypeBridgeLock = "BridgeLock"
+	TypeReferral   = "Referral"
+	TypeExpiry     = "Expiry"
+)
+
+// transactionTypes are the values accepted as transaction types
+var transactionTypes = []string{
+	TypeOrder, TypeBirthday, TypeCampaign, TypeGift, TypeAdjustment, TypeReversal, TypeConversion,
+	TypeIssue, TypeRedemption, TypeAllowance, TypeVoucher, TypeBurn, TypeLifeCard, TypeTransfer,
+	TypeBridge, TypeBridgeLock, TypeReferral, TypeExpiry,
+}
+
+// Transaction statuses
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusReversed  = "reversed"
+	StatusArchived  = "archived"
+	StatusVoided    = "voided"
+)
+
+// transactionStatuses are the values accepted as transaction statuses
+var transactionStatuses = []string{
+	StatusPending, StatusConfirmed, StatusCancelled, StatusReversed, StatusArchived, StatusVoided,
+}
+
+// statusTransitions lists the statuses a transaction may move to from each status
+var statusTransitions = map[string][]string{
+	StatusPending:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusReversed, StatusArchived, StatusVoided},
+	StatusCancelled: {StatusArchived},
+	StatusReversed:  {StatusArchived},
+	StatusArchived:  {},
+	StatusVoided:    {},
+}
+
+// manualStatuses are the statuses UpdateStatus may set, the others change balances
+// and are only set by their own functions
+var manualStatuses = map[string]bool{
+	StatusCancelled: true,
+	StatusArchived:  true,
+}
+
+// UpdateStatus moves a transaction to a new status, rejecting illegal transitions
+func (s *MerchantContract) UpdateStatus(ctx TransactionContext, id string, status string) error {
+	transaction, err := getTransaction(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, transaction.Merchant)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !manualStatuses[status] {
+		return newError(ErrInvalidArgument, "status %s cannot be set directly", status)
+	}
+
+	err = transitionStatus(transaction, status)
+	if err != nil {
+		return err
+	}
+
+	return putTransaction(ctx, transaction)
+}
+
+// transitionStatus sets the status of a transaction if the transition is allowed
+func transitionStatus(transaction *PointsTransaction, status string) error {
+	current := transactionStatus(transaction)
+
+	for _, next := range statusTransitions[current] {
+		if next == status {
+			transaction.Status = status
+			return nil
+		}
+	}
+
+	return newError(ErrInvalidState, "transaction %s cannot move from %s to %s", transaction.ID, current, status)
+}
+
+// transactionStatus returns the status of a transaction, records written before
+// statuses existed were applied immediately and count as confirmed
+func transactionStatus(transaction *PointsTransaction) string {
+	if transaction.Status == "" {
+		return StatusConfirmed
+	}
+
+	return transaction.Status
+}
@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 10)
+
+	err := env.merchants.UpdateStatus(env.ctx(otherMSPIdentity), order, StatusArchived)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.UpdateStatus(env.ctx(merchantIdentity), order, StatusReversed)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.UpdateStatus(env.ctx(merchantIdentity), order, StatusCancelled)
+	requireErrorCode(t, err, ErrInvalidState)
+
+	err = env.merchants.UpdateStatus(env.ctx(merchantIdentity), "missing", StatusArchived)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.merchants.UpdateStatus(env.ctx(merchantIdentity), order, StatusArchived))
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, StatusArchived, transaction.Status)
+
+	err = env.merchants.UpdateStatus(env.ctx(merchantIdentity), order, StatusCancelled)
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestReverseTransactionSetsStatus(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 10)
+
+	_, err := env.merchants.ReverseTransaction(env.ctx(merchantIdentity), order, "returned")
+	require.NoError(t, err)
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, StatusReversed, transaction.Status)
+}
@@ -0,0 +1,270 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	// commissionObjectType stores the commission of an order, keyed by merchant, stockist and transaction
+	commissionObjectType = "commission"
+
+	// maxCommissionRate is a commission rate of the whole order, in basis points
+	maxCommissionRate = 10000
+)
+
+// Commission is the commission a stockist earned on an order rewarded by a merchant, in points
+// of the merchant's program
+type Commission struct {
+	Schema
+	Merchant    string `json:"merchant"`
+	Stockist    string `json:"stockist"`
+	Transaction string `json:"transaction"`
+	Order       string `json:"order"`
+	OrderValue  int    `json:"orderValue"`
+	Rate        int    `json:"rate"`
+	Value       int    `json:"value"`
+	AccruedAt   string `json:"accruedAt"`
+}
+
+// CommissionPage is a page of the commissions of a stockist
+type CommissionPage struct {
+	Records []*Commission `json:"records"`
+	PageInfo
+}
+
+// StockistBalance is the commission a stockist accrued on the orders of a merchant
+type StockistBalance struct {
+	Merchant string `json:"merchant"`
+	Stockist string `json:"stockist"`
+	Orders   int    `json:"orders"`
+	Accrued  int    `json:"accrued"`
+}
+
+// SetStockistCommission sets the commission a merchant owes the stockist of an order, in basis
+// points of the points rewarded for the order. A rate of 0 accrues no commission.
+func (s *MerchantContract) SetStockistCommission(ctx TransactionContext, merchantID string, rate int) error {
+	merchant, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rate < 0 || rate > maxCommissionRate {
+		return newError(ErrInvalidArgument, "commission rate must be between 0 and %d basis points", maxCommissionRate)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.StockistCommission = rate
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, merchantID, merchant)
+}
+
+// CreateOrderTransaction rewards a customer with value points of a merchant for an order sold
+// by a stockist, who accrues the merchant's commission on it. It returns the key of the
+// transaction, or of the transaction of an earlier submission with the same idempotency token.
+func (s *PointsContract) CreateOrderTransaction(ctx TransactionContext, id string, merchant string, receiverKey string, value int, createdAt string, orderID string, stockist string) (string, error) {
+	createdAt, err := inputDate(ctx, createdAt)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = getMerchant(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertCanSend(ctx, merchant, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	transaction := PointsTransaction{
+		ID:        id,
+		Value:     value,
+		Merchant:  merchant,
+		CreatedAt: createdAt,
+		Sender:    merchant,
+		Receiver:  receiverKey,
+		Source:    &Source{Type: TypeOrder, ID: orderID, Stockist: stockist},
+		Status:    StatusConfirmed,
+	}
+
+	return idempotentCreate(ctx, func() (string, error) {
+		return id, createTransaction(ctx, &transaction)
+	})
+}
+
+// accrueCommission records the commission of the stockist of an order once the transaction
+// rewarding it is confirmed
+func accrueCommission(ctx TransactionContext, transaction *PointsTransaction) error {
+	if !isOrderReward(transaction) || transaction.Source.Stockist == "" || transactionStatus(transaction) != StatusConfirmed {
+		return nil
+	}
+
+	merchant, err := getMerchant(ctx, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	rate := merchant.Program.StockistCommission
+	value := transaction.Value * rate / maxCommissionRate
+	if value <= 0 {
+		return nil
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	commission := Commission{
+		Merchant:    transaction.Merchant,
+		Stockist:    transaction.Source.Stockist,
+		Transaction: transaction.ID,
+		Order:       transaction.Source.ID,
+		OrderValue:  transaction.Value,
+		Rate:        rate,
+		Value:       value,
+		AccruedAt:   now.Format(time.RFC3339),
+	}
+
+	return putCompositeObject(ctx, commissionObjectType, commissionKey(transaction), &commission)
+}
+
+// commissionKey returns the attributes of the commission accrued on the order of a transaction
+func commissionKey(transaction *PointsTransaction) []string {
+	return []string{transaction.Merchant, transaction.Source.Stockist, transaction.ID}
+}
+
+// dropCommission deletes the commission accrued by the stockist of an order reward which was
+// voided or reversed
+func dropCommission(ctx TransactionContext, transaction *PointsTransaction) error {
+	if !isOrderReward(transaction) || transaction.Source.Stockist == "" {
+		return nil
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(commissionObjectType, commissionKey(transaction))
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// GetStockistBalance returns the commission a stockist accrued on the orders of a merchant,
+// summed from its commission records
+func (s *MerchantContract) GetStockistBalance(ctx TransactionContext, merchantID string, stockist string) (*StockistBalance, error) {
+	err := assertCommissionReader(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(commissionObjectType, []string{merchantID, stockist})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	balance := StockistBalance{Merchant: merchantID, Stockist: stockist}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		commission, err := unmarshalCommission(queryResponse.Key, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		balance.Orders++
+		balance.Accrued += commission.Value
+	}
+
+	return &balance, nil
+}
+
+// QueryStockistCommissions returns a page of the commissions a stockist accrued on the orders of
+// a merchant, pass the bookmark of a page to fetch the next one
+func (s *MerchantContract) QueryStockistCommissions(ctx TransactionContext, merchantID string, stockist string, pageSize int32, bookmark string) (*CommissionPage, error) {
+	err := assertCommissionReader(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pageSize <= 0 {
+		return nil, newError(ErrInvalidArgument, "page size must be positive")
+	}
+
+	attributes := []string{merchantID, stockist}
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(commissionObjectType, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	page := CommissionPage{Records: []*Commission{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		commission, err := unmarshalCommission(queryResponse.Key, queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Records = append(page.Records, commission)
+	}
+
+	page.PageInfo, err = compositeKeyPageInfo(ctx, commissionObjectType, attributes, pageSize, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// assertCommissionReader checks that the caller may read the commissions of a merchant, an
+// admin or the merchant's organization
+func assertCommissionReader(ctx TransactionContext, merchantID string) error {
+	_, err := getMerchant(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	if isAdmin(ctx) {
+		return nil
+	}
+
+	return assertMerchantMSP(ctx, merchantID)
+}
+
+func unmarshalCommission(key string, value []byte) (*Commission, error) {
+	var commission Commission
+	err := unmarshalState(commissionObjectType, key, value, &commission)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commission, nil
+}
@@ -0,0 +1,136 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetStockistCommission(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	err := env.merchants.SetStockistCommission(env.ctx(otherMSPIdentity), "m1", 500)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", maxCommissionRate+1)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "unknown", 500)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", 500))
+
+	merchant, err := env.merchants.GetMerchant(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 500, merchant.Program.StockistCommission)
+}
+
+func TestCreateOrderTransactionAccruesCommission(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", 500))
+
+	_, err := env.points.CreateOrderTransaction(env.ctx(otherMSPIdentity), "t1", "m1", "alice", 200, "", "o1", "shop1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	id, err := env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 200, "", "o1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, "t1", id)
+	require.Equal(t, 200, env.balance("alice", "m1"))
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), "t1")
+	require.NoError(t, err)
+	require.Equal(t, "shop1", transaction.Source.Stockist)
+
+	_, err = env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t2", "m1", "alice", 200, "", "o1", "shop1")
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	_, err = env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t3", "m1", "bob", 30, "", "o2", "shop1")
+	require.NoError(t, err)
+
+	_, err = env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t4", "m1", "bob", 10, "", "o3", "shop1")
+	require.NoError(t, err, "an order too small for a commission")
+
+	_, err = env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t5", "m1", "bob", 100, "", "o4", "shop2")
+	require.NoError(t, err)
+
+	balance, err := env.merchants.GetStockistBalance(env.ctx(merchantIdentity), "m1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, 2, balance.Orders)
+	require.Equal(t, 11, balance.Accrued)
+
+	_, err = env.merchants.GetStockistBalance(env.ctx(otherMSPIdentity), "m1", "shop1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	page, err := env.merchants.QueryStockistCommissions(env.ctx(adminIdentity), "m1", "shop1", 1, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.True(t, page.HasMore)
+	require.Equal(t, "t1", page.Records[0].Transaction)
+	require.Equal(t, "o1", page.Records[0].Order)
+	require.Equal(t, 10, page.Records[0].Value)
+
+	page, err = env.merchants.QueryStockistCommissions(env.ctx(adminIdentity), "m1", "shop1", 1, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.False(t, page.HasMore)
+	require.Equal(t, 1, page.Records[0].Value)
+}
+
+func TestCommissionAccruesOnApproval(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", 1000))
+	require.NoError(t, env.merchants.SetApprovalPolicy(env.ctx(adminIdentity), "m1", 100, 1))
+
+	_, err := env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 150, "", "o1", "shop1")
+	require.NoError(t, err)
+
+	balance, err := env.merchants.GetStockistBalance(env.ctx(merchantIdentity), "m1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, 0, balance.Accrued, "no commission while the order is pending")
+
+	require.NoError(t, env.admin.ApproveTransaction(env.ctx(otherAdmin), "t1"))
+
+	balance, err = env.merchants.GetStockistBalance(env.ctx(merchantIdentity), "m1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, 15, balance.Accrued)
+}
+
+func TestVoidTransactionDropsCommission(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", 1000))
+
+	_, err := env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 100, "", "o1", "shop1")
+	require.NoError(t, err)
+
+	require.NoError(t, env.admin.VoidTransaction(env.ctx(adminIdentity), "t1", "duplicate"))
+
+	balance, err := env.merchants.GetStockistBalance(env.ctx(merchantIdentity), "m1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, 0, balance.Orders)
+	require.Equal(t, 0, balance.Accrued)
+}
+
+func TestReverseTransactionDropsCommission(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	require.NoError(t, env.merchants.SetStockistCommission(env.ctx(merchantIdentity), "m1", 1000))
+
+	_, err := env.points.CreateOrderTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 100, "", "o1", "shop1")
+	require.NoError(t, err)
+
+	_, err = env.merchants.ReverseTransaction(env.ctx(merchantIdentity), "t1", "refunded")
+	require.NoError(t, err)
+
+	balance, err := env.merchants.GetStockistBalance(env.ctx(merchantIdentity), "m1", "shop1")
+	require.NoError(t, err)
+	require.Equal(t, 0, balance.Orders)
+	require.Equal(t, 0, balance.Accrued)
+}
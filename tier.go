@@ -0,0 +1,188 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	tierObjectType = "tier"
+
+	// tierChangedEvent is emitted when earning points moves a customer to another tier
+	tierChangedEvent = "TierChanged"
+
+	// tierWindowMonths is the number of months of earned points counted towards a tier
+	tierWindowMonths = 12
+
+	// tierMonth is the layout of the months of the tier counters
+	tierMonth = "2006-01"
+)
+
+// Loyalty tiers
+const (
+	TierNone     = "none"
+	TierSilver   = "silver"
+	TierGold     = "gold"
+	TierPlatinum = "platinum"
+)
+
+// tierThresholds are the points to earn within the window for each tier, highest first
+var tierThresholds = []struct {
+	tier   string
+	points int
+}{
+	{TierPlatinum, 20000},
+	{TierGold, 5000},
+	{TierSilver, 1000},
+}
+
+// TierStatus holds the points a customer earned from a merchant and the resulting tier
+type TierStatus struct {
+	Schema
+	Owner    string `json:"owner"`
+	Merchant string `json:"merchant"`
+	Tier     string `json:"tier"`
+	// Lifetime is the number of points earned since the account was opened
+	Lifetime int `json:"lifetime"`
+	// Rolling is the number of points earned in the last 12 months
+	Rolling int `json:"rolling"`
+	// Monthly is the number of points earned per month, keyed by YYYY-MM, within the window
+	Monthly   map[string]int `json:"monthly"`
+	UpdatedAt string         `json:"updated_at"`
+	// deltaKeys are the earned points added to the counters read, deleted when they are written
+	deltaKeys []string
+}
+
+// TierChange is a change of tier, reported by the TierChanged event
+type TierChange struct {
+	Owner    string `json:"owner"`
+	Merchant string `json:"merchant"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// GetTier returns the loyalty tier of a customer at a merchant
+func (s *PointsContract) GetTier(ctx TransactionContext, owner string, merchantID string) (*TierStatus, error) {
+	status, err := getTierStatus(ctx, owner, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status.refresh(now)
+	return status, nil
+}
+
+// recordEarnedPoints adds points a customer earned from a merchant to the tier counters,
+// emitting a TierChanged event when the tier differs from the tier last written
+func recordEarnedPoints(ctx TransactionContext, owner string, merchantID string, value int) error {
+	status, err := getTierStatus(ctx, owner, merchantID)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous := status.Tier
+
+	status.Lifetime += value
+	status.Monthly[now.Format(tierMonth)] += value
+	status.refresh(now)
+	status.UpdatedAt = now.Format(time.RFC3339)
+
+	return putTierStatus(ctx, status, previous)
+}
+
+// deferEarnedPoints adds points a customer earned from a merchant by a transaction to the tier
+// counters as a delta, without reading them. The tier change is announced once the counters
+// are written with the delta.
+func deferEarnedPoints(ctx TransactionContext, transactionID string, owner string, merchantID string, value int) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	delta := CounterDelta{Value: value, Period: now.Format(tierMonth)}
+	return putCounterDelta(ctx, tierObjectType, []string{merchantID, owner}, transactionID, &delta)
+}
+
+// putTierStatus writes the tier counters with the deltas they were read with, emitting a
+// TierChanged event when the tier is not the previous one
+func putTierStatus(ctx TransactionContext, status *TierStatus, previous string) error {
+	err := deleteDeltas(ctx, status.deltaKeys)
+	if err != nil {
+		return err
+	}
+
+	status.deltaKeys = nil
+
+	err = putCompositeObject(ctx, tierObjectType, []string{status.Merchant, status.Owner}, status)
+	if err != nil {
+		return err
+	}
+
+	if status.Tier == previous {
+		return nil
+	}
+
+	change := TierChange{Owner: status.Owner, Merchant: status.Merchant, From: previous, To: status.Tier}
+	return emitEvent(ctx, tierChangedEvent, &TierChangedEvent{TierChange: change})
+}
+
+// getTierStatus returns the tier counters of a customer with the points earned as deltas,
+// empty ones if nothing was earned yet. The tier is the one last written.
+func getTierStatus(ctx TransactionContext, owner string, merchantID string) (*TierStatus, error) {
+	status := TierStatus{Owner: owner, Merchant: merchantID, Tier: TierNone}
+	_, err := getCompositeObject(ctx, tierObjectType, []string{merchantID, owner}, &status)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Monthly == nil {
+		status.Monthly = map[string]int{}
+	}
+
+	deltas, keys, err := getCounterDeltas(ctx, tierObjectType, []string{merchantID, owner})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delta := range deltas {
+		status.Lifetime += delta.Value
+		status.Monthly[delta.Period] += delta.Value
+	}
+	status.deltaKeys = keys
+
+	return &status, nil
+}
+
+// refresh drops the months which left the window and recomputes the rolling total and tier
+func (t *TierStatus) refresh(now time.Time) {
+	oldest := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-tierWindowMonths, 0).Format(tierMonth)
+
+	t.Rolling = 0
+	for month, points := range t.Monthly {
+		if month < oldest {
+			delete(t.Monthly, month)
+			continue
+		}
+
+		t.Rolling += points
+	}
+
+	t.Tier = TierNone
+	for _, threshold := range tierThresholds {
+		if t.Rolling >= threshold.points {
+			t.Tier = threshold.tier
+			break
+		}
+	}
+}
@@ -0,0 +1,36 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTier(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 900)
+
+	tier, err := env.points.GetTier(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, TierNone, tier.Tier)
+	require.Equal(t, 900, tier.Rolling)
+
+	env.reward("m1", "alice", 100)
+
+	tier, err = env.points.GetTier(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, TierSilver, tier.Tier)
+
+	env.advance(400 * 24 * time.Hour)
+
+	tier, err = env.points.GetTier(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, TierNone, tier.Tier, "points earned outside the window do not count")
+	require.Equal(t, 1000, tier.Lifetime)
+}
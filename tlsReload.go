@@ -0,0 +1,206 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// tlsReloader holds the TLS configuration of the chaincode server and reloads it from the
+// key, certificate and client CA files, so certificates can be rotated without a restart
+type tlsReloader struct {
+	keyFile      string
+	certFile     string
+	clientCAFile string
+
+	mu      sync.RWMutex
+	config  *tls.Config
+	modTime time.Time
+}
+
+// newTLSReloader loads the TLS configuration from the files, clientCAFile may be empty
+// when the peer certificate is not verified
+func newTLSReloader(keyFile string, certFile string, clientCAFile string) (*tlsReloader, error) {
+	reloader := &tlsReloader{keyFile: keyFile, certFile: certFile, clientCAFile: clientCAFile}
+
+	err := reloader.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// serverConfig returns the TLS configuration of the chaincode server, every handshake
+// uses the configuration loaded last
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return r.config, nil
+		},
+	}
+}
+
+// reload reads the files again and replaces the configuration, the current one is kept
+// if any file cannot be read or parsed
+func (r *tlsReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	keyBytes, err := ioutil.ReadFile(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS key %s. %s", r.keyFile, err)
+	}
+
+	certBytes, err := ioutil.ReadFile(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS certificate %s. %s", r.certFile, err)
+	}
+
+	var clientCABytes []byte
+	if r.clientCAFile != "" {
+		clientCABytes, err = ioutil.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA certificate %s. %s", r.clientCAFile, err)
+		}
+	}
+
+	config, err := newServerTLSConfig(keyBytes, certBytes, clientCABytes)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.config = config
+	r.modTime = modTime
+
+	return nil
+}
+
+// changed reports whether any of the files was modified since the configuration was loaded
+func (r *tlsReloader) changed() (bool, error) {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return !modTime.Equal(r.modTime), nil
+}
+
+func (r *tlsReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{r.keyFile, r.certFile, r.clientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s. %s", file, err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// watch reloads the configuration on SIGHUP and, if interval is positive, whenever a
+// poll finds that one of the files was modified
+func (r *tlsReloader) watch(interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var poll <-chan time.Time
+	if interval > 0 {
+		poll = time.NewTicker(interval).C
+	}
+
+	go func() {
+		for {
+			select {
+			case <-hangup:
+				r.reloadAndLog("SIGHUP")
+			case <-poll:
+				changed, err := r.changed()
+				if err != nil {
+					logWarn("failed to check TLS files", logFields{"error": err})
+					continue
+				}
+
+				if changed {
+					r.reloadAndLog("file change")
+				}
+			}
+		}
+	}()
+}
+
+func (r *tlsReloader) reloadAndLog(trigger string) {
+	err := r.reload()
+	if err != nil {
+		logError("failed to reload TLS certificates, keeping the current ones", logFields{"trigger": trigger, "error": err})
+		return
+	}
+
+	logInfo("reloaded TLS certificates", logFields{"trigger": trigger, "cert": r.certFile})
+}
+
+// newServerTLSConfig builds the TLS configuration of a chaincode server the way the shim does,
+// the peer certificate is required and verified when clientCABytes is set
+func newServerTLSConfig(keyBytes []byte, certBytes []byte, clientCABytes []byte) (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS key pair. %s", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:             tls.VersionTLS12,
+		Certificates:           []tls.Certificate{certificate},
+		NextProtos:             []string{"h2"},
+		SessionTicketsDisabled: true,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+		},
+	}
+
+	if clientCABytes != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(clientCABytes) {
+			return nil, fmt.Errorf("failed to parse client CA certificate")
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
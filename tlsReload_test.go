@@ -0,0 +1,131 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a self-signed certificate and its key to dir
+func writeKeyPair(t *testing.T, dir string, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "server.key")
+	certFile := filepath.Join(dir, "server.crt")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600))
+
+	return keyFile, certFile
+}
+
+func currentCommonName(t *testing.T, reloader *tlsReloader) string {
+	config, err := reloader.serverConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestTLSReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile, certFile := writeKeyPair(t, dir, "first")
+
+	reloader, err := newTLSReloader(keyFile, certFile, "")
+	require.NoError(t, err)
+	require.Equal(t, "first", currentCommonName(t, reloader))
+
+	changed, err := reloader.changed()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	writeKeyPair(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	changed, err = reloader.changed()
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	require.NoError(t, reloader.reload())
+	require.Equal(t, "second", currentCommonName(t, reloader))
+
+	// a broken key keeps the current configuration
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("not a key"), 0600))
+	require.Error(t, reloader.reload())
+	require.Equal(t, "second", currentCommonName(t, reloader))
+}
+
+func TestTLSReloaderClientCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile, certFile := writeKeyPair(t, dir, "server")
+
+	reloader, err := newTLSReloader(keyFile, certFile, certFile)
+	require.NoError(t, err)
+
+	config, err := reloader.serverConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	_, err = newTLSReloader(keyFile, certFile, filepath.Join(dir, "missing.crt"))
+	require.Error(t, err)
+
+	_, err = newTLSReloader(keyFile, certFile, keyFile)
+	require.EqualError(t, err, "failed to parse client CA certificate")
+}
+
+func TestNewChaincodeServerValidatesSettings(t *testing.T) {
+	_, _, err := newChaincodeServer("", "localhost:0", nil, nil, defaultServerConfig().GRPC)
+	require.EqualError(t, err, "ccid must be specified")
+
+	_, _, err = newChaincodeServer("points:1", "", nil, nil, defaultServerConfig().GRPC)
+	require.EqualError(t, err, "address must be specified")
+}
+
+func TestNewChaincodeServerListens(t *testing.T) {
+	server, listener, err := newChaincodeServer("points:1", "127.0.0.1:0", new(stateChaincode), nil, defaultServerConfig().GRPC)
+	require.NoError(t, err)
+	defer server.Stop()
+	defer listener.Close()
+
+	require.NotEmpty(t, listener.Addr().String())
+}
@@ -0,0 +1,181 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// maxSymbolLength is the longest ticker a merchant may set for its points
+const maxSymbolLength = 11
+
+// tokenQueries are the read-only functions of the token interface, named as in ERC-20
+var tokenQueries = map[string]bool{
+	"Name":        true,
+	"Symbol":      true,
+	"Decimals":    true,
+	"TotalSupply": true,
+	"BalanceOf":   true,
+}
+
+// Name returns the name of the points of a merchant program
+func (s *PointsContract) Name(ctx TransactionContext, merchant string) (string, error) {
+	m, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Name + " Points", nil
+}
+
+// Symbol returns the ticker of the points of a merchant program
+func (s *PointsContract) Symbol(ctx TransactionContext, merchant string) (string, error) {
+	m, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenSymbol(m), nil
+}
+
+// Decimals returns the number of decimals of the points of a merchant program. Points are
+// whole numbers, so it is always 0.
+func (s *PointsContract) Decimals(ctx TransactionContext, merchant string) (int, error) {
+	_, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// TotalSupply returns the points of a merchant program held by customers, the outstanding
+// points of its program statistics
+func (s *PointsContract) TotalSupply(ctx TransactionContext, merchant string) (int, error) {
+	_, err := getMerchant(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	stats, err := getProgramStats(ctx, merchant)
+	if err != nil {
+		return 0, err
+	}
+
+	return stats.Outstanding, nil
+}
+
+// BalanceOf returns the points of a merchant program held by a member
+func (s *PointsContract) BalanceOf(ctx TransactionContext, owner string, merchant string) (int, error) {
+	member, err := getMember(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	return member.MerchantPoints[merchant], nil
+}
+
+// Transfer moves value points from the caller's account to another member, recorded as a
+// transaction of the sender's merchant like any other transfer. It returns the key of the
+// transaction, or of the transaction of an earlier submission with the same idempotency token.
+func (s *PointsContract) Transfer(ctx TransactionContext, to string, value int) (string, error) {
+	return idempotentCreate(ctx, func() (string, error) {
+		return ctx.GetStub().GetTxID(), transfer(ctx, to, value)
+	})
+}
+
+func transfer(ctx TransactionContext, to string, value int) error {
+	account, err := getCallerAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	if account == "" {
+		return newError(ErrUnauthorized, "client has no registered account to transfer from")
+	}
+
+	member, err := getMember(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	err = assertTokenHolder(member)
+	if err != nil {
+		return err
+	}
+
+	err = assertCanSend(ctx, member.ID, member.Merchant)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     value,
+		Merchant:  member.Merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    account,
+		Receiver:  to,
+		Source:    &Source{Type: TypeTransfer},
+		Status:    StatusConfirmed,
+	}
+
+	return createTransaction(ctx, &transaction)
+}
+
+// assertTokenHolder checks that the member is a customer. Merchants issue their points through
+// CreateTransaction, which checks the merchant's organization, and cannot send them as tokens.
+func assertTokenHolder(member *Member) error {
+	if member.Merchant == "" {
+		return newError(ErrInvalidArgument, "%s is a merchant and cannot transfer tokens", member.ID)
+	}
+
+	return nil
+}
+
+// SetTokenSymbol sets the ticker of a merchant's points in the token interface, such as SHOP
+func (s *MerchantContract) SetTokenSymbol(ctx TransactionContext, id string, symbol string) error {
+	merchant, err := getMerchant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(ctx) {
+		err = assertMerchantMSP(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validateLength(namedField("symbol"), symbol, maxSymbolLength)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	merchant.Program.Symbol = symbol
+	merchant.UpdatedAt = now.Format(time.RFC3339)
+
+	return putObject(ctx, merchantObjectType, id, merchant)
+}
+
+// tokenSymbol returns the ticker of a merchant's points, the upper-cased merchant ID if none was set
+func tokenSymbol(merchant *Merchant) string {
+	if merchant.Program.Symbol != "" {
+		return merchant.Program.Symbol
+	}
+
+	return strings.ToUpper(merchant.ID)
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenQueries(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+
+	name, err := env.points.Name(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "Merchant m1 Points", name)
+
+	decimals, err := env.points.Decimals(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 0, decimals)
+
+	supply, err := env.points.TotalSupply(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 100, supply)
+
+	balance, err := env.points.BalanceOf(env.ctx(adminIdentity), "alice", "m1")
+	require.NoError(t, err)
+	require.Equal(t, 100, balance)
+
+	_, err = env.points.Name(env.ctx(adminIdentity), "unknown")
+	requireErrorCode(t, err, ErrNotFound)
+
+	_, err = env.points.BalanceOf(env.ctx(adminIdentity), "nobody", "m1")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestSetTokenSymbol(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+
+	err := env.merchants.SetTokenSymbol(env.ctx(otherMSPIdentity), "m1", "SHOP")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.merchants.SetTokenSymbol(env.ctx(merchantIdentity), "m1", "TOOLONGSYMBOL")
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.NoError(t, env.merchants.SetTokenSymbol(env.ctx(merchantIdentity), "m1", "SHOP"))
+
+	symbol, err := env.points.Symbol(env.ctx(adminIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, "SHOP", symbol)
+}
+
+func TestTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	env.reward("m1", "bob", 1)
+	alice := env.registerAccount("alice")
+
+	_, err := env.points.Transfer(env.ctx(alice), "bob", 30)
+	require.NoError(t, err)
+	require.Equal(t, 70, env.balance("alice", "m1"))
+	require.Equal(t, 31, env.balance("bob", "m1"))
+
+	_, err = env.points.Transfer(env.ctx(alice), "bob", 71)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	_, err = env.points.Transfer(env.ctx(customerIdentity("carol")), "bob", 1)
+	requireErrorCode(t, err, ErrUnauthorized)
+}
+
+func TestTokenRejectsMerchantSenders(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "bob", 1)
+
+	// A binding made before merchant accounts were refused
+	mallory := customerIdentity("mallory")
+	accountKey, err := env.stub.CreateCompositeKey(accountObjectType, []string{mallory.id})
+	require.NoError(t, err)
+	ownerKey, err := env.stub.CreateCompositeKey(accountOwnerType, []string{"m1"})
+	require.NoError(t, err)
+	env.stub.startTransaction("bind")
+	require.NoError(t, env.stub.PutState(accountKey, []byte("m1")))
+	require.NoError(t, env.stub.PutState(ownerKey, []byte(mallory.id)))
+
+	_, err = env.points.Transfer(env.ctx(mallory), "bob", 1000)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.points.Approve(env.ctx(mallory), "m1", "bob", 1000)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	require.Equal(t, 1, env.balance("bob", "m1"))
+}
@@ -0,0 +1,233 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+const (
+	// transactionStatusIndex lists transaction IDs by status and type
+	transactionStatusIndex = "transactionStatus"
+	// transactionOwnerIndex lists the IDs of the transactions of each sender and receiver
+	transactionOwnerIndex = "transactionOwner"
+)
+
+// TransactionPage is a page of transactions and the bookmark to fetch the next one
+type TransactionPage struct {
+	Records []*PointsTransaction `json:"records"`
+	PageInfo
+}
+
+// QueryTransactionsByStatus returns a page of the transactions with the given status,
+// pass the bookmark of a page to fetch the next one
+func (s *PointsContract) QueryTransactionsByStatus(ctx TransactionContext, status string, pageSize int32, bookmark string) (*TransactionPage, error) {
+	return queryTransactionIndex(ctx, []string{status}, pageSize, bookmark)
+}
+
+// QueryTransactionsByStatusAndType returns a page of the transactions with the given status
+// and type, such as the pending adjustments or the confirmed campaign awards
+func (s *PointsContract) QueryTransactionsByStatusAndType(ctx TransactionContext, status string, transactionType string, pageSize int32, bookmark string) (*TransactionPage, error) {
+	return queryTransactionIndex(ctx, []string{status, transactionType}, pageSize, bookmark)
+}
+
+// ReindexTransactions adds every stored transaction to the status, owner and point expiry
+// indexes, every settlement record to the index of its transaction and every gift, adjustment
+// and hold to the owner index, for records written before the indexes existed
+func (s *AdminContract) ReindexTransactions(ctx TransactionContext) (int, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	transactions, err := readTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, transaction := range transactions {
+		err = putTransactionIndex(ctx, transaction, transactionStatus(transaction))
+		if err != nil {
+			return 0, err
+		}
+
+		err = putTransactionOwnerIndex(ctx, transaction)
+		if err != nil {
+			return 0, err
+		}
+
+		err = reindexPointLot(ctx, transaction)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = reindexSettlements(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	err = reindexObjectOwners(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(transactions), nil
+}
+
+// putTransaction writes a transaction and moves its index entry to its current status
+func putTransaction(ctx TransactionContext, transaction *PointsTransaction) error {
+	var stored PointsTransaction
+	found, err := getObject(ctx, transactionObjectType, transaction.ID, &stored)
+	if err != nil {
+		return err
+	}
+
+	if found && transactionStatus(&stored) != transactionStatus(transaction) {
+		err = delTransactionIndex(ctx, &stored)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putTransactionIndex(ctx, transaction, transactionStatus(transaction))
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		err = putTransactionOwnerIndex(ctx, transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	return putObject(ctx, transactionObjectType, transaction.ID, transaction)
+}
+
+func putTransactionIndex(ctx TransactionContext, transaction *PointsTransaction, status string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(transactionStatusIndex, []string{status, transactionType(transaction), transaction.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
+	err = ctx.GetStub().PutState(key, []byte{0x00})
+	if err != nil {
+		return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// putTransactionOwnerIndex adds a transaction to the owner index of its sender and receiver
+func putTransactionOwnerIndex(ctx TransactionContext, transaction *PointsTransaction) error {
+	for _, owner := range []string{transaction.Sender, transaction.Receiver} {
+		key, err := ctx.GetStub().CreateCompositeKey(transactionOwnerIndex, []string{owner, transaction.ID})
+		if err != nil {
+			return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// delTransactionIndex removes the index entry of a stored transaction
+func delTransactionIndex(ctx TransactionContext, transaction *PointsTransaction) error {
+	key, err := ctx.GetStub().CreateCompositeKey(transactionStatusIndex, []string{transactionStatus(transaction), transactionType(transaction), transaction.ID})
+	if err != nil {
+		return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+	}
+
+	err = ctx.GetStub().DelState(key)
+	if err != nil {
+		return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+	}
+
+	return nil
+}
+
+// transactionType returns the type of a transaction, "" for transactions without source
+func transactionType(transaction *PointsTransaction) string {
+	if transaction.Source == nil {
+		return ""
+	}
+
+	return transaction.Source.Type
+}
+
+// queryTransactionIndex returns a page of the transactions listed in the status index
+func queryTransactionIndex(ctx TransactionContext, attributes []string, pageSize int32, bookmark string) (*TransactionPage, error) {
+	return queryTransactions(ctx, transactionStatusIndex, attributes, pageSize, bookmark)
+}
+
+// queryTransactions returns a page of the transactions of an index, whose keys end with the
+// transaction ID
+func queryTransactions(ctx TransactionContext, index string, attributes []string, pageSize int32, bookmark string) (*TransactionPage, error) {
+	if pageSize <= 0 {
+		return nil, newError(ErrInvalidArgument, "page size must be positive")
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(index, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	page := TransactionPage{Records: []*PointsTransaction{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		transaction, err := getTransaction(ctx, keyAttributes[len(keyAttributes)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		page.Records = append(page.Records, transaction)
+	}
+
+	page.PageInfo, err = compositeKeyPageInfo(ctx, index, attributes, pageSize, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// readTransactions returns all stored transactions
+func readTransactions(ctx TransactionContext) ([]*PointsTransaction, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(transactionObjectType, []string{})
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	transactions := []*PointsTransaction{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		transaction := new(PointsTransaction)
+		err = unmarshalState(transactionObjectType, queryResponse.Key, queryResponse.Value, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
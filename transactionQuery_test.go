@@ -0,0 +1,70 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTransactionsByStatus(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.reward("m1", "bob", 20)
+	alice := env.registerAccount("alice")
+	_, err := env.points.CreateTransaction(env.ctx(alice), "r1", "alice", "m1", 5, "m1", "", TypeRedemption, "")
+	require.NoError(t, err)
+
+	page, err := env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusConfirmed, 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	require.Equal(t, int32(2), page.FetchedRecordsCount)
+	require.NotEmpty(t, page.Bookmark)
+	require.True(t, page.HasMore)
+
+	page, err = env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusConfirmed, 2, page.Bookmark)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Empty(t, page.Bookmark)
+	require.False(t, page.HasMore)
+
+	page, err = env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusConfirmed, 3, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 3)
+	require.False(t, page.HasMore, "a full last page has no more records")
+	require.Empty(t, page.Bookmark)
+
+	page, err = env.points.QueryTransactionsByStatusAndType(env.ctx(adminIdentity), StatusConfirmed, TypeRedemption, 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "r1", page.Records[0].ID)
+
+	page, err = env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusPending, 10, "")
+	require.NoError(t, err)
+	require.Empty(t, page.Records)
+
+	_, err = env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusConfirmed, 0, "")
+	requireErrorCode(t, err, ErrInvalidArgument)
+}
+
+func TestReindexTransactions(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	env.reward("m1", "bob", 20)
+
+	_, err := env.admin.ReindexTransactions(env.ctx(merchantIdentity))
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	reindexed, err := env.admin.ReindexTransactions(env.ctx(adminIdentity))
+	require.NoError(t, err)
+	require.Equal(t, 2, reindexed)
+
+	page, err := env.points.QueryTransactionsByStatus(env.ctx(adminIdentity), StatusConfirmed, 10, "")
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+}
@@ -0,0 +1,208 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// maxFieldLength is the longest text accepted in a parameter or field, except parameters marked long
+const maxFieldLength = 512
+
+// Rules reported in the details of validation errors
+const (
+	ruleRequired  = "required"
+	ruleKey       = "key"
+	ruleMaxLength = "maxLength"
+	rulePositive  = "positive"
+	ruleDate      = "date"
+	ruleEnum      = "enum"
+	ruleLocale    = "locale"
+)
+
+// field names a validated value in error details, and describes it in error messages
+type field struct {
+	name  string
+	label string
+}
+
+// paramField names a parameter as the contract metadata does, param0 being the first one
+func paramField(function string, i int) field {
+	return field{name: "param" + strconv.Itoa(i), label: fmt.Sprintf("parameter %d of %s", i+1, function)}
+}
+
+// namedField names a field of a JSON document
+func namedField(name string) field {
+	return field{name: name, label: name}
+}
+
+// paramRules describes the checks applied to the parameters of a function before it runs
+type paramRules struct {
+	// required are the indexes of parameters which must not be empty
+	required []int
+	// points are the indexes of parameters which must be a positive number of points
+	points []int
+	// dates are the indexes of parameters which must be dates when they are not empty
+	dates []int
+	// enums lists the values allowed for the parameters at some indexes when they are not empty
+	enums map[int][]string
+	// long are the indexes of parameters exempt from maxFieldLength, such as JSON documents
+	long []int
+}
+
+// validateParams applies the transactionRules of a function to its parameters
+func validateParams(function string, params []string) error {
+	rules, ok := transactionRules[function]
+	if !ok {
+		return nil
+	}
+
+	field := func(i int) field {
+		return paramField(function, i)
+	}
+
+	for i, param := range params {
+		if !containsIndex(rules.long, i) {
+			err := validateLength(field(i), param, maxFieldLength)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, i := range rules.required {
+		if i >= len(params) {
+			continue
+		}
+
+		err := validateKey(field(i), params[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, i := range rules.points {
+		if i >= len(params) {
+			continue
+		}
+
+		value, err := strconv.Atoi(params[i])
+		if err != nil || value <= 0 {
+			return fieldError(field(i), rulePositive, "%s must be a positive number of points, got %q", field(i).label, params[i])
+		}
+	}
+
+	for _, i := range rules.dates {
+		if i < len(params) && params[i] != "" {
+			err := validateDate(field(i), params[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, allowed := range rules.enums {
+		if i < len(params) && params[i] != "" {
+			err := validateEnum(field(i), params[i], allowed)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateKey checks that a field is not empty and can be part of a composite key
+func validateKey(f field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fieldError(f, ruleRequired, "%s must not be empty", f.label)
+	}
+
+	if strings.ContainsRune(value, 0) || strings.ContainsRune(value, utf8.MaxRune) {
+		return fieldError(f, ruleKey, "%s contains a character which is not allowed in keys", f.label)
+	}
+
+	return nil
+}
+
+// validateLength checks that a field is valid UTF-8 of at most max bytes
+func validateLength(f field, value string, max int) error {
+	if !utf8.ValidString(value) {
+		return fieldError(f, ruleMaxLength, "%s is not valid UTF-8", f.label)
+	}
+
+	if len(value) > max {
+		return fieldError(f, ruleMaxLength, "%s is longer than %d bytes", f.label, max).
+			WithDetail("max", strconv.Itoa(max))
+	}
+
+	return nil
+}
+
+// validatePositive checks that a number of points is positive
+func validatePositive(f field, value int) error {
+	if value <= 0 {
+		return fieldError(f, rulePositive, "%s must be positive, got %d", f.label, value)
+	}
+
+	return nil
+}
+
+// validateDate checks that a field is an RFC3339 date, the legacy formats of stored records are
+// not accepted as input
+func validateDate(f field, value string) error {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return fieldError(f, ruleDate, "%s must be an RFC3339 date, got %q", f.label, value)
+	}
+
+	return nil
+}
+
+// validateEnum checks that a field takes one of the allowed values
+func validateEnum(f field, value string, allowed []string) error {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return nil
+		}
+	}
+
+	return fieldError(f, ruleEnum, "%s must be one of %s, got %q", f.label, strings.Join(allowed, ", "), value).
+		WithDetail("allowed", strings.Join(allowed, ","))
+}
+
+// validateLocale checks that a field is a BCP-47 language tag and returns the tag in canonical
+// form, such as zh-CN for zh_cn
+func validateLocale(f field, value string) (string, error) {
+	tag, err := language.Parse(value)
+	if err != nil || tag == language.Und {
+		return "", fieldError(f, ruleLocale, "%s must be a BCP-47 language tag such as zh-CN, got %q", f.label, value)
+	}
+
+	return tag.String(), nil
+}
+
+// fieldError returns an ErrInvalidArgument error naming the field and the rule it breaks
+func fieldError(f field, rule string, format string, args ...interface{}) *ContractError {
+	return newError(ErrInvalidArgument, format, args...).
+		WithDetail("field", f.name).
+		WithDetail("rule", rule)
+}
+
+func containsIndex(indexes []int, i int) bool {
+	for _, index := range indexes {
+		if index == i {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireFieldError checks that err is an invalid argument error naming the field and the rule it breaks
+func requireFieldError(t *testing.T, err error, field string, rule string) {
+	t.Helper()
+	requireErrorCode(t, err, ErrInvalidArgument)
+	contractErr := err.(*ContractError)
+	require.Equal(t, field, contractErr.Details["field"])
+	require.Equal(t, rule, contractErr.Details["rule"])
+}
+
+func TestValidateParams(t *testing.T) {
+	valid := []string{"t1", "m1", "alice", "10", "m1", "2024-03-15T10:00:00Z", TypeOrder, "o1"}
+	require.NoError(t, validateParams("CreateTransaction", valid))
+
+	params := append([]string{}, valid...)
+	params[0] = " "
+	requireFieldError(t, validateParams("CreateTransaction", params), "param0", ruleRequired)
+
+	params = append([]string{}, valid...)
+	params[1] = "m\x001"
+	requireFieldError(t, validateParams("CreateTransaction", params), "param1", ruleKey)
+
+	params = append([]string{}, valid...)
+	params[3] = "ten"
+	requireFieldError(t, validateParams("CreateTransaction", params), "param3", rulePositive)
+
+	params = append([]string{}, valid...)
+	params[5] = "next week"
+	requireFieldError(t, validateParams("CreateTransaction", params), "param5", ruleDate)
+
+	params = append([]string{}, valid...)
+	params[6] = "Bonus"
+	err := validateParams("CreateTransaction", params)
+	requireFieldError(t, err, "param6", ruleEnum)
+	require.Contains(t, err.(*ContractError).Details["allowed"], TypeOrder)
+
+	params = append([]string{}, valid...)
+	params[7] = strings.Repeat("o", maxFieldLength+1)
+	requireFieldError(t, validateParams("CreateTransaction", params), "param7", ruleMaxLength)
+
+	params = append([]string{}, valid...)
+	params[5] = ""
+	require.NoError(t, validateParams("CreateTransaction", params), "optional dates may be empty")
+
+	require.NoError(t, validateParams("CreateTransactionsBatch", []string{strings.Repeat("[]", maxFieldLength)}), "long parameters are not limited")
+	require.NoError(t, validateParams("UnknownFunction", []string{""}))
+}
+
+func TestValidateParamsMessages(t *testing.T) {
+	err := validateParams("GetMember", []string{""})
+	requireFieldError(t, err, "param0", ruleRequired)
+	require.Equal(t, "parameter 1 of GetMember must not be empty", err.(*ContractError).Message)
+
+	err = validateParams("UpdateStatus", []string{"t1", "done"})
+	requireFieldError(t, err, "param1", ruleEnum)
+	require.Contains(t, err.(*ContractError).Message, "parameter 2 of UpdateStatus must be one of")
+}
@@ -0,0 +1,252 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	velocityLimitsObjectType = "velocityLimits"
+	velocityUsageObjectType  = "velocityUsage"
+	velocityConfigID         = "velocity"
+
+	// supportRole is the value of the role attribute of support staff, whose transactions
+	// are not subject to velocity limits
+	supportRole = "support"
+
+	velocityDayLayout  = "2006-01-02"
+	velocityHourLayout = "2006-01-02T15"
+)
+
+// VelocityLimits caps how fast an owner may earn and transfer points, 0 means no limit
+type VelocityLimits struct {
+	Schema
+	Owner               string `json:"owner"`
+	MaxEarnedPerDay     int    `json:"maxEarnedPerDay"`
+	MaxTransfersPerHour int    `json:"maxTransfersPerHour"`
+	UpdatedBy           string `json:"updatedBy"`
+	UpdatedAt           string `json:"updated_at"`
+}
+
+// VelocityUsage counts the points an owner earned in the current day and the transfers
+// made in the current hour, in UTC
+type VelocityUsage struct {
+	Schema
+	Owner     string `json:"owner"`
+	Day       string `json:"day"`
+	Earned    int    `json:"earned"`
+	Hour      string `json:"hour"`
+	Transfers int    `json:"transfers"`
+	// deltaKeys are the earned points added to the usage read, deleted when it is written
+	deltaKeys []string
+}
+
+// SetDefaultVelocityLimits sets the velocity limits of owners without limits of their own
+func (s *AdminContract) SetDefaultVelocityLimits(ctx TransactionContext, maxEarnedPerDay int, maxTransfersPerHour int) error {
+	limits, err := newVelocityLimits(ctx, "", maxEarnedPerDay, maxTransfersPerHour)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, configObjectType, velocityConfigID, limits)
+}
+
+// SetVelocityLimits sets the velocity limits of an owner, replacing the default limits
+func (s *AdminContract) SetVelocityLimits(ctx TransactionContext, owner string, maxEarnedPerDay int, maxTransfersPerHour int) error {
+	_, err := getMember(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	limits, err := newVelocityLimits(ctx, owner, maxEarnedPerDay, maxTransfersPerHour)
+	if err != nil {
+		return err
+	}
+
+	return putObject(ctx, velocityLimitsObjectType, owner, limits)
+}
+
+// GetVelocityLimits returns the velocity limits applied to an owner
+func (s *AdminContract) GetVelocityLimits(ctx TransactionContext, owner string) (*VelocityLimits, error) {
+	return getVelocityLimits(ctx, owner)
+}
+
+// GetVelocityUsage returns the points earned today and the transfers made this hour by an owner
+func (s *AdminContract) GetVelocityUsage(ctx TransactionContext, owner string) (*VelocityUsage, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return getVelocityUsage(ctx, owner, now)
+}
+
+func newVelocityLimits(ctx TransactionContext, owner string, maxEarnedPerDay int, maxTransfersPerHour int) (*VelocityLimits, error) {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxEarnedPerDay < 0 || maxTransfersPerHour < 0 {
+		return nil, newError(ErrInvalidArgument, "velocity limits must not be negative")
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VelocityLimits{
+		Owner:               owner,
+		MaxEarnedPerDay:     maxEarnedPerDay,
+		MaxTransfersPerHour: maxTransfersPerHour,
+		UpdatedBy:           clientID,
+		UpdatedAt:           now.Format(time.RFC3339),
+	}, nil
+}
+
+// getVelocityLimits returns the limits of an owner, or the default limits if the owner has none
+func getVelocityLimits(ctx TransactionContext, owner string) (*VelocityLimits, error) {
+	var limits VelocityLimits
+	found, err := getObject(ctx, velocityLimitsObjectType, owner, &limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		return &limits, nil
+	}
+
+	_, err = getObject(ctx, configObjectType, velocityConfigID, &limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// getVelocityUsage returns the usage of an owner in the day and hour of now, with the points
+// earned as deltas
+func getVelocityUsage(ctx TransactionContext, owner string, now time.Time) (*VelocityUsage, error) {
+	var usage VelocityUsage
+	_, err := getObject(ctx, velocityUsageObjectType, owner, &usage)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Owner = owner
+
+	day := now.UTC().Format(velocityDayLayout)
+	if usage.Day != day {
+		usage.Day = day
+		usage.Earned = 0
+	}
+
+	deltas, keys, err := getCounterDeltas(ctx, velocityUsageObjectType, []string{owner})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, delta := range deltas {
+		if delta.Period == day {
+			usage.Earned += delta.Value
+		}
+	}
+	usage.deltaKeys = keys
+
+	hour := now.UTC().Format(velocityHourLayout)
+	if usage.Hour != hour {
+		usage.Hour = hour
+		usage.Transfers = 0
+	}
+
+	return &usage, nil
+}
+
+// recordVelocity adds earned points and transfers to the usage of an owner, rejecting them with
+// an ErrVelocityLimitExceeded error if they exceed the owner's limits. Support staff may exceed them.
+func recordVelocity(ctx TransactionContext, owner string, earned int, transfers int) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage, err := getVelocityUsage(ctx, owner, now)
+	if err != nil {
+		return err
+	}
+
+	usage.Earned += earned
+	usage.Transfers += transfers
+
+	if !isSupport(ctx) {
+		limits, err := getVelocityLimits(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		if earned > 0 && limits.MaxEarnedPerDay > 0 && usage.Earned > limits.MaxEarnedPerDay {
+			return newError(ErrVelocityLimitExceeded, "%s may earn at most %d points per day", owner, limits.MaxEarnedPerDay).
+				WithDetail("owner", owner).
+				WithDetail("limit", "maxEarnedPerDay").
+				WithDetail("max", strconv.Itoa(limits.MaxEarnedPerDay))
+		}
+
+		if transfers > 0 && limits.MaxTransfersPerHour > 0 && usage.Transfers > limits.MaxTransfersPerHour {
+			return newError(ErrVelocityLimitExceeded, "%s may make at most %d transfers per hour", owner, limits.MaxTransfersPerHour).
+				WithDetail("owner", owner).
+				WithDetail("limit", "maxTransfersPerHour").
+				WithDetail("max", strconv.Itoa(limits.MaxTransfersPerHour))
+		}
+	}
+
+	return putVelocityUsage(ctx, usage)
+}
+
+// deferVelocity adds points an owner earned by a transaction to its usage as a delta, without
+// reading the usage. Owners with a limit on earned points are checked against their usage instead.
+func deferVelocity(ctx TransactionContext, transactionID string, owner string, earned int) error {
+	if !isSupport(ctx) {
+		limits, err := getVelocityLimits(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		if limits.MaxEarnedPerDay > 0 {
+			return recordVelocity(ctx, owner, earned, 0)
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	delta := CounterDelta{Value: earned, Period: now.UTC().Format(velocityDayLayout)}
+	return putCounterDelta(ctx, velocityUsageObjectType, []string{owner}, transactionID, &delta)
+}
+
+// putVelocityUsage writes the usage of an owner with the deltas it was read with
+func putVelocityUsage(ctx TransactionContext, usage *VelocityUsage) error {
+	err := deleteDeltas(ctx, usage.deltaKeys)
+	if err != nil {
+		return err
+	}
+
+	usage.deltaKeys = nil
+	return putObject(ctx, velocityUsageObjectType, usage.Owner, usage)
+}
+
+// isSupport reports whether the caller was enrolled with the support role
+func isSupport(ctx TransactionContext) bool {
+	return ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, supportRole) == nil
+}
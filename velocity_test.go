@@ -0,0 +1,61 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetVelocityLimits(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+
+	err := env.admin.SetVelocityLimits(env.ctx(merchantIdentity), "alice", 50, 1)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.SetVelocityLimits(env.ctx(adminIdentity), "alice", -1, 1)
+	requireErrorCode(t, err, ErrInvalidArgument)
+
+	err = env.admin.SetVelocityLimits(env.ctx(adminIdentity), "nobody", 50, 1)
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.admin.SetDefaultVelocityLimits(env.ctx(adminIdentity), 1000, 10))
+	require.NoError(t, env.admin.SetVelocityLimits(env.ctx(adminIdentity), "alice", 50, 1))
+
+	limits, err := env.admin.GetVelocityLimits(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 50, limits.MaxEarnedPerDay)
+
+	limits, err = env.admin.GetVelocityLimits(env.ctx(adminIdentity), "bob")
+	require.NoError(t, err)
+	require.Equal(t, 1000, limits.MaxEarnedPerDay, "owners without limits get the default limits")
+}
+
+func TestVelocityLimitExceeded(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 10)
+	require.NoError(t, env.admin.SetVelocityLimits(env.ctx(adminIdentity), "alice", 50, 0))
+
+	env.reward("m1", "alice", 40)
+
+	usage, err := env.admin.GetVelocityUsage(env.ctx(adminIdentity), "alice")
+	require.NoError(t, err)
+	require.Equal(t, 50, usage.Earned)
+
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t1", "m1", "alice", 11, "m1", "", TypeOrder, "o1")
+	requireErrorCode(t, err, ErrVelocityLimitExceeded)
+
+	_, err = env.points.CreateTransaction(env.ctx(supportIdentity), "t2", "m1", "alice", 11, "m1", "", TypeOrder, "o2")
+	require.NoError(t, err, "support staff may exceed the limits")
+
+	env.advance(24 * time.Hour)
+	_, err = env.points.CreateTransaction(env.ctx(merchantIdentity), "t3", "m1", "alice", 50, "m1", "", TypeOrder, "o3")
+	require.NoError(t, err)
+}
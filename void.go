@@ -0,0 +1,90 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+// VoidTransaction cancels a confirmed transaction recorded in error: it undoes its balance effect,
+// removes it from the settlement reports and program statistics, frees the order it rewarded, drops
+// the commission of its stockist and keeps the record, marked voided with who voided it, when and
+// why, instead of deleting it
+func (s *AdminContract) VoidTransaction(ctx TransactionContext, txKey string, reason string) error {
+	err := assertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := getTransaction(ctx, txKey)
+	if err != nil {
+		return err
+	}
+
+	switch transactionType(transaction) {
+	case TypeReversal, TypeConversion, TypeBridgeLock, TypeBurn:
+		return newError(ErrInvalidState, "%s transaction %s cannot be voided", transactionType(transaction), txKey)
+	}
+
+	err = transitionStatus(transaction, StatusVoided)
+	if err != nil {
+		return err
+	}
+
+	settlements, err := getTransactionSettlements(ctx, txKey)
+	if err != nil {
+		return err
+	}
+
+	err = applyTransaction(ctx, transaction, -transaction.Value, transaction.Merchant)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	// applyTransaction recorded the undone points under the key of the transaction in this month,
+	// the records are deleted along with those of the transaction
+	for _, attributes := range settlements {
+		current := []string{attributes[0], now.Format(settlementMonth), attributes[2], attributes[3]}
+
+		for _, keyAttributes := range [][]string{attributes, current} {
+			err = deleteSettlement(ctx, keyAttributes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The order may be rewarded again by a corrected transaction, which accrues the commission again
+	if isOrderReward(transaction) {
+		key, err := ctx.GetStub().CreateCompositeKey(orderObjectType, orderRewardKey(transaction))
+		if err != nil {
+			return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+		}
+
+		err = dropCommission(ctx, transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	clientID, _, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction.VoidedBy = clientID
+	transaction.VoidedAt = now.Format(time.RFC3339)
+	transaction.VoidReason = reason
+
+	return putTransaction(ctx, transaction)
+}
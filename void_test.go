@@ -0,0 +1,67 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoidTransaction(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	order := env.reward("m1", "alice", 30)
+
+	err := env.admin.VoidTransaction(env.ctx(merchantIdentity), order, "duplicate")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	err = env.admin.VoidTransaction(env.ctx(adminIdentity), "missing", "duplicate")
+	requireErrorCode(t, err, ErrNotFound)
+
+	require.NoError(t, env.admin.VoidTransaction(env.ctx(adminIdentity), order, "duplicate"))
+	require.Equal(t, 100, env.balance("alice", "m1"))
+
+	transaction, err := env.points.GetTransaction(env.ctx(adminIdentity), order)
+	require.NoError(t, err)
+	require.Equal(t, StatusVoided, transaction.Status)
+
+	_, err = env.points.GetOrderReward(env.ctx(adminIdentity), "m1", order)
+	requireErrorCode(t, err, ErrNotFound)
+
+	stats, err := env.merchants.GetProgramStats(env.ctx(merchantIdentity), "m1")
+	require.NoError(t, err)
+	require.Equal(t, 100, stats.Issued)
+
+	err = env.admin.VoidTransaction(env.ctx(adminIdentity), order, "duplicate")
+	requireErrorCode(t, err, ErrInvalidState)
+}
+
+func TestVoidTransactionReindexedSettlements(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	order := env.reward("m1", "alice", 30)
+
+	// Settlement records written before the index existed
+	indexKey, err := env.stub.CreateCompositeKey(settlementTransactionIndex, []string{order, "m1", env.stub.now.Format(settlementMonth), settlementIssued})
+	require.NoError(t, err)
+	env.stub.startTransaction("legacy")
+	require.NoError(t, env.stub.DelState(indexKey))
+
+	_, err = env.admin.ReindexTransactions(env.ctx(adminIdentity))
+	require.NoError(t, err)
+
+	require.NoError(t, env.admin.VoidTransaction(env.ctx(adminIdentity), order, "duplicate"))
+
+	month := env.stub.now.Format(settlementMonth)
+	report, err := env.merchants.GetSettlementReport(env.ctx(merchantIdentity), "m1", month, month)
+	require.NoError(t, err)
+	require.Equal(t, 0, report.Total.Issued)
+
+	value, err := env.stub.GetState(indexKey)
+	require.NoError(t, err)
+	require.Nil(t, value, "the index entry is deleted with the record")
+}
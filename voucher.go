@@ -0,0 +1,248 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "time"
+
+const (
+	voucherObjectType = "voucher"
+
+	// voucherOwnerIndex and voucherStatusIndex list voucher IDs by owner and status
+	voucherOwnerIndex  = "voucherOwner"
+	voucherStatusIndex = "voucherStatus"
+
+	voucherIssuedEvent   = "VoucherIssued"
+	voucherRedeemedEvent = "VoucherRedeemed"
+)
+
+// Voucher statuses
+const (
+	VoucherIssued   = "issued"
+	VoucherRedeemed = "redeemed"
+)
+
+// voucherStatuses are the values accepted as voucher statuses
+var voucherStatuses = []string{VoucherIssued, VoucherRedeemed}
+
+// Voucher is a single-use coupon of a merchant bought by a customer with points
+type Voucher struct {
+	Schema
+	ID         string `json:"ID"`
+	Owner      string `json:"owner"`
+	Merchant   string `json:"merchant"`
+	Name       string `json:"name"`
+	Cost       int    `json:"cost"`
+	Status     string `json:"status"`
+	IssuedAt   string `json:"issued_at"`
+	RedeemedAt string `json:"redeemed_at,omitempty" metadata:"redeemed_at,optional"`
+}
+
+// IssueVoucher sells a voucher of a merchant to the owner for cost points
+func (s *PointsContract) IssueVoucher(ctx TransactionContext, id string, owner string, merchant string, name string, cost int) error {
+	existing, err := getVoucher(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return newError(ErrAlreadyExists, "voucher %s already exists", id)
+	}
+
+	err = assertAccountOwner(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	transaction := PointsTransaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Value:     cost,
+		Merchant:  merchant,
+		CreatedAt: now.Format(time.RFC3339),
+		Sender:    owner,
+		Receiver:  merchant,
+		Source:    &Source{Type: TypeVoucher, ID: id},
+		Status:    StatusConfirmed,
+	}
+
+	err = createTransaction(ctx, &transaction)
+	if err != nil {
+		return err
+	}
+
+	voucher := Voucher{
+		ID:       id,
+		Owner:    owner,
+		Merchant: merchant,
+		Name:     name,
+		Cost:     cost,
+		Status:   VoucherIssued,
+		IssuedAt: now.Format(time.RFC3339),
+	}
+
+	err = putVoucher(ctx, &voucher, "")
+	if err != nil {
+		return err
+	}
+
+	return setVoucherEvent(ctx, voucherIssuedEvent, &voucher)
+}
+
+// RedeemVoucher marks a voucher as used, only the issuing merchant may redeem it and only once
+func (s *PointsContract) RedeemVoucher(ctx TransactionContext, id string) error {
+	voucher, err := s.GetVoucher(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = assertMerchantMSP(ctx, voucher.Merchant)
+	if err != nil {
+		return err
+	}
+
+	if voucher.Status != VoucherIssued {
+		return newError(ErrInvalidState, "voucher %s is already %s", id, voucher.Status)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	voucher.Status = VoucherRedeemed
+	voucher.RedeemedAt = now.Format(time.RFC3339)
+
+	err = putVoucher(ctx, voucher, VoucherIssued)
+	if err != nil {
+		return err
+	}
+
+	return setVoucherEvent(ctx, voucherRedeemedEvent, voucher)
+}
+
+// GetVoucher returns the voucher stored in the world state with given id
+func (s *PointsContract) GetVoucher(ctx TransactionContext, id string) (*Voucher, error) {
+	voucher, err := getVoucher(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if voucher == nil {
+		return nil, newError(ErrNotFound, "voucher %s does not exist in world state", id)
+	}
+
+	return voucher, nil
+}
+
+// GetVouchersByOwner returns the vouchers of an owner, only those with the given status if it is not empty
+func (s *PointsContract) GetVouchersByOwner(ctx TransactionContext, owner string, status string) ([]*Voucher, error) {
+	attributes := []string{owner}
+	if status != "" {
+		attributes = append(attributes, status)
+	}
+
+	return getIndexedVouchers(ctx, voucherOwnerIndex, attributes)
+}
+
+// GetVouchersByStatus returns all vouchers with the given status
+func (s *PointsContract) GetVouchersByStatus(ctx TransactionContext, status string) ([]*Voucher, error) {
+	return getIndexedVouchers(ctx, voucherStatusIndex, []string{status})
+}
+
+// getVoucher returns the voucher with given id, or nil if it does not exist
+func getVoucher(ctx TransactionContext, id string) (*Voucher, error) {
+	var voucher Voucher
+	found, err := getObject(ctx, voucherObjectType, id, &voucher)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+// putVoucher stores the voucher and moves its index entries from previousStatus to its status
+func putVoucher(ctx TransactionContext, voucher *Voucher, previousStatus string) error {
+	if previousStatus != "" {
+		for _, index := range voucherIndexes(voucher, previousStatus) {
+			key, err := ctx.GetStub().CreateCompositeKey(index.objectType, index.attributes)
+			if err != nil {
+				return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+			}
+
+			err = ctx.GetStub().DelState(key)
+			if err != nil {
+				return newError(ErrInternal, "failed to delete from world state. %s", err.Error())
+			}
+		}
+	}
+
+	for _, index := range voucherIndexes(voucher, voucher.Status) {
+		key, err := ctx.GetStub().CreateCompositeKey(index.objectType, index.attributes)
+		if err != nil {
+			return newError(ErrInternal, "failed to create composite key. %s", err.Error())
+		}
+
+		// Pass []byte{0x00} as null value, as pass a 'nil' value will effectively delete the key from state
+		err = ctx.GetStub().PutState(key, []byte{0x00})
+		if err != nil {
+			return newError(ErrInternal, "failed to put to world state. %s", err.Error())
+		}
+	}
+
+	return putObject(ctx, voucherObjectType, voucher.ID, voucher)
+}
+
+type voucherIndex struct {
+	objectType string
+	attributes []string
+}
+
+func voucherIndexes(voucher *Voucher, status string) []voucherIndex {
+	return []voucherIndex{
+		{voucherOwnerIndex, []string{voucher.Owner, status, voucher.ID}},
+		{voucherStatusIndex, []string{status, voucher.ID}},
+	}
+}
+
+// getIndexedVouchers returns the vouchers listed in an index under the given attributes
+func getIndexedVouchers(ctx TransactionContext, index string, attributes []string) ([]*Voucher, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, attributes)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+	}
+	defer resultsIterator.Close()
+
+	vouchers := []*Voucher{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to read from world state. %s", err.Error())
+		}
+
+		_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, newError(ErrInternal, "failed to split composite key. %s", err.Error())
+		}
+
+		voucher, err := getVoucher(ctx, keyAttributes[len(keyAttributes)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		if voucher != nil {
+			vouchers = append(vouchers, voucher)
+		}
+	}
+
+	return vouchers, nil
+}
+
+func setVoucherEvent(ctx TransactionContext, name string, voucher *Voucher) error {
+	return emitEvent(ctx, name, &VoucherEvent{Voucher: *voucher})
+}
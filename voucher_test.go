@@ -0,0 +1,70 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueVoucher(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+
+	require.NoError(t, env.points.IssueVoucher(env.ctx(alice), "v1", "alice", "m1", "Coffee", 30))
+	env.requireEvent(voucherIssuedEvent, nil)
+	require.Equal(t, 70, env.balance("alice", "m1"))
+
+	voucher, err := env.points.GetVoucher(env.ctx(alice), "v1")
+	require.NoError(t, err)
+	require.Equal(t, VoucherIssued, voucher.Status)
+
+	err = env.points.IssueVoucher(env.ctx(alice), "v1", "alice", "m1", "Coffee", 30)
+	requireErrorCode(t, err, ErrAlreadyExists)
+
+	err = env.points.IssueVoucher(env.ctx(alice), "v2", "alice", "m1", "Dinner", 71)
+	requireErrorCode(t, err, ErrInsufficientPoints)
+
+	err = env.points.IssueVoucher(env.ctx(customerIdentity("bob")), "v3", "alice", "m1", "Coffee", 30)
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	_, err = env.points.GetVoucher(env.ctx(alice), "missing")
+	requireErrorCode(t, err, ErrNotFound)
+}
+
+func TestRedeemVoucher(t *testing.T) {
+	env := newTestEnv(t)
+	env.registerMerchant("m1")
+	env.reward("m1", "alice", 100)
+	alice := env.registerAccount("alice")
+	require.NoError(t, env.points.IssueVoucher(env.ctx(alice), "v1", "alice", "m1", "Coffee", 30))
+	require.NoError(t, env.points.IssueVoucher(env.ctx(alice), "v2", "alice", "m1", "Tea", 20))
+
+	err := env.points.RedeemVoucher(env.ctx(otherMSPIdentity), "v1")
+	requireErrorCode(t, err, ErrUnauthorized)
+
+	require.NoError(t, env.points.RedeemVoucher(env.ctx(merchantIdentity), "v1"))
+	env.requireEvent(voucherRedeemedEvent, nil)
+
+	err = env.points.RedeemVoucher(env.ctx(merchantIdentity), "v1")
+	requireErrorCode(t, err, ErrInvalidState)
+
+	vouchers, err := env.points.GetVouchersByOwner(env.ctx(alice), "alice", "")
+	require.NoError(t, err)
+	require.Len(t, vouchers, 2)
+
+	vouchers, err = env.points.GetVouchersByOwner(env.ctx(alice), "alice", VoucherIssued)
+	require.NoError(t, err)
+	require.Len(t, vouchers, 1)
+	require.Equal(t, "v2", vouchers[0].ID)
+
+	vouchers, err = env.points.GetVouchersByStatus(env.ctx(adminIdentity), VoucherRedeemed)
+	require.NoError(t, err)
+	require.Len(t, vouchers, 1)
+	require.Equal(t, "v1", vouchers[0].ID)
+}